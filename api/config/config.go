@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -19,6 +20,9 @@ type Config struct {
 	PostgresPassword string
 	PostgresDB       string
 	PostgresSSLMode  string
+	// PostgresRLS scopes tenant requests with row-level security; the
+	// Postgres user must not be a superuser
+	PostgresRLS bool
 
 	// DynamoDB
 	DynamoEndpoint string
@@ -37,6 +41,16 @@ type Config struct {
 	GoogleClientID     string
 	GoogleClientSecret string
 	GoogleRedirectURL  string
+
+	// Email (logged instead of sent when SMTPHost is empty)
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// WebSocketOrigins are the browser origins allowed to open WebSockets
+	WebSocketOrigins []string
 }
 
 func Load() (*Config, error) {
@@ -53,6 +67,7 @@ func Load() (*Config, error) {
 		PostgresPassword: getEnv("POSTGRES_PASSWORD", "postgres"),
 		PostgresDB:       getEnv("POSTGRES_DB", "base"),
 		PostgresSSLMode:  getEnv("POSTGRES_SSL_MODE", "disable"),
+		PostgresRLS:      getEnvBool("POSTGRES_RLS", false),
 
 		DynamoEndpoint: getEnv("DYNAMO_ENDPOINT", "http://localhost:8000"),
 		DynamoRegion:   getEnv("DYNAMO_REGION", "us-east-1"),
@@ -67,6 +82,14 @@ func Load() (*Config, error) {
 		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
 		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
 		GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "http://localhost:5173/auth/google/callback"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+
+		WebSocketOrigins: getEnvList("WEBSOCKET_ORIGINS", []string{"http://localhost:5173"}),
 	}
 
 	return cfg, nil
@@ -99,3 +122,25 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvList(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/jmoiron/sqlx"
+
+	"base/api/internal/middleware"
+)
+
+type contextKey string
+
+const ipContextKey contextKey = "audit_ip"
+
+// Data is a JSON document stored in a JSONB column.
+type Data json.RawMessage
+
+func (d Data) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+func (d *Data) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		*d = append((*d)[:0], v...)
+	case string:
+		*d = Data(v)
+	case nil:
+		*d = nil
+	default:
+		return fmt.Errorf("cannot scan %T into audit.Data", src)
+	}
+	return nil
+}
+
+func (d Data) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return []byte("null"), nil
+	}
+	return d, nil
+}
+
+// Event is a recorded audit event.
+type Event struct {
+	ID             string    `json:"id" db:"id"`
+	OrganizationID string    `json:"organization_id" db:"organization_id"`
+	ActorID        *string   `json:"actor_id" db:"actor_id"`
+	Action         string    `json:"action" db:"action"`
+	TargetType     string    `json:"target_type" db:"target_type"`
+	TargetID       *string   `json:"target_id" db:"target_id"`
+	Before         Data      `json:"before" db:"before"`
+	After          Data      `json:"after" db:"after"`
+	IP             *string   `json:"ip" db:"ip"`
+	RequestID      *string   `json:"request_id" db:"request_id"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// Entry describes a mutation to record. Before and After are marshaled to JSON;
+// actor, IP and request ID are taken from the context.
+type Entry struct {
+	OrganizationID string
+	Action         string
+	TargetType     string
+	TargetID       string
+	Before         any
+	After          any
+}
+
+// Record appends an audit event using exec, which should be the transaction
+// performing the mutation so the event commits or rolls back with it.
+func Record(ctx context.Context, exec sqlx.ExecerContext, e Entry) error {
+	before, err := marshal(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshal(e.After)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_events (organization_id, actor_id, action, target_type, target_id, before, after, ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = exec.ExecContext(ctx, query,
+		e.OrganizationID,
+		nullable(actorID(ctx)),
+		e.Action,
+		e.TargetType,
+		nullable(e.TargetID),
+		before,
+		after,
+		nullable(clientIP(ctx)),
+		nullable(chimiddleware.GetReqID(ctx)),
+	)
+	return err
+}
+
+// Middleware captures the client IP for audit events recorded during the request.
+// It must run after chi's RealIP middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		ctx := context.WithValue(r.Context(), ipContextKey, ip)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func actorID(ctx context.Context) string {
+	if usr := middleware.GetUserFromContext(ctx); usr != nil {
+		return usr.ID
+	}
+	return ""
+}
+
+func clientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ipContextKey).(string)
+	return ip
+}
+
+func marshal(v any) (Data, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Data(b), nil
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
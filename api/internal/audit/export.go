@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"context"
+
+	"base/api/internal/export"
+)
+
+// RegisterExporters adds the audit events a user performed, or that were
+// performed on them, to personal data exports.
+func RegisterExporters(reg *export.Registry, repo *Repository) {
+	reg.Register("audit_events", export.ExporterFunc(repo.exportEvents))
+}
+
+func (r *Repository) exportEvents(ctx context.Context, userID string) (any, error) {
+	events := []Event{}
+	query := `
+		SELECT ` + eventColumns + ` FROM audit_events
+		WHERE actor_id = $1 OR target_id = $1::text
+		ORDER BY created_at, id
+	`
+	err := r.postgres.SelectContext(ctx, &events, query, userID)
+	return events, err
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"base/api/internal/database"
+	"base/api/pkg/pagination"
+)
+
+// Filter narrows the audit log of one organization.
+type Filter struct {
+	OrganizationID string
+	ActorID        string
+	Action         string
+	From           time.Time
+	To             time.Time
+}
+
+type Repository struct {
+	postgres *database.PostgresDB
+}
+
+func NewRepository(postgres *database.PostgresDB) *Repository {
+	return &Repository{postgres: postgres}
+}
+
+const eventColumns = `id, organization_id, actor_id, action, target_type, target_id, before, after, ip, request_id, created_at`
+
+// EventsPage lists audit events, newest first.
+var EventsPage = pagination.Spec{
+	Sorts: []pagination.Sort{
+		{Key: "created", Columns: []pagination.Column{{Expr: "created_at", Type: "timestamptz"}}, Desc: true},
+	},
+	IDColumn:     "id",
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+// List returns one page of matching events and the cursor for the next.
+func (r *Repository) List(ctx context.Context, f Filter, p pagination.Params) ([]Event, string, error) {
+	where, args := f.where()
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
+	query := `SELECT ` + eventColumns + ` FROM audit_events WHERE ` + strings.Join(where, " AND ") +
+		` AND ` + keyset + ` ` + order
+
+	var events []Event
+	if err := r.postgres.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	events, next := pagination.Page(p, events, func(e Event) ([]any, string) {
+		return []any{e.CreatedAt}, e.ID
+	})
+	return events, next, nil
+}
+
+// Stream calls fn for every matching event, newest first, without buffering
+// the whole result. Used for exports.
+func (r *Repository) Stream(ctx context.Context, f Filter, fn func(*Event) error) error {
+	where, args := f.where()
+	query := `SELECT ` + eventColumns + ` FROM audit_events WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY created_at DESC, id DESC`
+
+	// Rows are read inside a transaction so they stay in the tenant's scope
+	tx, err := r.postgres.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Event
+		if err := rows.StructScan(&e); err != nil {
+			return err
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (f Filter) where() ([]string, []any) {
+	where := []string{"organization_id = $1"}
+	args := []any{f.OrganizationID}
+
+	if f.ActorID != "" {
+		args = append(args, f.ActorID)
+		where = append(where, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+	if f.Action != "" {
+		args = append(args, f.Action)
+		where = append(where, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		where = append(where, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		where = append(where, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	return where, args
+}
@@ -0,0 +1,97 @@
+// Package dbtest connects tests to a migrated PostgreSQL database. Tests that
+// use it are skipped unless TEST_POSTGRES_DSN is set.
+package dbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"base/api/internal/database"
+)
+
+// Open connects to the test database with row-level security enabled.
+func Open(t *testing.T) *database.PostgresDB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	db, err := database.NewPostgres(dsn, nil, false)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.EnableRowLevelSecurity()
+	return db
+}
+
+// RequireRowLevelSecurity skips the test when the database role is exempt
+// from row-level security policies.
+func RequireRowLevelSecurity(t *testing.T, db *database.PostgresDB) {
+	t.Helper()
+	var exempt bool
+	query := `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`
+	if err := db.GetContext(context.Background(), &exempt, query); err != nil {
+		t.Fatalf("check role: %v", err)
+	}
+	if exempt {
+		t.Skip("database role bypasses row-level security")
+	}
+}
+
+// Context returns a context whose statements span organizations, for setup
+// and cleanup.
+func Context() context.Context {
+	return database.WithoutTenant(context.Background())
+}
+
+// CreateUser inserts a user and returns its ID. It is deleted with the test.
+func CreateUser(t *testing.T, db *database.PostgresDB) string {
+	t.Helper()
+	var id string
+	query := `INSERT INTO users (email, name) VALUES ($1, 'Test User') RETURNING id`
+	if err := db.GetContext(Context(), &id, query, "test-"+suffix()+"@example.com"); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() {
+		db.ExecContext(Context(), `DELETE FROM users WHERE id = $1`, id)
+	})
+	return id
+}
+
+// CreateOrganization inserts an organization owned by ownerID and returns
+// its ID. It is deleted with the test.
+func CreateOrganization(t *testing.T, db *database.PostgresDB, ownerID string) string {
+	t.Helper()
+	ctx := Context()
+	var id string
+	query := `INSERT INTO organizations (name, slug, created_by) VALUES ('Test Org', $1, $2) RETURNING id`
+	if err := db.GetContext(ctx, &id, query, "test-"+suffix(), ownerID); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	// Registered after the owner so it runs first
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM organizations WHERE id = $1`, id)
+		db.ExecContext(ctx, `DELETE FROM audit_events WHERE organization_id = $1`, id)
+	})
+	AddMember(t, db, id, ownerID, "owner")
+	return id
+}
+
+// AddMember adds userID to the organization with role.
+func AddMember(t *testing.T, db *database.PostgresDB, orgID, userID, role string) {
+	t.Helper()
+	query := `INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err := db.ExecContext(Context(), query, orgID, userID, role); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+}
+
+func suffix() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -12,9 +12,13 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// PostgresDB is the connection pool. It offers only the query methods that
+// honour the context's tenant scope (see rls.go); the underlying *sqlx.DB is
+// not exposed, so no statement can skip row-level security by accident.
 type PostgresDB struct {
-	*sqlx.DB
+	db   *sqlx.DB
 	pool *pgxpool.Pool
+	rls  bool
 }
 
 type slogAdapter struct {
@@ -56,14 +60,14 @@ func NewPostgres(dsn string, logger *slog.Logger, debug bool) (*PostgresDB, erro
 	// Wrap pool for sqlx compatibility
 	db := sqlx.NewDb(stdlib.OpenDBFromPool(pool), "pgx")
 
-	return &PostgresDB{DB: db, pool: pool}, nil
+	return &PostgresDB{db: db, pool: pool}, nil
 }
 
 func (db *PostgresDB) Health(ctx context.Context) error {
-	return db.PingContext(ctx)
+	return db.db.PingContext(ctx)
 }
 
 func (db *PostgresDB) Close() error {
 	db.pool.Close()
-	return db.DB.Close()
+	return db.db.Close()
 }
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Tenant identifies the organization a request acts for. With row-level
+// security enabled, every statement run under a context carrying a Tenant
+// executes in a transaction where app.current_org_id is set, and the tenant
+// isolation policies only expose that organization's rows.
+type Tenant struct {
+	OrganizationID string
+}
+
+// scope is how a context's statements see tenant tables: as one tenant, or
+// across all of them when bypass is set. Contexts without a scope see no
+// tenant rows at all.
+type scope struct {
+	tenant Tenant
+	bypass bool
+}
+
+type scopeKey struct{}
+
+// WithTenant returns a copy of ctx whose database access is scoped to t.
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{tenant: t})
+}
+
+// WithoutTenant returns a copy of ctx whose database access spans every
+// organization. It is for work that is not done for one organization: sign-in,
+// listings across the caller's organizations and background jobs.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{bypass: true})
+}
+
+// TenantFromContext returns the tenant set by WithTenant.
+func TenantFromContext(ctx context.Context) (Tenant, bool) {
+	s, ok := ctx.Value(scopeKey{}).(scope)
+	if !ok || s.bypass {
+		return Tenant{}, false
+	}
+	return s.tenant, true
+}
+
+// EnableRowLevelSecurity turns on tenant scoping. Policies are not enforced
+// for superusers or roles with BYPASSRLS, so the API must connect as an
+// ordinary role for this to have any effect.
+func (db *PostgresDB) EnableRowLevelSecurity() {
+	db.rls = true
+}
+
+// scope returns the context's scope when row-level security is enabled.
+// Without one, statements run as they are and the policies hide every
+// tenant row.
+func (db *PostgresDB) scope(ctx context.Context) (scope, bool) {
+	if !db.rls {
+		return scope{}, false
+	}
+	s, ok := ctx.Value(scopeKey{}).(scope)
+	return s, ok
+}
+
+// BeginTxx starts a transaction, scoped to the context's tenant or bypassing
+// tenant isolation when row-level security is enabled.
+func (db *PostgresDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	tx, err := db.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := db.scope(ctx); ok {
+		query, arg := `SELECT set_config('app.current_org_id', $1, true)`, s.tenant.OrganizationID
+		if s.bypass {
+			query, arg = `SELECT set_config('app.bypass_rls', $1, true)`, "on"
+		}
+		if _, err := tx.ExecContext(ctx, query, arg); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return tx, nil
+}
+
+// GetContext, SelectContext and ExecContext run in their own scoped
+// transaction when the context carries a scope. There is no QueryxContext:
+// rows cannot outlive that transaction, so stream them from one begun with
+// BeginTxx.
+
+func (db *PostgresDB) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	if _, ok := db.scope(ctx); !ok {
+		return db.db.GetContext(ctx, dest, query, args...)
+	}
+	return db.inTx(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (db *PostgresDB) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	if _, ok := db.scope(ctx); !ok {
+		return db.db.SelectContext(ctx, dest, query, args...)
+	}
+	return db.inTx(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+func (db *PostgresDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if _, ok := db.scope(ctx); !ok {
+		return db.db.ExecContext(ctx, query, args...)
+	}
+	var result sql.Result
+	err := db.inTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		result, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (db *PostgresDB) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
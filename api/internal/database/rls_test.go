@@ -0,0 +1,168 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"base/api/internal/database"
+	"base/api/internal/database/dbtest"
+)
+
+var tenantTables = []string{
+	"organization_members",
+	"organization_invitations",
+	"organization_ownership_transfers",
+	"organization_teams",
+	"organization_team_members",
+	"projects",
+	"project_members",
+	"organization_roles",
+	"organization_join_links",
+	"organization_domains",
+	"organization_slug_history",
+	"audit_events",
+}
+
+// appendOnly tables reject updates outright, whatever the tenant.
+var appendOnly = map[string]bool{"audit_events": true}
+
+// seedTenant gives a new organization one row in every tenant table.
+func seedTenant(t *testing.T, db *database.PostgresDB) string {
+	t.Helper()
+	ownerID := dbtest.CreateUser(t, db)
+	memberID := dbtest.CreateUser(t, db)
+	orgID := dbtest.CreateOrganization(t, db, ownerID)
+	dbtest.AddMember(t, db, orgID, memberID, "admin")
+
+	owner, member := []any{orgID, ownerID}, []any{orgID, ownerID, memberID}
+	seed := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO organization_invitations (organization_id, email, role, token, invited_by, expires_at)
+		  VALUES ($1, 'invitee-' || gen_random_uuid() || '@example.com', 'member', gen_random_uuid()::text, $2, NOW() + INTERVAL '1 day')`, owner},
+		{`INSERT INTO organization_ownership_transfers (organization_id, from_user_id, to_user_id, expires_at)
+		  VALUES ($1, $2, $3, NOW() + INTERVAL '1 day')`, member},
+		{`WITH team AS (
+			INSERT INTO organization_teams (organization_id, name) VALUES ($1, 'Team') RETURNING id
+		  )
+		  INSERT INTO organization_team_members (team_id, organization_id, user_id, role)
+		  SELECT id, $1, $2, 'member' FROM team`, []any{orgID, memberID}},
+		{`WITH project AS (
+			INSERT INTO projects (organization_id, name, created_by) VALUES ($1, 'Project', $2) RETURNING id
+		  )
+		  INSERT INTO project_members (project_id, organization_id, user_id, role)
+		  SELECT id, $1, $3, 'viewer' FROM project`, member},
+		{`INSERT INTO organization_roles (organization_id, name) VALUES ($1, 'custom')`, []any{orgID}},
+		{`INSERT INTO organization_join_links (organization_id, token, role, created_by, expires_at)
+		  VALUES ($1, gen_random_uuid()::text, 'member', $2, NOW() + INTERVAL '1 day')`, owner},
+		{`INSERT INTO organization_domains (organization_id, domain, verification_token, created_by)
+		  VALUES ($1, gen_random_uuid() || '.example.com', gen_random_uuid()::text, $2)`, owner},
+		{`INSERT INTO organization_slug_history (organization_id, slug) VALUES ($1, 'old-' || gen_random_uuid())`, []any{orgID}},
+		{`INSERT INTO audit_events (organization_id, actor_id, action, target_type, target_id)
+		  VALUES ($1, $2, 'member.added', 'member', $3)`, member},
+	}
+	for _, row := range seed {
+		if _, err := db.ExecContext(dbtest.Context(), row.query, row.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return orgID
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := dbtest.Open(t)
+	dbtest.RequireRowLevelSecurity(t, db)
+
+	orgA := seedTenant(t, db)
+	orgB := seedTenant(t, db)
+	ctx := database.WithTenant(context.Background(), database.Tenant{OrganizationID: orgA})
+
+	for _, table := range tenantTables {
+		t.Run(table, func(t *testing.T) {
+			var selected []string
+			if err := db.SelectContext(ctx, &selected, `SELECT organization_id FROM `+table); err != nil {
+				t.Fatalf("select: %v", err)
+			}
+			assertOnly(t, "select", selected, orgA)
+
+			if !appendOnly[table] {
+				var updated []string
+				query := `UPDATE ` + table + ` SET organization_id = organization_id RETURNING organization_id`
+				if err := db.SelectContext(ctx, &updated, query); err != nil {
+					t.Fatalf("update: %v", err)
+				}
+				assertOnly(t, "update", updated, orgA)
+			}
+
+			var deleted []string
+			tx, err := db.BeginTxx(ctx, nil)
+			if err != nil {
+				t.Fatalf("begin: %v", err)
+			}
+			defer tx.Rollback()
+			if err := tx.SelectContext(ctx, &deleted, `DELETE FROM `+table+` WHERE organization_id = $1 RETURNING organization_id`, orgB); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if len(deleted) != 0 {
+				t.Errorf("delete reached %d rows of another organization", len(deleted))
+			}
+		})
+	}
+}
+
+func TestTenantIsolationRejectsWritesToOtherTenants(t *testing.T) {
+	db := dbtest.Open(t)
+	dbtest.RequireRowLevelSecurity(t, db)
+
+	orgA := seedTenant(t, db)
+	orgB := seedTenant(t, db)
+	ctx := database.WithTenant(context.Background(), database.Tenant{OrganizationID: orgA})
+
+	_, err := db.ExecContext(ctx, `INSERT INTO projects (organization_id, name) VALUES ($1, 'Intruder')`, orgB)
+	if err == nil {
+		t.Error("inserted a project into another organization")
+	}
+	_, err = db.ExecContext(ctx, `UPDATE projects SET organization_id = $1 WHERE organization_id = $2`, orgB, orgA)
+	if err == nil {
+		t.Error("moved a project into another organization")
+	}
+}
+
+func TestTenantIsolationFailsClosed(t *testing.T) {
+	db := dbtest.Open(t)
+	dbtest.RequireRowLevelSecurity(t, db)
+
+	orgID := seedTenant(t, db)
+
+	for _, table := range tenantTables {
+		var count int
+		if err := db.GetContext(context.Background(), &count, `SELECT COUNT(*) FROM `+table); err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("%s: unscoped statement saw %d rows", table, count)
+		}
+
+		var visible bool
+		query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE organization_id = $1)`
+		if err := db.GetContext(dbtest.Context(), &visible, query, orgID); err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		if !visible {
+			t.Errorf("%s: bypass did not see the organization's rows", table)
+		}
+	}
+}
+
+func assertOnly(t *testing.T, op string, orgIDs []string, orgID string) {
+	t.Helper()
+	if len(orgIDs) == 0 {
+		t.Errorf("%s returned none of the tenant's rows", op)
+	}
+	for _, id := range orgIDs {
+		if id != orgID {
+			t.Errorf("%s returned a row of organization %s", op, id)
+		}
+	}
+}
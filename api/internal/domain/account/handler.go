@@ -0,0 +1,162 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"base/api/internal/domain/user"
+	"base/api/internal/export"
+	"base/api/internal/session"
+	"base/api/pkg/response"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type Handler struct {
+	repo         *Repository
+	exportRepo   *export.Repository
+	sessionStore *session.Store
+	logger       *slog.Logger
+}
+
+func NewHandler(repo *Repository, exportRepo *export.Repository, sessionStore *session.Store, logger *slog.Logger) *Handler {
+	return &Handler{repo: repo, exportRepo: exportRepo, sessionStore: sessionStore, logger: logger}
+}
+
+// soleOwnerResponse tells the user which organizations need a new owner
+// before their account can be deleted.
+type soleOwnerResponse struct {
+	Error         string                 `json:"error"`
+	Message       string                 `json:"message"`
+	Organizations []BlockingOrganization `json:"organizations"`
+}
+
+// Delete schedules the current user's account for deletion and signs them
+// out everywhere. Signing in again within GracePeriod undoes it.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	deletion, err := h.repo.ScheduleDeletion(r.Context(), usr.ID)
+	if err != nil {
+		var soleOwner *SoleOwnerError
+		switch {
+		case errors.As(err, &soleOwner):
+			names := make([]string, len(soleOwner.Organizations))
+			for i, org := range soleOwner.Organizations {
+				names[i] = org.Name
+			}
+			response.JSON(w, http.StatusConflict, soleOwnerResponse{
+				Error:         "sole_owner",
+				Message:       fmt.Sprintf("transfer ownership of %s or remove its other members first", strings.Join(names, ", ")),
+				Organizations: soleOwner.Organizations,
+			})
+		case errors.Is(err, ErrDeletionPending):
+			response.BadRequest(w, "account deletion already requested")
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "user not found")
+		default:
+			response.InternalError(w, "failed to delete account")
+		}
+		return
+	}
+
+	// Sessions live in Redis, outside the transaction. RequireAuth also
+	// rejects deleted users, so a failure here does not leave them signed in.
+	if err := h.sessionStore.DeleteAllForUser(r.Context(), usr.ID); err != nil {
+		h.logger.Error("failed to revoke sessions", "user_id", usr.ID, "error", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+
+	response.OK(w, deletion)
+}
+
+// RequestExport queues a personal data export. Poll GetExport for its status
+// and fetch the archive from DownloadExport once it completes.
+func (h *Handler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	job, err := h.exportRepo.Create(r.Context(), usr.ID)
+	if err != nil {
+		if errors.Is(err, export.ErrInProgress) {
+			response.BadRequest(w, "an export is already in progress")
+			return
+		}
+		response.InternalError(w, "failed to request export")
+		return
+	}
+
+	response.Created(w, job)
+}
+
+func (h *Handler) ListExports(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	jobs, err := h.exportRepo.List(r.Context(), usr.ID)
+	if err != nil {
+		response.InternalError(w, "failed to list exports")
+		return
+	}
+
+	response.OK(w, jobs)
+}
+
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+	exportID := chi.URLParam(r, "exportID")
+	if !uuidPattern.MatchString(exportID) {
+		response.NotFound(w, "export not found")
+		return
+	}
+
+	job, err := h.exportRepo.Get(r.Context(), usr.ID, exportID)
+	if err != nil {
+		if errors.Is(err, export.ErrNotFound) {
+			response.NotFound(w, "export not found")
+			return
+		}
+		response.InternalError(w, "failed to get export")
+		return
+	}
+
+	response.OK(w, job)
+}
+
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+	exportID := chi.URLParam(r, "exportID")
+	if !uuidPattern.MatchString(exportID) {
+		response.NotFound(w, "export not found")
+		return
+	}
+
+	archive, err := h.exportRepo.GetArchive(r.Context(), usr.ID, exportID)
+	if err != nil {
+		switch {
+		case errors.Is(err, export.ErrNotFound):
+			response.NotFound(w, "export not found")
+		case errors.Is(err, export.ErrNotReady):
+			response.BadRequest(w, "export is not ready yet")
+		default:
+			response.InternalError(w, "failed to download export")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.json"`, exportID))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(archive)
+}
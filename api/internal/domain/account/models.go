@@ -0,0 +1,23 @@
+package account
+
+import "time"
+
+// BlockingOrganization is an organization the user solely owns while others
+// are still members. Ownership must be transferred before the account can be
+// deleted.
+type BlockingOrganization struct {
+	ID          string `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	Slug        string `json:"slug" db:"slug"`
+	MemberCount int    `json:"member_count" db:"member_count"`
+}
+
+// Deletion describes a scheduled account deletion.
+type Deletion struct {
+	UserID string `json:"user_id"`
+	// DeletedOrganizations are the user's personal organizations, deleted
+	// with the account and restored if the deletion is undone
+	DeletedOrganizations []string  `json:"deleted_organizations"`
+	RequestedAt          time.Time `json:"requested_at"`
+	PurgeAt              time.Time `json:"purge_at"`
+}
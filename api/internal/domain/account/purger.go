@@ -0,0 +1,72 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// GracePeriod is how long a deleted account can be restored by signing in
+// again. It must not exceed organization.DeletionRetention, or personal
+// organizations would be purged before the account they are restored with.
+const GracePeriod = 14 * 24 * time.Hour
+
+const purgeInterval = time.Hour
+
+// Purger periodically removes accounts whose grace period has passed.
+type Purger struct {
+	repo   *Repository
+	logger *slog.Logger
+}
+
+func NewPurger(repo *Repository, logger *slog.Logger) *Purger {
+	return &Purger{repo: repo, logger: logger}
+}
+
+// Run purges once immediately and then every purgeInterval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	cutoff := time.Now().Add(-GracePeriod)
+	ids, err := p.repo.GetExpired(ctx, cutoff)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Error("failed to list deleted accounts", "error", err)
+		}
+		return
+	}
+
+	for _, id := range ids {
+		err := p.repo.Purge(ctx, id, cutoff)
+		var soleOwner *SoleOwnerError
+		switch {
+		case err == nil:
+			p.logger.Info("purged deleted account", "user_id", id)
+		case errors.As(err, &soleOwner):
+			// Another owner left during the grace period; keep the account
+			// until the organization has an owner again
+			p.logger.Warn("deleted account still owns shared organizations", "user_id", id, "organizations", len(soleOwner.Organizations))
+		case errors.Is(err, ErrNotFound):
+			// Restored since it was listed
+		default:
+			if ctx.Err() != nil {
+				return
+			}
+			p.logger.Error("failed to purge deleted account", "user_id", id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"base/api/internal/audit"
+	"base/api/internal/database"
+	"base/api/internal/domain/organization"
+)
+
+var (
+	ErrNotFound        = errors.New("user not found")
+	ErrSoleOwner       = errors.New("user is the sole owner of organizations with other members")
+	ErrDeletionPending = errors.New("account deletion already requested")
+)
+
+// SoleOwnerError lists the organizations blocking an account deletion.
+type SoleOwnerError struct {
+	Organizations []BlockingOrganization
+}
+
+func (e *SoleOwnerError) Error() string { return ErrSoleOwner.Error() }
+
+func (e *SoleOwnerError) Unwrap() error { return ErrSoleOwner }
+
+const targetOrganization = "organization"
+
+type Repository struct {
+	postgres *database.PostgresDB
+}
+
+func NewRepository(postgres *database.PostgresDB) *Repository {
+	return &Repository{postgres: postgres}
+}
+
+// ScheduleDeletion marks the account deleted and soft-deletes the user's
+// personal organizations (those with no other members). It fails with a
+// *SoleOwnerError while the user is the only owner of an organization that
+// others still belong to.
+func (r *Repository) ScheduleDeletion(ctx context.Context, userID string) (*Deletion, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt *time.Time
+	err = tx.GetContext(ctx, &deletedAt, `SELECT deleted_at FROM users WHERE id = $1 FOR UPDATE`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt != nil {
+		return nil, ErrDeletionPending
+	}
+
+	// Lock the owner rows of every organization the user belongs to so no
+	// co-owner can leave between the check and the commit
+	lockQuery := `
+		SELECT m.id FROM organization_members m
+		WHERE m.role = 'owner' AND m.organization_id IN (
+			SELECT organization_id FROM organization_members WHERE user_id = $1
+		)
+		ORDER BY m.id
+		FOR UPDATE
+	`
+	var locked []string
+	if err = tx.SelectContext(ctx, &locked, lockQuery, userID); err != nil {
+		return nil, err
+	}
+
+	blocking, err := soleOwnedOrganizations(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocking) > 0 {
+		return nil, &SoleOwnerError{Organizations: blocking}
+	}
+
+	var requestedAt time.Time
+	err = tx.GetContext(ctx, &requestedAt, `UPDATE users SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 RETURNING deleted_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOW() is fixed for the transaction, so these organizations share the
+	// user's deleted_at and Restore can find them again
+	var orgs []organization.Organization
+	personalQuery := `
+		UPDATE organizations o
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE o.deleted_at IS NULL
+		  AND EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id <> $1)
+		RETURNING o.id, o.name, o.slug
+	`
+	if err = tx.SelectContext(ctx, &orgs, personalQuery, userID); err != nil {
+		return nil, err
+	}
+
+	deletion := &Deletion{
+		UserID:               userID,
+		DeletedOrganizations: make([]string, 0, len(orgs)),
+		RequestedAt:          requestedAt,
+		PurgeAt:              requestedAt.Add(GracePeriod),
+	}
+	for _, org := range orgs {
+		err = audit.Record(ctx, tx, audit.Entry{
+			OrganizationID: org.ID,
+			Action:         organization.ActionOrgDeleted,
+			TargetType:     targetOrganization,
+			TargetID:       org.ID,
+			Before:         map[string]any{"name": org.Name, "slug": org.Slug},
+		})
+		if err != nil {
+			return nil, err
+		}
+		deletion.DeletedOrganizations = append(deletion.DeletedOrganizations, org.ID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// soleOwnedOrganizations returns the active organizations where userID is the
+// only owner and at least one other member remains.
+func soleOwnedOrganizations(ctx context.Context, tx *sqlx.Tx, userID string) ([]BlockingOrganization, error) {
+	var orgs []BlockingOrganization
+	query := `
+		SELECT o.id, o.name, o.slug,
+			   (SELECT COUNT(*) FROM organization_members x WHERE x.organization_id = o.id) AS member_count
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id AND m.user_id = $1 AND m.role = 'owner'
+		WHERE o.deleted_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM organization_members x
+			WHERE x.organization_id = o.id AND x.role = 'owner' AND x.user_id <> $1
+		  )
+		  AND EXISTS (
+			SELECT 1 FROM organization_members x
+			WHERE x.organization_id = o.id AND x.user_id <> $1
+		  )
+		ORDER BY o.name
+	`
+	err := tx.SelectContext(ctx, &orgs, query, userID)
+	return orgs, err
+}
+
+// Restore undoes a scheduled deletion that is still within its grace period,
+// restoring the personal organizations deleted with the account. It reports
+// whether there was a deletion to undo.
+func (r *Repository) Restore(ctx context.Context, userID string) (bool, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt *time.Time
+	err = tx.GetContext(ctx, &deletedAt, `SELECT deleted_at FROM users WHERE id = $1 FOR UPDATE`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if deletedAt == nil {
+		return false, nil
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE users SET deleted_at = NULL, updated_at = NOW() WHERE id = $1`, userID); err != nil {
+		return false, err
+	}
+
+	var orgs []organization.Organization
+	query := `
+		UPDATE organizations o
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE o.deleted_at = $2
+		  AND EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id = $1)
+		RETURNING o.id, o.name, o.slug
+	`
+	if err = tx.SelectContext(ctx, &orgs, query, userID, *deletedAt); err != nil {
+		return false, err
+	}
+
+	for _, org := range orgs {
+		err = audit.Record(ctx, tx, audit.Entry{
+			OrganizationID: org.ID,
+			Action:         organization.ActionOrgRestored,
+			TargetType:     targetOrganization,
+			TargetID:       org.ID,
+			After:          map[string]any{"name": org.Name, "slug": org.Slug},
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// GetExpired returns the IDs of accounts whose grace period ended before the
+// cutoff.
+func (r *Repository) GetExpired(ctx context.Context, before time.Time) ([]string, error) {
+	var ids []string
+	query := `SELECT id FROM users WHERE deleted_at < $1 ORDER BY deleted_at`
+	err := r.postgres.SelectContext(ctx, &ids, query, before)
+	return ids, err
+}
+
+// Purge permanently deletes an account whose grace period has passed. The
+// user's audit references are replaced with a random pseudonym, so their
+// actions stay correlated without identifying them. Memberships and
+// transfers cascade; rows the user created are kept with no creator. It
+// returns ErrSoleOwner if the user became the only owner of a shared
+// organization during the grace period.
+func (r *Repository) Purge(ctx context.Context, userID string, before time.Time) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.GetContext(ctx, &email, `SELECT email FROM users WHERE id = $1 AND deleted_at < $2 FOR UPDATE`, userID, before)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	blocking, err := soleOwnedOrganizations(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		return &SoleOwnerError{Organizations: blocking}
+	}
+
+	// The append-only trigger permits this update only when opted in
+	if _, err = tx.ExecContext(ctx, `SELECT set_config('app.audit_anonymize', 'on', true)`); err != nil {
+		return err
+	}
+
+	anonymize := `
+		WITH pseudonym AS (SELECT gen_random_uuid() AS id)
+		UPDATE audit_events e
+		SET actor_id = CASE WHEN e.actor_id = $1 THEN p.id ELSE e.actor_id END,
+			ip = CASE WHEN e.actor_id = $1 THEN NULL ELSE e.ip END,
+			target_id = CASE WHEN e.target_id = $1::text THEN p.id::text ELSE e.target_id END,
+			before = CASE WHEN e.before->>'email' = $2 THEN e.before - 'email' ELSE e.before END,
+			after = CASE WHEN e.after->>'email' = $2 THEN e.after - 'email' ELSE e.after END
+		FROM pseudonym p
+		WHERE e.actor_id = $1 OR e.target_id = $1::text
+		   OR e.before->>'email' = $2 OR e.after->>'email' = $2
+	`
+	if _, err = tx.ExecContext(ctx, anonymize, userID, email); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM organization_invitations WHERE email = $1`, email); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
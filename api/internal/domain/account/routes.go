@@ -0,0 +1,17 @@
+package account
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers account lifecycle routes alongside the user
+// profile routes. All routes require authentication (applied at router level).
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Delete("/me", h.Delete)
+
+	// Personal data exports
+	r.Post("/me/exports", h.RequestExport)
+	r.Get("/me/exports", h.ListExports)
+	r.Get("/me/exports/{exportID}", h.GetExport)
+	r.Get("/me/exports/{exportID}/download", h.DownloadExport)
+}
@@ -11,8 +11,10 @@ import (
 	"net/http"
 	"time"
 
+	"base/api/internal/domain/account"
 	"base/api/internal/domain/organization"
 	"base/api/internal/domain/user"
+	"base/api/internal/events"
 	"base/api/internal/session"
 	"base/api/pkg/response"
 )
@@ -23,15 +25,19 @@ type Handler struct {
 	config       *Config
 	userRepo     *user.Repository
 	orgRepo      *organization.Repository
+	accountRepo  *account.Repository
 	sessionStore *session.Store
+	events       *events.Broker
 }
 
-func NewHandler(config *Config, userRepo *user.Repository, orgRepo *organization.Repository, sessionStore *session.Store) *Handler {
+func NewHandler(config *Config, userRepo *user.Repository, orgRepo *organization.Repository, accountRepo *account.Repository, sessionStore *session.Store, broker *events.Broker) *Handler {
 	return &Handler{
 		config:       config,
 		userRepo:     userRepo,
 		orgRepo:      orgRepo,
+		accountRepo:  accountRepo,
 		sessionStore: sessionStore,
+		events:       broker,
 	}
 }
 
@@ -101,20 +107,41 @@ func (h *Handler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user has any organizations, create default "Personal" org if not
-	orgs, err := h.orgRepo.GetUserOrganizations(ctx, dbUser.ID)
-	if err != nil {
-		http.Error(w, "Failed to check organizations: "+err.Error(), http.StatusInternalServerError)
-		return
+	// Signing in during the deletion grace period cancels the deletion
+	if dbUser.DeletedAt != nil {
+		if _, err := h.accountRepo.Restore(ctx, dbUser.ID); err != nil {
+			http.Error(w, "Failed to restore account: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dbUser.DeletedAt = nil
+	}
+
+	// Users with a verified company email belong to the org that claimed the
+	// domain. This runs on every sign-in so claims verified after the user
+	// signed up still apply.
+	provisioned := false
+	if googleUser.VerifiedEmail {
+		provisioned, err = h.provisionByDomain(ctx, dbUser)
+		if err != nil {
+			http.Error(w, "Failed to join organization: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 
-	if len(orgs) == 0 {
-		slug := organization.GenerateSlug("personal", dbUser.ID)
-		_, err = h.orgRepo.CreateWithOwner(ctx, "Personal", slug, dbUser.ID)
+	// Everyone else starts with a "Personal" org, its slug taken from their name
+	if !provisioned {
+		hasOrgs, err := h.orgRepo.HasOrganizations(ctx, dbUser.ID)
 		if err != nil {
-			http.Error(w, "Failed to create default organization: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, "Failed to check organizations: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if !hasOrgs {
+			_, err = h.orgRepo.CreateWithGeneratedSlug(ctx, "Personal", dbUser.Name, dbUser.ID)
+			if err != nil {
+				http.Error(w, "Failed to create default organization: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
 	}
 
 	// Create session with user's UUID
@@ -193,6 +220,71 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, dbUser)
 }
 
+// provisionByDomain adds the user to the organization that verified their email
+// domain, either directly or via a pending invitation depending on the claim's
+// join mode. It is safe to repeat: existing members and pending invitations are
+// left alone, and users who left the organization or declined its invitation
+// are not brought back. It reports whether the user is a member or has a
+// pending invitation.
+func (h *Handler) provisionByDomain(ctx context.Context, dbUser *user.User) (bool, error) {
+	claim, err := h.orgRepo.GetVerifiedDomain(ctx, organization.EmailDomain(dbUser.Email))
+	if err != nil {
+		if errors.Is(err, organization.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err := h.orgRepo.GetMember(ctx, claim.OrganizationID, dbUser.ID); err == nil {
+		return true, nil
+	} else if !errors.Is(err, organization.ErrNotMember) {
+		return false, err
+	}
+	left, err := h.orgRepo.HasLeft(ctx, claim.OrganizationID, dbUser.ID)
+	if err != nil || left {
+		return false, err
+	}
+
+	switch claim.JoinMode {
+	case organization.JoinModeJoin:
+		member, err := h.orgRepo.AddMember(ctx, claim.OrganizationID, dbUser.ID, claim.DefaultRole)
+		if errors.Is(err, organization.ErrAlreadyMember) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		h.events.Publish(ctx, events.Event{
+			Type:           events.TypeMemberAdded,
+			OrganizationID: member.OrganizationID,
+			UserID:         member.UserID,
+			Data:           member,
+		})
+		return true, nil
+	case organization.JoinModeInvite:
+		status, err := h.orgRepo.LatestInvitationStatus(ctx, claim.OrganizationID, dbUser.Email)
+		if err != nil && !errors.Is(err, organization.ErrNotFound) {
+			return false, err
+		}
+		switch status {
+		case organization.StatusPending:
+			return true, nil
+		case organization.StatusDeclined:
+			return false, nil
+		}
+		org, err := h.orgRepo.GetByID(ctx, claim.OrganizationID)
+		if err != nil {
+			return false, err
+		}
+		expiresAt := time.Now().Add(org.Settings.InvitationExpiry())
+		if _, err := h.orgRepo.CreateInvitation(ctx, claim.OrganizationID, dbUser.Email, claim.DefaultRole, claim.CreatedBy, expiresAt); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
 func generateState() string {
 	b := make([]byte, 32)
 	rand.Read(b)
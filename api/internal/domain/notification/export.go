@@ -0,0 +1,23 @@
+package notification
+
+import (
+	"context"
+
+	"base/api/internal/export"
+)
+
+// RegisterExporters adds the user's notifications and preferences to
+// personal data exports.
+func RegisterExporters(reg *export.Registry, repo *Repository) {
+	reg.Register("notifications", export.ExporterFunc(repo.exportNotifications))
+	reg.Register("notification_preferences", export.ExporterFunc(func(ctx context.Context, userID string) (any, error) {
+		return repo.GetPreferences(ctx, userID)
+	}))
+}
+
+func (r *Repository) exportNotifications(ctx context.Context, userID string) (any, error) {
+	notifications := []Notification{}
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1 ORDER BY created_at, id`
+	err := r.postgres.SelectContext(ctx, &notifications, query, userID)
+	return notifications, err
+}
@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+
+	"base/api/internal/domain/user"
+	"base/api/internal/events"
+	"base/api/pkg/response"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Handler serves the current user's notifications and preferences.
+type Handler struct {
+	repo   *Repository
+	events *events.Broker
+}
+
+func NewHandler(repo *Repository, broker *events.Broker) *Handler {
+	return &Handler{repo: repo, events: broker}
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	page, err := NotificationsPage.Parse(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if v := page.Filters["unread"]; v != "" && v != "true" && v != "false" {
+		response.BadRequest(w, "filter[unread] must be true or false")
+		return
+	}
+	if v := page.Filters["type"]; v != "" && !Type(v).IsValid() {
+		response.BadRequest(w, "invalid filter[type]")
+		return
+	}
+
+	notifications, next, err := h.repo.List(r.Context(), usr.ID, page)
+	if err != nil {
+		response.InternalError(w, "failed to list notifications")
+		return
+	}
+
+	response.Page(w, notifications, next)
+}
+
+func (h *Handler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	count, err := h.repo.CountUnread(r.Context(), usr.ID)
+	if err != nil {
+		response.InternalError(w, "failed to count notifications")
+		return
+	}
+
+	response.OK(w, UnreadCount{Count: count})
+}
+
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+	id := chi.URLParam(r, "notificationID")
+	if !uuidPattern.MatchString(id) {
+		response.NotFound(w, "notification not found")
+		return
+	}
+
+	n, err := h.repo.MarkRead(r.Context(), usr.ID, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "notification not found")
+			return
+		}
+		response.InternalError(w, "failed to mark notification read")
+		return
+	}
+	h.events.Publish(r.Context(), events.Event{Type: events.TypeNotificationRead, UserID: usr.ID, Data: n})
+
+	response.OK(w, n)
+}
+
+func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	count, err := h.repo.MarkAllRead(r.Context(), usr.ID)
+	if err != nil {
+		response.InternalError(w, "failed to mark notifications read")
+		return
+	}
+	// Without data the event covers every notification
+	h.events.Publish(r.Context(), events.Event{Type: events.TypeNotificationRead, UserID: usr.ID})
+
+	response.OK(w, map[string]int{"marked": count})
+}
+
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	prefs, err := h.repo.GetPreferences(r.Context(), usr.ID)
+	if err != nil {
+		response.InternalError(w, "failed to get notification preferences")
+		return
+	}
+
+	response.OK(w, prefs)
+}
+
+// SetPreference updates the channels for one notification type. Omitted
+// channels keep their current setting.
+func (h *Handler) SetPreference(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+	t := Type(chi.URLParam(r, "type"))
+	if !t.IsValid() {
+		response.NotFound(w, "unknown notification type")
+		return
+	}
+
+	var req SetPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	pref, err := h.repo.GetPreference(r.Context(), usr.ID, t)
+	if err != nil {
+		response.InternalError(w, "failed to get notification preference")
+		return
+	}
+	if req.InApp != nil {
+		pref.InApp = *req.InApp
+	}
+	if req.Email != nil {
+		pref.Email = *req.Email
+	}
+
+	saved, err := h.repo.SetPreference(r.Context(), usr.ID, pref)
+	if err != nil {
+		response.InternalError(w, "failed to update notification preference")
+		return
+	}
+
+	response.OK(w, saved)
+}
@@ -0,0 +1,60 @@
+package notification
+
+import "time"
+
+// Type identifies what a notification is about. Preferences are set per type.
+type Type string
+
+const (
+	TypeInvitation        Type = "invitation"
+	TypeRoleChanged       Type = "role_changed"
+	TypeRemoved           Type = "removed"
+	TypeOwnershipTransfer Type = "ownership_transfer"
+)
+
+// Types lists every notification type, in display order.
+var Types = []Type{TypeInvitation, TypeRoleChanged, TypeRemoved, TypeOwnershipTransfer}
+
+func (t Type) IsValid() bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+type Notification struct {
+	ID             string     `json:"id" db:"id"`
+	UserID         string     `json:"user_id" db:"user_id"`
+	Type           Type       `json:"type" db:"type"`
+	OrganizationID *string    `json:"organization_id" db:"organization_id"`
+	Title          string     `json:"title" db:"title"`
+	Body           string     `json:"body" db:"body"`
+	ReadAt         *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Preference is the channels a user receives one type of notification on.
+type Preference struct {
+	Type  Type `json:"type" db:"type"`
+	InApp bool `json:"in_app" db:"in_app"`
+	Email bool `json:"email" db:"email"`
+}
+
+// defaultPreference applies until the user chooses otherwise. Role changes
+// are in-app only; the rest also warrant an email.
+func defaultPreference(t Type) Preference {
+	return Preference{Type: t, InApp: true, Email: t != TypeRoleChanged}
+}
+
+type UnreadCount struct {
+	Count int `json:"count"`
+}
+
+// Request types
+
+type SetPreferenceRequest struct {
+	InApp *bool `json:"in_app"`
+	Email *bool `json:"email"`
+}
@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"base/api/internal/domain/user"
+	"base/api/internal/events"
+	"base/api/internal/mail"
+)
+
+// Message is a notification to deliver. The recipient is identified by
+// UserID, or by Email for people who may not have an account yet; those
+// only receive the email.
+type Message struct {
+	UserID         string
+	Email          string
+	Type           Type
+	OrganizationID string
+	Title          string
+	Body           string
+	// NoEmail limits delivery to in-app regardless of preferences
+	NoEmail bool
+}
+
+// Notifier delivers messages on the channels each recipient has enabled.
+type Notifier struct {
+	repo     *Repository
+	userRepo *user.Repository
+	mailer   mail.Sender
+	events   *events.Broker
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	closing bool
+	pending sync.WaitGroup
+}
+
+func NewNotifier(repo *Repository, userRepo *user.Repository, mailer mail.Sender, broker *events.Broker, logger *slog.Logger) *Notifier {
+	return &Notifier{repo: repo, userRepo: userRepo, mailer: mailer, events: broker, logger: logger}
+}
+
+// Notify delivers msg and returns the in-app notification, if one was
+// created.
+func (n *Notifier) Notify(ctx context.Context, msg Message) (*Notification, error) {
+	recipient, err := n.recipient(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an account there are no preferences and nowhere to show it
+	pref := Preference{Type: msg.Type, Email: true}
+	if recipient != nil {
+		if pref, err = n.repo.GetPreference(ctx, recipient.ID, msg.Type); err != nil {
+			return nil, err
+		}
+	}
+
+	var created *Notification
+	if pref.InApp && recipient != nil {
+		var orgID *string
+		if msg.OrganizationID != "" {
+			orgID = &msg.OrganizationID
+		}
+		created, err = n.repo.Create(ctx, &Notification{
+			UserID:         recipient.ID,
+			Type:           msg.Type,
+			OrganizationID: orgID,
+			Title:          msg.Title,
+			Body:           msg.Body,
+		})
+		if err != nil {
+			return nil, err
+		}
+		n.events.Publish(ctx, events.Event{Type: events.TypeNotificationCreated, UserID: recipient.ID, Data: created})
+	}
+
+	if pref.Email && !msg.NoEmail {
+		to := msg.Email
+		if recipient != nil {
+			to = recipient.Email
+		}
+		if to != "" {
+			err = n.mailer.Send(ctx, mail.Message{To: to, Subject: msg.Title, Body: msg.Body + "\n"})
+			if err != nil {
+				return created, err
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// Send delivers msg in the background. Notifications are best effort and
+// must not hold up the response that triggered them. Once Shutdown has begun
+// they are delivered before Send returns.
+func (n *Notifier) Send(ctx context.Context, msg Message) {
+	ctx = context.WithoutCancel(ctx)
+
+	n.mu.Lock()
+	if n.closing {
+		n.mu.Unlock()
+		n.deliver(ctx, msg)
+		return
+	}
+	n.pending.Add(1)
+	n.mu.Unlock()
+
+	go func() {
+		defer n.pending.Done()
+		n.deliver(ctx, msg)
+	}()
+}
+
+func (n *Notifier) deliver(ctx context.Context, msg Message) {
+	if _, err := n.Notify(ctx, msg); err != nil {
+		n.logger.ErrorContext(ctx, "failed to send notification", "type", msg.Type, "user_id", msg.UserID, "error", err)
+	}
+}
+
+// Shutdown waits for notifications sent in the background to be delivered,
+// giving up when ctx ends.
+func (n *Notifier) Shutdown(ctx context.Context) error {
+	n.mu.Lock()
+	n.closing = true
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *Notifier) recipient(ctx context.Context, msg Message) (*user.User, error) {
+	var (
+		usr *user.User
+		err error
+	)
+	if msg.UserID != "" {
+		usr, err = n.userRepo.GetByID(ctx, msg.UserID)
+	} else {
+		usr, err = n.userRepo.GetByEmail(ctx, msg.Email)
+	}
+	if errors.Is(err, user.ErrNotFound) {
+		if msg.UserID != "" {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Accounts awaiting deletion are treated as gone
+	if usr.DeletedAt != nil {
+		return nil, nil
+	}
+	return usr, nil
+}
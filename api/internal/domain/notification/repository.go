@@ -0,0 +1,171 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"base/api/internal/database"
+	"base/api/pkg/pagination"
+)
+
+var ErrNotFound = errors.New("notification not found")
+
+const notificationColumns = `id, user_id, type, organization_id, title, body, read_at, created_at`
+
+// NotificationsPage lists a user's notifications, newest first.
+var NotificationsPage = pagination.Spec{
+	Sorts: []pagination.Sort{
+		{Key: "created", Columns: []pagination.Column{{Expr: "created_at", Type: "timestamptz"}}, Desc: true},
+	},
+	Filters:      []string{"unread", "type"},
+	IDColumn:     "id",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+type Repository struct {
+	postgres *database.PostgresDB
+}
+
+func NewRepository(postgres *database.PostgresDB) *Repository {
+	return &Repository{postgres: postgres}
+}
+
+func (r *Repository) Create(ctx context.Context, n *Notification) (*Notification, error) {
+	var created Notification
+	query := `
+		INSERT INTO notifications (user_id, type, organization_id, title, body)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + notificationColumns
+	err := r.postgres.GetContext(ctx, &created, query, n.UserID, n.Type, n.OrganizationID, n.Title, n.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// List returns one page of the user's notifications and the next cursor.
+func (r *Repository) List(ctx context.Context, userID string, p pagination.Params) ([]Notification, string, error) {
+	args := []any{userID}
+	where := []string{"user_id = $1"}
+	switch p.Filters["unread"] {
+	case "true":
+		where = append(where, "read_at IS NULL")
+	case "false":
+		where = append(where, "read_at IS NOT NULL")
+	}
+	if t := p.Filters["type"]; t != "" {
+		args = append(args, t)
+		where = append(where, fmt.Sprintf("type = $%d", len(args)))
+	}
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE ` + strings.Join(where, " AND ") +
+		` AND ` + keyset + ` ` + order
+
+	var notifications []Notification
+	if err := r.postgres.SelectContext(ctx, &notifications, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	notifications, next := pagination.Page(p, notifications, func(n Notification) ([]any, string) {
+		return []any{n.CreatedAt}, n.ID
+	})
+	return notifications, next, nil
+}
+
+func (r *Repository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+	err := r.postgres.GetContext(ctx, &count, query, userID)
+	return count, err
+}
+
+// MarkRead marks one notification read. Marking it again keeps the original
+// read time.
+func (r *Repository) MarkRead(ctx context.Context, userID, id string) (*Notification, error) {
+	var n Notification
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + notificationColumns
+	err := r.postgres.GetContext(ctx, &n, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// MarkAllRead marks every unread notification read and returns how many
+// changed.
+func (r *Repository) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	query := `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`
+	res, err := r.postgres.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// GetPreferences returns the user's preference for every type, falling back
+// to the defaults where they have not chosen.
+func (r *Repository) GetPreferences(ctx context.Context, userID string) ([]Preference, error) {
+	var stored []Preference
+	query := `SELECT type, in_app, email FROM notification_preferences WHERE user_id = $1`
+	if err := r.postgres.SelectContext(ctx, &stored, query, userID); err != nil {
+		return nil, err
+	}
+
+	byType := make(map[Type]Preference, len(stored))
+	for _, p := range stored {
+		byType[p.Type] = p
+	}
+
+	prefs := make([]Preference, len(Types))
+	for i, t := range Types {
+		if p, ok := byType[t]; ok {
+			prefs[i] = p
+		} else {
+			prefs[i] = defaultPreference(t)
+		}
+	}
+	return prefs, nil
+}
+
+// GetPreference returns the user's preference for one type.
+func (r *Repository) GetPreference(ctx context.Context, userID string, t Type) (Preference, error) {
+	var p Preference
+	query := `SELECT type, in_app, email FROM notification_preferences WHERE user_id = $1 AND type = $2`
+	err := r.postgres.GetContext(ctx, &p, query, userID, t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultPreference(t), nil
+	}
+	return p, err
+}
+
+func (r *Repository) SetPreference(ctx context.Context, userID string, p Preference) (*Preference, error) {
+	var saved Preference
+	query := `
+		INSERT INTO notification_preferences (user_id, type, in_app, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, type) DO UPDATE SET
+			in_app = EXCLUDED.in_app,
+			email = EXCLUDED.email,
+			updated_at = NOW()
+		RETURNING type, in_app, email
+	`
+	err := r.postgres.GetContext(ctx, &saved, query, userID, p.Type, p.InApp, p.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -0,0 +1,17 @@
+package notification
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers notification routes for the current user
+// All routes require authentication (applied at router level)
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/", h.List)
+	r.Get("/unread-count", h.UnreadCount)
+	r.Post("/read-all", h.MarkAllRead)
+	r.Post("/{notificationID}/read", h.MarkRead)
+
+	r.Get("/preferences", h.GetPreferences)
+	r.Put("/preferences/{type}", h.SetPreference)
+}
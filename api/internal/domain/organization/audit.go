@@ -0,0 +1,52 @@
+package organization
+
+// Audit actions recorded alongside organization mutations
+const (
+	ActionOrgUpdated           = "org.updated"
+	ActionOrgSettingsUpdated   = "org.settings_updated"
+	ActionOrgDeleted           = "org.deleted"
+	ActionOrgRestored          = "org.restored"
+	ActionOrgPurged            = "org.purged"
+	ActionOwnershipTransferred = "org.ownership_transferred"
+
+	ActionTransferInitiated = "transfer.initiated"
+	ActionTransferCancelled = "transfer.cancelled"
+
+	ActionMemberAdded       = "member.added"
+	ActionMemberRoleChanged = "member.role_changed"
+	ActionMemberRemoved     = "member.removed"
+
+	ActionInvitationCreated   = "invitation.created"
+	ActionInvitationCancelled = "invitation.cancelled"
+
+	ActionJoinLinkCreated = "join_link.created"
+	ActionJoinLinkRevoked = "join_link.revoked"
+
+	ActionDomainClaimed  = "domain.claimed"
+	ActionDomainVerified = "domain.verified"
+	ActionDomainUpdated  = "domain.updated"
+	ActionDomainDeleted  = "domain.deleted"
+
+	ActionRoleCreated = "role.created"
+	ActionRoleUpdated = "role.updated"
+	ActionRoleDeleted = "role.deleted"
+
+	ActionTeamCreated           = "team.created"
+	ActionTeamUpdated           = "team.updated"
+	ActionTeamDeleted           = "team.deleted"
+	ActionTeamMemberAdded       = "team.member_added"
+	ActionTeamMemberRoleChanged = "team.member_role_changed"
+	ActionTeamMemberRemoved     = "team.member_removed"
+)
+
+// Audit target types
+const (
+	targetOrganization = "organization"
+	targetMember       = "member"
+	targetInvitation   = "invitation"
+	targetJoinLink     = "join_link"
+	targetDomain       = "domain"
+	targetRole         = "role"
+	targetTransfer     = "transfer"
+	targetTeam         = "team"
+)
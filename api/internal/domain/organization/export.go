@@ -0,0 +1,98 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	"base/api/internal/export"
+)
+
+// RegisterExporters adds the user's memberships, invitations, teams and
+// ownership transfers to personal data exports.
+func RegisterExporters(reg *export.Registry, repo *Repository) {
+	reg.Register("memberships", export.ExporterFunc(repo.exportMemberships))
+	reg.Register("invitations_sent", export.ExporterFunc(repo.exportInvitationsSent))
+	reg.Register("invitations_received", export.ExporterFunc(repo.exportInvitationsReceived))
+	reg.Register("teams", export.ExporterFunc(repo.exportTeams))
+	reg.Register("ownership_transfers", export.ExporterFunc(repo.exportTransfers))
+}
+
+type membershipExport struct {
+	OrganizationID   string     `json:"organization_id" db:"organization_id"`
+	OrganizationName string     `json:"organization_name" db:"organization_name"`
+	OrganizationSlug string     `json:"organization_slug" db:"organization_slug"`
+	Role             Role       `json:"role" db:"role"`
+	JoinedAt         time.Time  `json:"joined_at" db:"joined_at"`
+	DeletedAt        *time.Time `json:"organization_deleted_at,omitempty" db:"deleted_at"`
+}
+
+func (r *Repository) exportMemberships(ctx context.Context, userID string) (any, error) {
+	memberships := []membershipExport{}
+	query := `
+		SELECT o.id AS organization_id, o.name AS organization_name, o.slug AS organization_slug,
+			   m.role, m.created_at AS joined_at, o.deleted_at
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1
+		ORDER BY m.created_at
+	`
+	err := r.postgres.SelectContext(ctx, &memberships, query, userID)
+	return memberships, err
+}
+
+// invitationExportColumns leaves out the token, which is a credential
+const invitationExportColumns = `i.id, i.organization_id, i.email, i.role, i.invited_by, i.status,
+	i.expires_at, i.created_at, i.updated_at`
+
+func (r *Repository) exportInvitationsSent(ctx context.Context, userID string) (any, error) {
+	invitations := []Invitation{}
+	query := `SELECT ` + invitationExportColumns + ` FROM organization_invitations i WHERE i.invited_by = $1 ORDER BY i.created_at`
+	err := r.postgres.SelectContext(ctx, &invitations, query, userID)
+	return invitations, err
+}
+
+func (r *Repository) exportInvitationsReceived(ctx context.Context, userID string) (any, error) {
+	invitations := []Invitation{}
+	query := `
+		SELECT ` + invitationExportColumns + `
+		FROM organization_invitations i
+		JOIN users u ON u.email = i.email
+		WHERE u.id = $1
+		ORDER BY i.created_at
+	`
+	err := r.postgres.SelectContext(ctx, &invitations, query, userID)
+	return invitations, err
+}
+
+type teamExport struct {
+	OrganizationID string    `json:"organization_id" db:"organization_id"`
+	TeamID         string    `json:"team_id" db:"team_id"`
+	TeamName       string    `json:"team_name" db:"team_name"`
+	Role           TeamRole  `json:"role" db:"role"`
+	JoinedAt       time.Time `json:"joined_at" db:"joined_at"`
+}
+
+func (r *Repository) exportTeams(ctx context.Context, userID string) (any, error) {
+	teams := []teamExport{}
+	query := `
+		SELECT t.organization_id, t.id AS team_id, t.name AS team_name, tm.role, tm.created_at AS joined_at
+		FROM organization_team_members tm
+		JOIN organization_teams t ON t.id = tm.team_id
+		WHERE tm.user_id = $1
+		ORDER BY tm.created_at
+	`
+	err := r.postgres.SelectContext(ctx, &teams, query, userID)
+	return teams, err
+}
+
+func (r *Repository) exportTransfers(ctx context.Context, userID string) (any, error) {
+	transfers := []OwnershipTransfer{}
+	query := `
+		SELECT id, organization_id, from_user_id, to_user_id, expires_at, created_at
+		FROM organization_ownership_transfers
+		WHERE from_user_id = $1 OR to_user_id = $1
+		ORDER BY created_at
+	`
+	err := r.postgres.SelectContext(ctx, &transfers, query, userID)
+	return transfers, err
+}
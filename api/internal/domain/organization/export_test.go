@@ -0,0 +1,54 @@
+package organization
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"base/api/internal/audit"
+)
+
+func TestFinishExport(t *testing.T) {
+	h := &Handler{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	errStream := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		written  string
+		err      error
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{"empty export", "", nil, http.StatusOK, "text/csv", ""},
+		{"complete export", "id\n1\n", nil, http.StatusOK, "text/csv", "id\n1\n"},
+		{"fails before the first byte", "", errStream, http.StatusInternalServerError, "application/json", ""},
+		{"fails mid-stream", "id\n1\n", errStream, http.StatusOK, "text/csv", "id\n1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ew := &exportWriter{w: rec, contentType: "text/csv", filename: "audit-log.csv"}
+			if tt.written != "" {
+				io.WriteString(ew, tt.written)
+			}
+			h.finishExport(ew, audit.Filter{OrganizationID: "org"}, tt.err)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if tt.wantCode == http.StatusOK && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantCode != http.StatusOK && rec.Header().Get("Content-Disposition") != "" {
+				t.Error("error response is marked as an attachment")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package organization
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanGrant(t *testing.T) {
+	h := &Handler{authz: NewAuthorizer(nil)}
+	inviter := []Permission{PermOrgRead, PermMembersRead, PermMembersInvite}
+
+	tests := []struct {
+		name  string
+		perms []Permission
+		role  Role
+		want  int
+	}{
+		{"owner grants admin", RoleOwner.Permissions(), RoleAdmin, http.StatusNoContent},
+		{"admin grants admin", RoleAdmin.Permissions(), RoleAdmin, http.StatusNoContent},
+		{"admin grants owner", RoleAdmin.Permissions(), RoleOwner, http.StatusForbidden},
+		{"member grants admin", RoleMember.Permissions(), RoleAdmin, http.StatusForbidden},
+		{"inviter grants admin", inviter, RoleAdmin, http.StatusForbidden},
+		{"inviter grants member", inviter, RoleMember, http.StatusForbidden},
+		{"inviter plus member grants member", append(RoleMember.Permissions(), PermMembersInvite), RoleMember, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oc := &Context{Organization: &Organization{}, Member: &Member{}, Permissions: tt.perms}
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(WithContext(req.Context(), oc))
+			rec := httptest.NewRecorder()
+			if h.canGrant(rec, req, tt.role) {
+				rec.WriteHeader(http.StatusNoContent)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
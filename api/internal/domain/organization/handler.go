@@ -1,33 +1,101 @@
 package organization
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"base/api/internal/audit"
+	"base/api/internal/domain/notification"
 	"base/api/internal/domain/user"
+	"base/api/internal/events"
 	"base/api/internal/middleware"
 	"base/api/internal/session"
+	"base/api/pkg/pagination"
+	"base/api/pkg/request"
 	"base/api/pkg/response"
 
 	"github.com/go-chi/chi/v5"
 )
 
-const invitationExpiryDays = 7
+const (
+	invitationExpiryDays  = 7
+	maxJoinLinkExpiryDays = 30
+	dnsLookupTimeout      = 10 * time.Second
+	maxMemberSearchLength = 100
+	transferWindow        = 7 * 24 * time.Hour
+)
 
 type Handler struct {
 	repo         *Repository
+	authz        *Authorizer
+	auditRepo    *audit.Repository
 	userRepo     *user.Repository
 	sessionStore *session.Store
+	resolver     TXTResolver
+	notifier     *notification.Notifier
+	events       *events.Broker
+	logger       *slog.Logger
 }
 
-func NewHandler(repo *Repository, userRepo *user.Repository, sessionStore *session.Store) *Handler {
+func NewHandler(repo *Repository, authz *Authorizer, auditRepo *audit.Repository, userRepo *user.Repository, sessionStore *session.Store, resolver TXTResolver, notifier *notification.Notifier, broker *events.Broker, logger *slog.Logger) *Handler {
 	return &Handler{
 		repo:         repo,
+		authz:        authz,
+		auditRepo:    auditRepo,
 		userRepo:     userRepo,
 		sessionStore: sessionStore,
+		resolver:     resolver,
+		notifier:     notifier,
+		events:       broker,
+		logger:       logger,
+	}
+}
+
+// decodePatch reads a merge patch body into dst, writing the error response
+// and returning false when the body is unusable.
+func decodePatch(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := request.DecodePatch(r, dst)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, request.ErrUnsupportedMediaType):
+		response.UnsupportedMediaType(w, err.Error())
+	case errors.Is(err, request.ErrInvalidPatch):
+		response.BadRequest(w, err.Error())
+	default:
+		response.BadRequest(w, "invalid request body")
+	}
+	return false
+}
+
+// notModified sets the response ETag and, when the client's If-None-Match
+// names it, answers 304 and reports true.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if request.IfNoneMatch(r, etag) {
+		response.NotModified(w)
+		return true
 	}
+	return false
+}
+
+// parsePage reads pagination parameters for spec, writing a 400 and
+// returning false when they are invalid.
+func parsePage(w http.ResponseWriter, r *http.Request, spec pagination.Spec) (pagination.Params, bool) {
+	page, err := spec.Parse(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return page, false
+	}
+	return page, true
 }
 
 // Organization handlers
@@ -50,11 +118,19 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slug := GenerateSlug(req.Name, usr.ID)
-	org, err := h.repo.CreateWithOwner(r.Context(), req.Name, slug, usr.ID)
+	var org *Organization
+	var err error
+	if req.Slug == "" {
+		org, err = h.repo.CreateWithGeneratedSlug(r.Context(), req.Name, req.Name, usr.ID)
+	} else if err = ValidateSlug(req.Slug); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	} else {
+		org, err = h.repo.CreateWithOwner(r.Context(), req.Name, req.Slug, usr.ID)
+	}
 	if err != nil {
 		if errors.Is(err, ErrSlugExists) {
-			response.BadRequest(w, "organization with similar name already exists")
+			response.BadRequest(w, "slug is already taken")
 			return
 		}
 		response.InternalError(w, "failed to create organization")
@@ -74,495 +150,1731 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orgs, err := h.repo.GetUserOrganizations(r.Context(), usr.ID)
+	page, ok := parsePage(w, r, UserOrganizationsPage)
+	if !ok {
+		return
+	}
+
+	version, err := h.repo.GetUserOrganizationsVersion(r.Context(), usr.ID)
 	if err != nil {
 		response.InternalError(w, "failed to list organizations")
 		return
 	}
+	if notModified(w, r, version.ETag(r.URL.Query())) {
+		return
+	}
 
-	response.OK(w, orgs)
+	orgs, next, err := h.repo.GetUserOrganizations(r.Context(), usr.ID, page)
+	if err != nil {
+		response.InternalError(w, "failed to list organizations")
+		return
+	}
+
+	response.Page(w, orgs, next)
 }
 
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+	oc := FromContext(r.Context())
+
+	if notModified(w, r, request.ETag(oc.Organization.UpdatedAt)) {
+		return
+	}
+
+	response.OK(w, OrganizationWithRole{
+		Organization: *oc.Organization,
+		Role:         oc.Member.Role,
+	})
+}
+
+// Update applies a JSON merge patch to the organization's name, slug and
+// settings. An If-Match header makes the update conditional on the version
+// the client last read.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+
+	var req UpdateOrgRequest
+	if !decodePatch(w, r, &req) {
+		return
+	}
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
+	if req.Name.Null || req.Slug.Null {
+		response.BadRequest(w, "name and slug cannot be null")
+		return
+	}
+	if req.Slug.Set && req.Slug.Value != oc.Organization.Slug && !oc.Can(PermOrgSlug) {
+		response.Forbidden(w, "only owners can change the slug")
+		return
+	}
+
+	var errInvalid error
+	org, err := h.repo.Update(r.Context(), oc.Organization.ID, func(org *Organization) error {
+		if !request.IfMatch(r, request.ETag(org.UpdatedAt)) {
+			return request.ErrPreconditionFailed
+		}
+		errInvalid = req.apply(org)
+		return errInvalid
+	})
 	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
-			return
+		switch {
+		case errInvalid != nil:
+			response.BadRequest(w, errInvalid.Error())
+		case errors.Is(err, request.ErrPreconditionFailed):
+			response.PreconditionFailed(w, "organization was modified by someone else")
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "organization not found")
+		case errors.Is(err, ErrSlugExists):
+			response.BadRequest(w, "slug is already taken")
+		default:
+			response.InternalError(w, "failed to update organization")
 		}
-		response.InternalError(w, "failed to check membership")
 		return
 	}
 
-	org, err := h.repo.GetByID(r.Context(), orgID)
+	w.Header().Set("ETag", request.ETag(org.UpdatedAt))
+	response.OK(w, OrganizationWithRole{
+		Organization: *org,
+		Role:         oc.Member.Role,
+	})
+}
+
+// apply validates the patch and writes it onto org.
+func (p *UpdateOrgRequest) apply(org *Organization) error {
+	if p.Name.Set {
+		name := strings.TrimSpace(p.Name.Value)
+		if name == "" {
+			return errors.New("name cannot be empty")
+		}
+		org.Name = name
+	}
+	if p.Slug.Set && p.Slug.Value != org.Slug {
+		if err := ValidateSlug(p.Slug.Value); err != nil {
+			return err
+		}
+		org.Slug = p.Slug.Value
+	}
+	if p.Settings.Null {
+		org.Settings = DefaultSettings()
+	} else if p.Settings.Set {
+		settings, err := org.Settings.Merge(p.Settings.Value)
+		if err != nil {
+			return err
+		}
+		org.Settings = settings
+	}
+	return nil
+}
+
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	org := FromContext(r.Context()).Organization
+
+	if notModified(w, r, request.ETag(org.UpdatedAt)) {
+		return
+	}
+
+	response.OK(w, org.Settings)
+}
+
+// UpdateSettings applies a JSON merge patch to the settings document alone.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	var patch json.RawMessage
+	if !decodePatch(w, r, &patch) {
+		return
+	}
+
+	var errInvalid error
+	org, err := h.repo.Update(r.Context(), orgID, func(org *Organization) error {
+		if !request.IfMatch(r, request.ETag(org.UpdatedAt)) {
+			return request.ErrPreconditionFailed
+		}
+		org.Settings, errInvalid = org.Settings.Merge(patch)
+		return errInvalid
+	})
 	if err != nil {
+		switch {
+		case errInvalid != nil:
+			response.BadRequest(w, errInvalid.Error())
+		case errors.Is(err, request.ErrPreconditionFailed):
+			response.PreconditionFailed(w, "organization was modified by someone else")
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "organization not found")
+		default:
+			response.InternalError(w, "failed to update settings")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", request.ETag(org.UpdatedAt))
+	response.OK(w, org.Settings)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	if err := h.repo.Delete(r.Context(), orgID); err != nil {
 		if errors.Is(err, ErrNotFound) {
 			response.NotFound(w, "organization not found")
 			return
 		}
-		response.InternalError(w, "failed to get organization")
+		response.InternalError(w, "failed to delete organization")
 		return
 	}
 
-	response.OK(w, OrganizationWithRole{
-		Organization: *org,
-		Role:         member.Role,
-	})
+	response.NoContent(w)
 }
 
-func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+// ListDeleted returns organizations the user can still restore
+func (h *Handler) ListDeleted(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	if usr == nil {
+		response.Unauthorized(w, "authentication required")
+		return
+	}
+
+	orgs, err := h.repo.GetDeletedOrganizations(r.Context(), usr.ID, time.Now().Add(-DeletionRetention))
+	if err != nil {
+		response.InternalError(w, "failed to list deleted organizations")
+		return
+	}
+
+	response.OK(w, orgs)
+}
+
+// Restore undoes a soft delete within the retention window. Deleted
+// organizations are invisible to Resolve, so ownership is checked here.
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
 	usr := middleware.GetUserFromContext(r.Context())
+	if usr == nil {
+		response.Unauthorized(w, "authentication required")
+		return
+	}
+
 	orgID := chi.URLParam(r, "orgID")
+	if !uuidPattern.MatchString(orgID) {
+		response.NotFound(w, "organization not found")
+		return
+	}
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
+	org, err := h.repo.Restore(r.Context(), orgID, usr.ID, time.Now().Add(-DeletionRetention))
 	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "no restorable organization found")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to restore organization")
 		return
 	}
 
-	if !member.Role.CanManageMembers() {
-		response.Forbidden(w, "insufficient permissions")
+	response.OK(w, org)
+}
+
+// Member handlers
+
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	page, ok := parsePage(w, r, MembersPage)
+	if !ok {
+		return
+	}
+	for _, name := range []string{"joined_after", "joined_before"} {
+		if v := page.Filters[name]; v != "" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				response.BadRequest(w, "filter["+name+"] must be an RFC 3339 timestamp")
+				return
+			}
+		}
+	}
+
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(search) > maxMemberSearchLength {
+		response.BadRequest(w, fmt.Sprintf("q must be at most %d characters", maxMemberSearchLength))
 		return
 	}
 
-	var req UpdateOrgRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	version, err := h.repo.GetMembersVersion(r.Context(), orgID)
+	if err != nil {
+		response.InternalError(w, "failed to list members")
+		return
+	}
+	if notModified(w, r, version.ETag(r.URL.Query())) {
 		return
 	}
 
-	if req.Name == "" {
-		response.BadRequest(w, "name is required")
+	members, next, err := h.repo.GetMembers(r.Context(), orgID, search, page)
+	if err != nil {
+		response.InternalError(w, "failed to list members")
 		return
 	}
 
-	org, err := h.repo.Update(r.Context(), orgID, req.Name)
+	response.Page(w, members, next)
+}
+
+func (h *Handler) GetMember(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	member, err := h.repo.GetMemberWithUser(r.Context(), orgID, chi.URLParam(r, "userID"))
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			response.NotFound(w, "organization not found")
+		if errors.Is(err, ErrNotMember) {
+			response.NotFound(w, "member not found")
 			return
 		}
-		response.InternalError(w, "failed to update organization")
+		response.InternalError(w, "failed to get member")
 		return
 	}
 
-	response.OK(w, OrganizationWithRole{
-		Organization: *org,
-		Role:         member.Role,
-	})
+	if notModified(w, r, request.ETag(member.UpdatedAt)) {
+		return
+	}
+
+	response.OK(w, member)
 }
 
-func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+// UpdateMember applies a JSON merge patch to a membership. Role is the only
+// mutable field today.
+func (h *Handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
+	targetUserID := chi.URLParam(r, "userID")
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
+	var req UpdateMemberRequest
+	if !decodePatch(w, r, &req) {
+		return
+	}
+
+	if req.Role.Null || (req.Role.Set && req.Role.Value == "") {
+		response.BadRequest(w, "role cannot be empty")
+		return
+	}
+
+	targetMember, err := h.repo.GetMember(r.Context(), orgID, targetUserID)
 	if err != nil {
 		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+			response.NotFound(w, "member not found")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to get member")
 		return
 	}
 
-	if !member.Role.CanDeleteOrg() {
-		response.Forbidden(w, "only owners can delete organizations")
+	if !request.IfMatch(r, request.ETag(targetMember.UpdatedAt)) {
+		response.PreconditionFailed(w, "member was modified by someone else")
 		return
 	}
 
-	if err := h.repo.Delete(r.Context(), orgID); err != nil {
-		if errors.Is(err, ErrNotFound) {
-			response.NotFound(w, "organization not found")
-			return
+	// With If-Match the repository re-checks the version under its row lock
+	var version *time.Time
+	if r.Header.Get("If-Match") != "" {
+		version = &targetMember.UpdatedAt
+	}
+
+	if !req.Role.Set || req.Role.Value == targetMember.Role {
+		w.Header().Set("ETag", request.ETag(targetMember.UpdatedAt))
+		response.OK(w, targetMember)
+		return
+	}
+	role := req.Role.Value
+
+	// Nobody changes their own role, and the caller must hold every
+	// permission of both the member's current role and the new one
+	if targetUserID == oc.Member.UserID {
+		response.Forbidden(w, "cannot change your own role")
+		return
+	}
+	if !h.canGrant(w, r, targetMember.Role) || !h.canGrant(w, r, role) {
+		return
+	}
+
+	// Owner changes are checked against the locked membership
+	member, err := h.repo.UpdateMemberRole(r.Context(), orgID, targetUserID, role, oc.Can(PermMembersManageOwners), version)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidRole):
+			response.BadRequest(w, "invalid role")
+		case errors.Is(err, ErrOwnerRole):
+			response.Forbidden(w, "only owners can change owner roles")
+		case errors.Is(err, ErrLastOwner):
+			response.BadRequest(w, "cannot demote the last owner")
+		case errors.Is(err, request.ErrPreconditionFailed):
+			response.PreconditionFailed(w, "member was modified by someone else")
+		case errors.Is(err, ErrNotMember):
+			response.NotFound(w, "member not found")
+		default:
+			response.InternalError(w, "failed to update role")
 		}
-		response.InternalError(w, "failed to delete organization")
 		return
 	}
 
-	response.NoContent(w)
+	h.notifier.Send(r.Context(), notification.Message{
+		UserID:         targetUserID,
+		Type:           notification.TypeRoleChanged,
+		OrganizationID: orgID,
+		Title:          fmt.Sprintf("Your role in %s changed", oc.Organization.Name),
+		Body:           fmt.Sprintf("You are now %s in %s.", withArticle(string(role)), oc.Organization.Name),
+	})
+	h.publishMember(r.Context(), events.TypeMemberUpdated, member)
+
+	w.Header().Set("ETag", request.ETag(member.UpdatedAt))
+	response.OK(w, member)
 }
 
-// Member handlers
+// canGrant reports whether the caller may hand out role, which takes holding
+// every permission it grants. Otherwise it writes the error response.
+func (h *Handler) canGrant(w http.ResponseWriter, r *http.Request, role Role) bool {
+	oc := FromContext(r.Context())
+	perms, err := h.authz.RolePermissions(r.Context(), oc.Organization.ID, role)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRole) {
+			response.BadRequest(w, "invalid role")
+			return false
+		}
+		response.InternalError(w, "failed to check role")
+		return false
+	}
+	if !oc.CanAll(perms) {
+		response.Forbidden(w, "role has permissions you do not have")
+		return false
+	}
+	return true
+}
 
-func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
+	targetUserID := chi.URLParam(r, "userID")
 
-	_, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
+	targetMember, err := h.repo.GetMember(r.Context(), orgID, targetUserID)
 	if err != nil {
 		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+			response.NotFound(w, "member not found")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to get member")
 		return
 	}
 
-	members, err := h.repo.GetMembers(r.Context(), orgID)
-	if err != nil {
-		response.InternalError(w, "failed to list members")
+	// Admins cannot remove owners
+	if targetMember.Role == RoleOwner && !oc.Can(PermMembersManageOwners) {
+		response.Forbidden(w, "admins cannot remove owners")
 		return
 	}
 
-	response.OK(w, members)
+	if err := h.repo.RemoveMember(r.Context(), orgID, targetUserID); err != nil {
+		switch {
+		case errors.Is(err, ErrLastOwner):
+			response.BadRequest(w, "cannot remove the last owner")
+		case errors.Is(err, ErrNotMember):
+			response.NotFound(w, "member not found")
+		default:
+			response.InternalError(w, "failed to remove member")
+		}
+		return
+	}
+
+	h.notifier.Send(r.Context(), notification.Message{
+		UserID:         targetUserID,
+		Type:           notification.TypeRemoved,
+		OrganizationID: orgID,
+		Title:          fmt.Sprintf("You were removed from %s", oc.Organization.Name),
+		Body:           fmt.Sprintf("You no longer have access to %s.", oc.Organization.Name),
+	})
+	h.publishMember(r.Context(), events.TypeMemberRemoved, targetMember)
+
+	response.NoContent(w)
 }
 
-func (h *Handler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Leave(w http.ResponseWriter, r *http.Request) {
 	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
-	targetUserID := chi.URLParam(r, "userID")
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+	if err := h.repo.RemoveMember(r.Context(), orgID, usr.ID); err != nil {
+		if errors.Is(err, ErrLastOwner) {
+			response.BadRequest(w, "cannot leave as the last owner - transfer ownership or delete the organization")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to leave organization")
 		return
 	}
+	h.publishMember(r.Context(), events.TypeMemberRemoved, oc.Member)
 
-	if !member.Role.CanManageMembers() {
-		response.Forbidden(w, "insufficient permissions")
+	response.NoContent(w)
+}
+
+// TransferOwnership starts a transfer to another member. Nothing changes
+// until they accept it.
+func (h *Handler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	org := FromContext(r.Context()).Organization
+
+	var req TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.NewOwnerID == "" {
+		response.BadRequest(w, "new_owner_id is required")
+		return
+	}
+
+	if req.NewOwnerID == usr.ID {
+		response.BadRequest(w, "cannot transfer ownership to yourself")
+		return
+	}
+
+	transfer, err := h.repo.CreateOwnershipTransfer(r.Context(), org.ID, usr.ID, req.NewOwnerID, time.Now().Add(transferWindow))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotOwner):
+			response.Forbidden(w, "only owners can transfer ownership")
+		case errors.Is(err, ErrNotMember):
+			response.BadRequest(w, "new owner must be a member of the organization")
+		case errors.Is(err, ErrAlreadyOwner):
+			response.BadRequest(w, "user is already an owner")
+		case errors.Is(err, ErrTransferPending):
+			response.BadRequest(w, "an ownership transfer is already pending - cancel it first")
+		default:
+			response.InternalError(w, "failed to transfer ownership")
+		}
+		return
+	}
+
+	// Notify opts in to email on top of the recipient's preferences
+	h.notifier.Send(r.Context(), notification.Message{
+		UserID:         req.NewOwnerID,
+		Type:           notification.TypeOwnershipTransfer,
+		OrganizationID: org.ID,
+		Title:          fmt.Sprintf("%s wants to make you the owner of %s", usr.Name, org.Name),
+		Body: fmt.Sprintf(
+			"%s has asked you to take over ownership of %s. Sign in to accept or decline before %s.",
+			usr.Name, org.Name, transfer.ExpiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+		),
+		NoEmail: !req.Notify,
+	})
+
+	response.Created(w, transfer)
+}
+
+// GetTransfer returns the pending transfer to the owners who may cancel it
+// and to its recipient.
+func (h *Handler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+
+	transfer, err := h.repo.GetPendingTransfer(r.Context(), oc.Organization.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "no pending ownership transfer")
+			return
+		}
+		response.InternalError(w, "failed to get ownership transfer")
+		return
+	}
+
+	if !oc.Can(PermOrgTransfer) && transfer.ToUserID != oc.Member.UserID {
+		response.NotFound(w, "no pending ownership transfer")
+		return
+	}
+
+	response.OK(w, transfer)
+}
+
+// CancelTransfer withdraws (owners) or declines (recipient) the pending
+// transfer.
+func (h *Handler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+
+	transfer, err := h.repo.GetPendingTransfer(r.Context(), oc.Organization.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "no pending ownership transfer")
+			return
+		}
+		response.InternalError(w, "failed to cancel ownership transfer")
+		return
+	}
+
+	if !oc.Can(PermOrgTransfer) && transfer.ToUserID != oc.Member.UserID {
+		response.NotFound(w, "no pending ownership transfer")
+		return
+	}
+
+	if err := h.repo.CancelOwnershipTransfer(r.Context(), oc.Organization.ID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "no pending ownership transfer")
+			return
+		}
+		response.InternalError(w, "failed to cancel ownership transfer")
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// AcceptTransfer completes the pending transfer addressed to the caller.
+func (h *Handler) AcceptTransfer(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	usr := middleware.GetUserFromContext(r.Context())
+
+	transfer, err := h.repo.TransferOwnership(r.Context(), oc.Organization.ID, oc.Member.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "no pending ownership transfer to you")
+		case errors.Is(err, ErrNotOwner):
+			response.BadRequest(w, "the user who started this transfer is no longer an owner")
+		default:
+			response.InternalError(w, "failed to accept ownership transfer")
+		}
+		return
+	}
+
+	h.notifier.Send(r.Context(), notification.Message{
+		UserID:         transfer.FromUserID,
+		Type:           notification.TypeOwnershipTransfer,
+		OrganizationID: oc.Organization.ID,
+		Title:          fmt.Sprintf("%s is now the owner of %s", usr.Name, oc.Organization.Name),
+		Body:           fmt.Sprintf("%s accepted your ownership transfer. You are now an admin of %s.", usr.Name, oc.Organization.Name),
+	})
+	for _, userID := range []string{transfer.ToUserID, transfer.FromUserID} {
+		if member, err := h.repo.GetMember(r.Context(), oc.Organization.ID, userID); err == nil {
+			h.publishMember(r.Context(), events.TypeMemberUpdated, member)
+		}
+	}
+
+	response.NoContent(w)
+}
+
+// MyTransfers lists pending transfers the current user started or was sent.
+func (h *Handler) MyTransfers(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	if usr == nil {
+		response.Unauthorized(w, "authentication required")
+		return
+	}
+
+	transfers, err := h.repo.GetPendingTransfersForUser(r.Context(), usr.ID)
+	if err != nil {
+		response.InternalError(w, "failed to list ownership transfers")
+		return
+	}
+
+	response.OK(w, transfers)
+}
+
+// publishMember tells the organization's members, and the member
+// themselves, about a change to a membership.
+func (h *Handler) publishMember(ctx context.Context, typ events.Type, member *Member) {
+	h.events.Publish(ctx, events.Event{
+		Type:           typ,
+		OrganizationID: member.OrganizationID,
+		UserID:         member.UserID,
+		Data:           member,
+	})
+}
+
+// publishInvitation tells the invitee, and the members who may list the
+// organization's invitations, about a change to an invitation.
+func (h *Handler) publishInvitation(ctx context.Context, typ events.Type, inv *Invitation) {
+	h.events.Publish(ctx, events.Event{
+		Type:           typ,
+		OrganizationID: inv.OrganizationID,
+		Email:          inv.Email,
+		Permission:     string(PermInvitationsRead),
+		Data: InvitationEvent{
+			ID:        inv.ID,
+			Role:      inv.Role,
+			ExpiresAt: inv.ExpiresAt,
+		},
+	})
+}
+
+// withArticle prefixes a role name with "a" or "an" for notification text.
+func withArticle(role string) string {
+	if role != "" && strings.ContainsRune("aeiou", rune(role[0])) {
+		return "an " + role
+	}
+	return "a " + role
+}
+
+// Invitation handlers
+
+func (h *Handler) Invite(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	org := FromContext(r.Context()).Organization
+	orgID := org.ID
+
+	var req InviteMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.Email == "" {
+		response.BadRequest(w, "email is required")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = org.Settings.DefaultMemberRole
+	}
+	if !req.Role.IsValid() || req.Role == RoleOwner {
+		response.BadRequest(w, "invalid role - must be admin or member")
+		return
+	}
+	if !h.canGrant(w, r, req.Role) {
+		return
+	}
+
+	if !org.Settings.AllowsEmail(req.Email) {
+		response.BadRequest(w, "email domain is not allowed by this organization")
+		return
+	}
+
+	// Check if already a member
+	isMember, err := h.repo.IsMemberByEmail(r.Context(), orgID, req.Email)
+	if err != nil {
+		response.InternalError(w, "failed to check membership")
+		return
+	}
+	if isMember {
+		response.BadRequest(w, "user is already a member of this organization")
+		return
+	}
+
+	expiresAt := time.Now().Add(org.Settings.InvitationExpiry())
+	inv, err := h.repo.CreateInvitation(r.Context(), orgID, req.Email, req.Role, &usr.ID, expiresAt)
+	if err != nil {
+		if errors.Is(err, ErrInviteExists) {
+			response.BadRequest(w, "pending invitation already exists for this email")
+			return
+		}
+		response.InternalError(w, "failed to create invitation")
+		return
+	}
+
+	h.notifier.Send(r.Context(), notification.Message{
+		Email:          req.Email,
+		Type:           notification.TypeInvitation,
+		OrganizationID: orgID,
+		Title:          fmt.Sprintf("%s invited you to join %s", usr.Name, org.Name),
+		Body: fmt.Sprintf(
+			"%s invited you to join %s as %s. Sign in to accept or decline before %s.",
+			usr.Name, org.Name, withArticle(string(inv.Role)), inv.ExpiresAt.UTC().Format("January 2, 2006"),
+		),
+	})
+	h.publishInvitation(r.Context(), events.TypeInvitationCreated, inv)
+
+	response.Created(w, inv)
+}
+
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	page, ok := parsePage(w, r, InvitationsPage)
+	if !ok {
+		return
+	}
+
+	invitations, next, err := h.repo.GetPendingInvitationsForOrg(r.Context(), orgID, page)
+	if err != nil {
+		response.InternalError(w, "failed to list invitations")
+		return
+	}
+
+	response.Page(w, invitations, next)
+}
+
+func (h *Handler) CancelInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	inviteID := chi.URLParam(r, "inviteID")
+
+	// Verify invitation belongs to this org
+	inv, err := h.repo.GetInvitationByID(r.Context(), inviteID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "invitation not found")
+			return
+		}
+		response.InternalError(w, "failed to get invitation")
+		return
+	}
+
+	if inv.OrganizationID != orgID {
+		response.NotFound(w, "invitation not found")
+		return
+	}
+
+	if err := h.repo.DeleteInvitation(r.Context(), inviteID); err != nil {
+		response.InternalError(w, "failed to cancel invitation")
+		return
+	}
+	h.publishInvitation(r.Context(), events.TypeInvitationCancelled, inv)
+
+	response.NoContent(w)
+}
+
+// Join link handlers
+
+func (h *Handler) CreateJoinLink(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	orgID := FromContext(r.Context()).Organization.ID
+
+	var req CreateJoinLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if !req.Role.IsValid() || req.Role == RoleOwner {
+		response.BadRequest(w, "invalid role - must be admin or member")
+		return
+	}
+	if !h.canGrant(w, r, req.Role) {
+		return
+	}
+
+	if req.MaxUses != nil && *req.MaxUses <= 0 {
+		response.BadRequest(w, "max_uses must be positive")
+		return
+	}
+
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = invitationExpiryDays
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxJoinLinkExpiryDays {
+		response.BadRequest(w, "expires_in_days must be between 1 and 30")
+		return
+	}
+
+	var allowedDomain *string
+	if req.AllowedDomain != "" {
+		domain, ok := NormalizeDomain(req.AllowedDomain)
+		if !ok {
+			response.BadRequest(w, "invalid allowed_domain")
+			return
+		}
+		allowedDomain = &domain
+	}
+
+	expiresAt := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+	link, err := h.repo.CreateJoinLink(r.Context(), orgID, req.Role, req.MaxUses, allowedDomain, usr.ID, expiresAt)
+	if err != nil {
+		response.InternalError(w, "failed to create join link")
+		return
+	}
+
+	response.Created(w, link)
+}
+
+func (h *Handler) ListJoinLinks(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	links, err := h.repo.GetActiveJoinLinksForOrg(r.Context(), orgID)
+	if err != nil {
+		response.InternalError(w, "failed to list join links")
+		return
+	}
+
+	response.OK(w, links)
+}
+
+func (h *Handler) RevokeJoinLink(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	linkID := chi.URLParam(r, "linkID")
+
+	// Verify join link belongs to this org
+	link, err := h.repo.GetJoinLinkByID(r.Context(), linkID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "join link not found")
+			return
+		}
+		response.InternalError(w, "failed to get join link")
+		return
+	}
+
+	if link.OrganizationID != orgID {
+		response.NotFound(w, "join link not found")
+		return
+	}
+
+	if err := h.repo.RevokeJoinLink(r.Context(), linkID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.BadRequest(w, "join link is already revoked")
+			return
+		}
+		response.InternalError(w, "failed to revoke join link")
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *Handler) PreviewJoinLink(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	preview, err := h.repo.GetJoinLinkPreview(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "join link not found")
+			return
+		}
+		response.InternalError(w, "failed to get join link")
+		return
+	}
+
+	response.OK(w, preview)
+}
+
+func (h *Handler) RedeemJoinLink(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	if usr == nil {
+		response.Unauthorized(w, "authentication required")
+		return
+	}
+
+	token := chi.URLParam(r, "token")
+
+	link, err := h.repo.RedeemJoinLink(r.Context(), token, usr.ID, usr.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "join link not found")
+		case errors.Is(err, ErrJoinLinkRevoked), errors.Is(err, ErrJoinLinkExpired), errors.Is(err, ErrJoinLinkExhausted):
+			response.BadRequest(w, err.Error())
+		case errors.Is(err, ErrDomainNotAllowed):
+			response.Forbidden(w, "join link is restricted to another email domain")
+		case errors.Is(err, ErrAlreadyMember):
+			response.BadRequest(w, "already a member of this organization")
+		default:
+			response.InternalError(w, "failed to join organization")
+		}
+		return
+	}
+
+	if member, err := h.repo.GetMember(r.Context(), link.OrganizationID, usr.ID); err == nil {
+		h.publishMember(r.Context(), events.TypeMemberAdded, member)
+	}
+
+	org, err := h.repo.GetByID(r.Context(), link.OrganizationID)
+	if err != nil {
+		response.InternalError(w, "failed to get organization")
+		return
+	}
+
+	response.OK(w, OrganizationWithRole{
+		Organization: *org,
+		Role:         link.Role,
+	})
+}
+
+// Domain handlers
+
+func (h *Handler) ClaimDomain(w http.ResponseWriter, r *http.Request) {
+	usr := middleware.GetUserFromContext(r.Context())
+	orgID := FromContext(r.Context()).Organization.ID
+
+	var req ClaimDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	domain, ok := NormalizeDomain(req.Domain)
+	if !ok {
+		response.BadRequest(w, "invalid domain")
+		return
+	}
+
+	if req.DefaultRole == "" {
+		req.DefaultRole = RoleMember
+	}
+	if !req.DefaultRole.IsValid() || req.DefaultRole == RoleOwner {
+		response.BadRequest(w, "invalid default_role - must be admin or member")
+		return
+	}
+	if !h.canGrant(w, r, req.DefaultRole) {
+		return
+	}
+
+	if req.JoinMode == "" {
+		req.JoinMode = JoinModeInvite
+	}
+	if !req.JoinMode.IsValid() {
+		response.BadRequest(w, "invalid join_mode - must be join or invite")
+		return
+	}
+
+	d, err := h.repo.ClaimDomain(r.Context(), orgID, domain, req.DefaultRole, req.JoinMode, usr.ID)
+	if err != nil {
+		if errors.Is(err, ErrDomainExists) {
+			response.BadRequest(w, "domain already claimed by this organization")
+			return
+		}
+		response.InternalError(w, "failed to claim domain")
+		return
+	}
+
+	response.Created(w, withInstructions(*d))
+}
+
+func (h *Handler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	domains, err := h.repo.GetDomainsForOrg(r.Context(), orgID)
+	if err != nil {
+		response.InternalError(w, "failed to list domains")
+		return
+	}
+
+	result := make([]DomainWithInstructions, 0, len(domains))
+	for _, d := range domains {
+		result = append(result, withInstructions(d))
+	}
+
+	response.OK(w, result)
+}
+
+func (h *Handler) VerifyDomain(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	domainID := chi.URLParam(r, "domainID")
+
+	d, err := h.repo.GetDomainByID(r.Context(), domainID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "domain not found")
+			return
+		}
+		response.InternalError(w, "failed to get domain")
+		return
+	}
+
+	if d.OrganizationID != orgID {
+		response.NotFound(w, "domain not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), dnsLookupTimeout)
+	defer cancel()
+
+	found, err := checkDomainTXT(ctx, h.resolver, d)
+	if err != nil {
+		response.InternalError(w, "failed to look up DNS records")
+		return
+	}
+	if !found {
+		response.BadRequest(w, "verification TXT record not found")
+		return
+	}
+
+	d, err = h.repo.MarkDomainVerified(r.Context(), domainID)
+	if err != nil {
+		if errors.Is(err, ErrDomainClaimed) {
+			response.BadRequest(w, "domain is already verified by another organization")
+			return
+		}
+		response.InternalError(w, "failed to verify domain")
+		return
+	}
+
+	response.OK(w, withInstructions(*d))
+}
+
+func (h *Handler) UpdateDomain(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	domainID := chi.URLParam(r, "domainID")
+
+	var req UpdateDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if !req.DefaultRole.IsValid() || req.DefaultRole == RoleOwner {
+		response.BadRequest(w, "invalid default_role - must be admin or member")
+		return
+	}
+	if !h.canGrant(w, r, req.DefaultRole) {
+		return
+	}
+
+	if !req.JoinMode.IsValid() {
+		response.BadRequest(w, "invalid join_mode - must be join or invite")
+		return
+	}
+
+	d, err := h.repo.GetDomainByID(r.Context(), domainID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "domain not found")
+			return
+		}
+		response.InternalError(w, "failed to get domain")
+		return
+	}
+
+	if d.OrganizationID != orgID {
+		response.NotFound(w, "domain not found")
+		return
+	}
+
+	d, err = h.repo.UpdateDomain(r.Context(), domainID, req.DefaultRole, req.JoinMode)
+	if err != nil {
+		response.InternalError(w, "failed to update domain")
+		return
+	}
+
+	response.OK(w, withInstructions(*d))
+}
+
+func (h *Handler) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	domainID := chi.URLParam(r, "domainID")
+
+	d, err := h.repo.GetDomainByID(r.Context(), domainID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "domain not found")
+			return
+		}
+		response.InternalError(w, "failed to get domain")
+		return
+	}
+
+	if d.OrganizationID != orgID {
+		response.NotFound(w, "domain not found")
+		return
+	}
+
+	if err := h.repo.DeleteDomain(r.Context(), domainID); err != nil {
+		response.InternalError(w, "failed to delete domain")
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// Role handlers
+
+var builtInRoles = []RoleDefinition{
+	{Name: RoleOwner, Description: "Full control, including deleting the organization", BuiltIn: true},
+	{Name: RoleAdmin, Description: "Manage members, invitations and settings", BuiltIn: true},
+	{Name: RoleMember, Description: "View the organization and its members, and create projects", BuiltIn: true},
+}
+
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	custom, err := h.repo.GetRoles(r.Context(), orgID)
+	if err != nil {
+		response.InternalError(w, "failed to list roles")
+		return
+	}
+
+	roles := make([]RoleDefinition, 0, len(builtInRoles)+len(custom))
+	for _, role := range builtInRoles {
+		role.Permissions = role.Name.Permissions()
+		roles = append(roles, role)
+	}
+	for _, role := range custom {
+		roles = append(roles, RoleDefinition{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	response.OK(w, roles)
+}
+
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
+
+	var req CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.Name.IsValid() {
+		response.BadRequest(w, "built-in roles cannot be redefined")
+		return
+	}
+	if !req.Name.IsValidCustomName() {
+		response.BadRequest(w, "invalid role name - use 2-32 lowercase letters, digits, '-' or '_'")
+		return
+	}
+
+	perms, ok := validatePermissions(w, req.Permissions)
+	if !ok {
+		return
+	}
+	if !oc.CanAll(perms) {
+		response.Forbidden(w, "cannot grant permissions you do not have")
+		return
+	}
+
+	role, err := h.repo.CreateRole(r.Context(), orgID, req.Name, req.Description, perms)
+	if err != nil {
+		if errors.Is(err, ErrRoleExists) {
+			response.BadRequest(w, "role already exists")
+			return
+		}
+		response.InternalError(w, "failed to create role")
+		return
+	}
+
+	response.Created(w, role)
+}
+
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
+	roleName := Role(chi.URLParam(r, "roleName"))
+
+	if roleName.IsValid() {
+		response.BadRequest(w, "built-in roles cannot be modified")
+		return
+	}
+	if roleName == oc.Member.Role {
+		response.Forbidden(w, "cannot change your own role")
 		return
 	}
 
-	var req UpdateMemberRoleRequest
+	var req UpdateRoleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.BadRequest(w, "invalid request body")
 		return
 	}
 
-	if !req.Role.IsValid() {
-		response.BadRequest(w, "invalid role")
+	perms, ok := validatePermissions(w, req.Permissions)
+	if !ok {
+		return
+	}
+	if !oc.CanAll(perms) {
+		response.Forbidden(w, "cannot grant permissions you do not have")
 		return
 	}
 
-	targetMember, err := h.repo.GetMember(r.Context(), orgID, targetUserID)
+	// Narrowing a role demotes its holders, so the caller must also hold
+	// what it grants today
+	current, err := h.authz.RolePermissions(r.Context(), orgID, roleName)
 	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.NotFound(w, "member not found")
+		if errors.Is(err, ErrInvalidRole) {
+			response.NotFound(w, "role not found")
 			return
 		}
-		response.InternalError(w, "failed to get member")
+		response.InternalError(w, "failed to update role")
 		return
 	}
-
-	// Only owners can promote to owner or demote owners
-	if req.Role == RoleOwner || targetMember.Role == RoleOwner {
-		if member.Role != RoleOwner {
-			response.Forbidden(w, "only owners can change owner roles")
-			return
-		}
+	if !oc.CanAll(current) {
+		response.Forbidden(w, "role has permissions you do not have")
+		return
 	}
 
-	// Check if demoting the last owner
-	if targetMember.Role == RoleOwner && req.Role != RoleOwner {
-		count, err := h.repo.CountOwners(r.Context(), orgID)
-		if err != nil {
-			response.InternalError(w, "failed to check owners")
-			return
-		}
-		if count <= 1 {
-			response.BadRequest(w, "cannot demote the last owner")
+	role, err := h.repo.UpdateRole(r.Context(), orgID, string(roleName), req.Description, perms)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "role not found")
 			return
 		}
-	}
-
-	if err := h.repo.UpdateMemberRole(r.Context(), orgID, targetUserID, req.Role); err != nil {
 		response.InternalError(w, "failed to update role")
 		return
 	}
 
-	response.NoContent(w)
+	response.OK(w, role)
 }
 
-func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
-	targetUserID := chi.URLParam(r, "userID")
-
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
-			return
-		}
-		response.InternalError(w, "failed to check membership")
-		return
-	}
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	roleName := Role(chi.URLParam(r, "roleName"))
 
-	if !member.Role.CanManageMembers() {
-		response.Forbidden(w, "insufficient permissions")
+	if roleName.IsValid() {
+		response.BadRequest(w, "built-in roles cannot be deleted")
 		return
 	}
 
-	targetMember, err := h.repo.GetMember(r.Context(), orgID, targetUserID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.NotFound(w, "member not found")
-			return
+	if err := h.repo.DeleteRole(r.Context(), orgID, string(roleName)); err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "role not found")
+		case errors.Is(err, ErrRoleInUse):
+			response.BadRequest(w, "role is still assigned to members")
+		default:
+			response.InternalError(w, "failed to delete role")
 		}
-		response.InternalError(w, "failed to get member")
 		return
 	}
 
-	// Admins cannot remove owners
-	if targetMember.Role == RoleOwner && member.Role != RoleOwner {
-		response.Forbidden(w, "admins cannot remove owners")
-		return
+	response.NoContent(w)
+}
+
+// validatePermissions checks every permission may be granted to a custom
+// role or team.
+func validatePermissions(w http.ResponseWriter, perms []Permission) (PermissionList, bool) {
+	if err := checkGrantable(perms); err != nil {
+		response.BadRequest(w, err.Error())
+		return nil, false
 	}
+	return slices.Compact(slices.Sorted(slices.Values(perms))), true
+}
 
-	// Prevent removing the last owner
-	if targetMember.Role == RoleOwner {
-		count, err := h.repo.CountOwners(r.Context(), orgID)
-		if err != nil {
-			response.InternalError(w, "failed to check owners")
-			return
-		}
-		if count <= 1 {
-			response.BadRequest(w, "cannot remove the last owner")
-			return
+func checkGrantable(perms []Permission) error {
+	for _, p := range perms {
+		if !p.IsGrantable() {
+			return errors.New("permission cannot be granted: " + string(p))
 		}
 	}
+	return nil
+}
 
-	if err := h.repo.RemoveMember(r.Context(), orgID, targetUserID); err != nil {
-		response.InternalError(w, "failed to remove member")
+// Team handlers
+
+const (
+	maxTeamNameLength        = 100
+	maxTeamDescriptionLength = 500
+)
+
+func (h *Handler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	teams, err := h.repo.GetTeams(r.Context(), orgID)
+	if err != nil {
+		response.InternalError(w, "failed to list teams")
 		return
 	}
 
-	response.NoContent(w)
+	response.OK(w, teams)
 }
 
-func (h *Handler) Leave(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
-			return
-		}
-		response.InternalError(w, "failed to check membership")
+	var req CreateTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
 		return
 	}
 
-	// Check if sole owner
-	if member.Role == RoleOwner {
-		count, err := h.repo.CountOwners(r.Context(), orgID)
-		if err != nil {
-			response.InternalError(w, "failed to check owners")
-			return
-		}
-		if count <= 1 {
-			response.BadRequest(w, "cannot leave as the last owner - transfer ownership or delete the organization")
-			return
-		}
+	name, description := strings.TrimSpace(req.Name), strings.TrimSpace(req.Description)
+	if err := validateTeam(name, description); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if req.ParentID != nil && !uuidPattern.MatchString(*req.ParentID) {
+		response.BadRequest(w, "parent team not found")
+		return
 	}
 
-	if err := h.repo.RemoveMember(r.Context(), orgID, usr.ID); err != nil {
-		response.InternalError(w, "failed to leave organization")
+	perms, ok := validatePermissions(w, req.Permissions)
+	if !ok {
+		return
+	}
+	if !oc.CanAll(perms) {
+		response.Forbidden(w, "cannot grant permissions you do not have")
 		return
 	}
 
-	response.NoContent(w)
+	team, err := h.repo.CreateTeam(r.Context(), orgID, name, description, req.ParentID, perms)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.BadRequest(w, "parent team not found")
+		case errors.Is(err, ErrTeamTooDeep):
+			response.BadRequest(w, "parent team is itself a child team - teams can only be nested one level deep")
+		case errors.Is(err, ErrTeamExists):
+			response.BadRequest(w, "a team with this name already exists")
+		default:
+			response.InternalError(w, "failed to create team")
+		}
+		return
+	}
+
+	response.Created(w, team)
 }
 
-func (h *Handler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
+	team, err := h.repo.GetTeam(r.Context(), orgID, chi.URLParam(r, "teamID"))
 	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "team not found")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to get team")
 		return
 	}
 
-	if !member.Role.CanTransferOwnership() {
-		response.Forbidden(w, "only owners can transfer ownership")
+	response.OK(w, team)
+}
+
+// UpdateTeam applies a merge patch to a team. Maintainers may change its
+// name and description; changing its permissions requires teams:manage.
+func (h *Handler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	teamID := chi.URLParam(r, "teamID")
+
+	if !h.canManageTeam(w, r, teamID) {
 		return
 	}
 
-	var req TransferOwnershipRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	var req UpdateTeamRequest
+	if !decodePatch(w, r, &req) {
 		return
 	}
-
-	if req.NewOwnerID == "" {
-		response.BadRequest(w, "new_owner_id is required")
+	if req.Name.Null || req.Description.Null {
+		response.BadRequest(w, "name and description cannot be null")
 		return
 	}
-
-	if req.NewOwnerID == usr.ID {
-		response.BadRequest(w, "cannot transfer ownership to yourself")
+	if req.Permissions.Present() && !oc.Can(PermTeamsManage) {
+		response.Forbidden(w, "changing team permissions requires "+string(PermTeamsManage))
+		return
+	}
+	if req.Permissions.Set && !oc.CanAll(req.Permissions.Value) {
+		response.Forbidden(w, "cannot grant permissions you do not have")
 		return
 	}
 
-	// Verify new owner is a member
-	_, err = h.repo.GetMember(r.Context(), orgID, req.NewOwnerID)
+	team, err := h.repo.UpdateTeam(r.Context(), oc.Organization.ID, teamID, req.apply)
 	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.BadRequest(w, "new owner must be a member of the organization")
-			return
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "team not found")
+		case errors.Is(err, ErrTeamExists):
+			response.BadRequest(w, "a team with this name already exists")
+		case errors.Is(err, errInvalidTeam):
+			response.BadRequest(w, strings.TrimPrefix(err.Error(), errInvalidTeam.Error()+": "))
+		default:
+			response.InternalError(w, "failed to update team")
 		}
-		response.InternalError(w, "failed to verify new owner")
 		return
 	}
 
-	if err := h.repo.TransferOwnership(r.Context(), orgID, usr.ID, req.NewOwnerID); err != nil {
-		response.InternalError(w, "failed to transfer ownership")
+	response.OK(w, team)
+}
+
+var errInvalidTeam = errors.New("invalid team")
+
+func (req *UpdateTeamRequest) apply(team *Team) error {
+	if req.Name.Set {
+		team.Name = strings.TrimSpace(req.Name.Value)
+	}
+	if req.Description.Set {
+		team.Description = strings.TrimSpace(req.Description.Value)
+	}
+	if err := validateTeam(team.Name, team.Description); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidTeam, err)
+	}
+
+	if req.Permissions.Null {
+		team.Permissions = PermissionList{}
+	} else if req.Permissions.Set {
+		if err := checkGrantable(req.Permissions.Value); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidTeam, err)
+		}
+		team.Permissions = slices.Compact(slices.Sorted(slices.Values(req.Permissions.Value)))
+	}
+	return nil
+}
+
+func validateTeam(name, description string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > maxTeamNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxTeamNameLength)
+	}
+	if len(description) > maxTeamDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxTeamDescriptionLength)
+	}
+	return nil
+}
+
+func (h *Handler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+
+	if err := h.repo.DeleteTeam(r.Context(), orgID, chi.URLParam(r, "teamID")); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "team not found")
+			return
+		}
+		response.InternalError(w, "failed to delete team")
 		return
 	}
 
 	response.NoContent(w)
 }
 
-// Invitation handlers
-
-func (h *Handler) Invite(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+func (h *Handler) ListTeamMembers(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	teamID := chi.URLParam(r, "teamID")
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+	if _, err := h.repo.GetTeam(r.Context(), orgID, teamID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "team not found")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to list team members")
 		return
 	}
 
-	if !member.Role.CanManageMembers() {
-		response.Forbidden(w, "insufficient permissions")
+	members, err := h.repo.GetTeamMembers(r.Context(), orgID, teamID)
+	if err != nil {
+		response.InternalError(w, "failed to list team members")
 		return
 	}
 
-	var req InviteMemberRequest
+	response.OK(w, members)
+}
+
+// SetTeamMember adds an organization member to the team or changes their team
+// role. Requires teams:manage or being a maintainer of the team, and holding
+// every permission the team grants.
+func (h *Handler) SetTeamMember(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	orgID := oc.Organization.ID
+	teamID := chi.URLParam(r, "teamID")
+
+	if !h.canManageTeam(w, r, teamID) {
+		return
+	}
+
+	var req SetTeamMemberRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.BadRequest(w, "invalid request body")
 		return
 	}
-
-	if req.Email == "" {
-		response.BadRequest(w, "email is required")
+	if req.Role == "" {
+		req.Role = TeamRoleMember
+	}
+	if !req.Role.IsValid() {
+		response.BadRequest(w, "role must be maintainer or member")
 		return
 	}
 
-	if !req.Role.IsValid() || req.Role == RoleOwner {
-		response.BadRequest(w, "invalid role - must be admin or member")
+	userID := chi.URLParam(r, "userID")
+	if !uuidPattern.MatchString(userID) {
+		response.BadRequest(w, "user is not a member of the organization")
 		return
 	}
 
-	// Check if already a member
-	isMember, err := h.repo.IsMemberByEmail(r.Context(), orgID, req.Email)
+	// Team members receive the team's grants, which the caller must hold
+	grants, err := h.repo.GetTeamMembershipGrants(r.Context(), orgID, teamID)
 	if err != nil {
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to update team member")
 		return
 	}
-	if isMember {
-		response.BadRequest(w, "user is already a member of this organization")
+	if !oc.CanAll(grants) {
+		response.Forbidden(w, "team has permissions you do not have")
 		return
 	}
 
-	expiresAt := time.Now().Add(invitationExpiryDays * 24 * time.Hour)
-	inv, err := h.repo.CreateInvitation(r.Context(), orgID, req.Email, req.Role, usr.ID, expiresAt)
+	member, err := h.repo.SetTeamMember(r.Context(), orgID, teamID, userID, req.Role)
 	if err != nil {
-		if errors.Is(err, ErrInviteExists) {
-			response.BadRequest(w, "pending invitation already exists for this email")
-			return
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "team not found")
+		case errors.Is(err, ErrNotMember):
+			response.BadRequest(w, "user is not a member of the organization")
+		default:
+			response.InternalError(w, "failed to update team member")
 		}
-		response.InternalError(w, "failed to create invitation")
 		return
 	}
 
-	response.Created(w, inv)
+	response.OK(w, member)
 }
 
-func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
+// RemoveTeamMember removes someone from the team. Members may remove
+// themselves; removing others requires teams:manage or being a maintainer.
+func (h *Handler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	teamID := chi.URLParam(r, "teamID")
+	userID := chi.URLParam(r, "userID")
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
-	if err != nil {
+	if userID != oc.Member.UserID && !h.canManageTeam(w, r, teamID) {
+		return
+	}
+	if !uuidPattern.MatchString(userID) {
+		response.NotFound(w, "team member not found")
+		return
+	}
+
+	if err := h.repo.RemoveTeamMember(r.Context(), oc.Organization.ID, teamID, userID); err != nil {
 		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+			response.NotFound(w, "team member not found")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+		response.InternalError(w, "failed to remove team member")
 		return
 	}
 
-	if !member.Role.CanManageMembers() {
-		response.Forbidden(w, "insufficient permissions")
-		return
-	}
+	response.NoContent(w)
+}
 
-	invitations, err := h.repo.GetPendingInvitationsForOrg(r.Context(), orgID)
-	if err != nil {
-		response.InternalError(w, "failed to list invitations")
-		return
+// canManageTeam reports whether the caller holds teams:manage or maintains
+// the team. On denial it writes the error response.
+func (h *Handler) canManageTeam(w http.ResponseWriter, r *http.Request, teamID string) bool {
+	oc := FromContext(r.Context())
+	if oc.Can(PermTeamsManage) {
+		return true
 	}
 
-	response.OK(w, invitations)
+	role, err := h.repo.GetTeamRole(r.Context(), oc.Organization.ID, teamID, oc.Member.UserID)
+	if err != nil && !errors.Is(err, ErrNotMember) {
+		response.InternalError(w, "failed to check team role")
+		return false
+	}
+	if role != TeamRoleMaintainer {
+		response.Forbidden(w, "insufficient permissions")
+		return false
+	}
+	return true
 }
 
-func (h *Handler) CancelInvitation(w http.ResponseWriter, r *http.Request) {
-	usr := middleware.GetUserFromContext(r.Context())
-	orgID := chi.URLParam(r, "orgID")
-	inviteID := chi.URLParam(r, "inviteID")
+// Audit log handlers
 
-	member, err := h.repo.GetMember(r.Context(), orgID, usr.ID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
-			response.Forbidden(w, "not a member of this organization")
+// AuditLog lists the organization's audit events, newest first. Supports
+// filtering by actor, action and time range, cursor pagination, and full
+// exports with format=csv or format=jsonl.
+func (h *Handler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	orgID := FromContext(r.Context()).Organization.ID
+	q := r.URL.Query()
+
+	filter := audit.Filter{
+		OrganizationID: orgID,
+		ActorID:        q.Get("actor"),
+		Action:         q.Get("action"),
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			response.BadRequest(w, "from must be an RFC 3339 timestamp")
 			return
 		}
-		response.InternalError(w, "failed to check membership")
+	}
+	if v := q.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			response.BadRequest(w, "to must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if filter.ActorID != "" && !uuidPattern.MatchString(filter.ActorID) {
+		response.BadRequest(w, "actor must be a user ID")
 		return
 	}
 
-	if !member.Role.CanManageMembers() {
-		response.Forbidden(w, "insufficient permissions")
+	switch q.Get("format") {
+	case "csv":
+		h.exportAuditCSV(w, r, filter)
+		return
+	case "jsonl":
+		h.exportAuditJSONL(w, r, filter)
+		return
+	case "", "json":
+	default:
+		response.BadRequest(w, "format must be json, csv or jsonl")
 		return
 	}
 
-	// Verify invitation belongs to this org
-	inv, err := h.repo.GetInvitationByID(r.Context(), inviteID)
-	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			response.NotFound(w, "invitation not found")
-			return
-		}
-		response.InternalError(w, "failed to get invitation")
+	page, ok := parsePage(w, r, audit.EventsPage)
+	if !ok {
 		return
 	}
 
-	if inv.OrganizationID != orgID {
-		response.NotFound(w, "invitation not found")
+	events, next, err := h.auditRepo.List(r.Context(), filter, page)
+	if err != nil {
+		response.InternalError(w, "failed to list audit events")
 		return
 	}
 
-	if err := h.repo.DeleteInvitation(r.Context(), inviteID); err != nil {
-		response.InternalError(w, "failed to cancel invitation")
+	response.Page(w, events, next)
+}
+
+func (h *Handler) exportAuditCSV(w http.ResponseWriter, r *http.Request, filter audit.Filter) {
+	ew := &exportWriter{w: w, contentType: "text/csv", filename: "audit-log.csv"}
+	cw := csv.NewWriter(ew)
+	cw.Write([]string{"id", "created_at", "actor_id", "action", "target_type", "target_id", "before", "after", "ip", "request_id"})
+
+	err := h.auditRepo.Stream(r.Context(), filter, func(e *audit.Event) error {
+		return cw.Write([]string{
+			e.ID,
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			deref(e.ActorID),
+			e.Action,
+			e.TargetType,
+			deref(e.TargetID),
+			string(e.Before),
+			string(e.After),
+			deref(e.IP),
+			deref(e.RequestID),
+		})
+	})
+	if err == nil {
+		cw.Flush()
+		err = cw.Error()
+	}
+	h.finishExport(ew, filter, err)
+}
+
+func (h *Handler) exportAuditJSONL(w http.ResponseWriter, r *http.Request, filter audit.Filter) {
+	ew := &exportWriter{w: w, contentType: "application/x-ndjson", filename: "audit-log.jsonl"}
+	enc := json.NewEncoder(ew)
+	err := h.auditRepo.Stream(r.Context(), filter, func(e *audit.Event) error {
+		return enc.Encode(e)
+	})
+	h.finishExport(ew, filter, err)
+}
+
+// exportWriter sends an export's headers with its first byte, so a failure
+// before anything is written can still be answered with an error status.
+type exportWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	ew.start()
+	return ew.w.Write(p)
+}
+
+func (ew *exportWriter) start() {
+	if ew.started {
 		return
 	}
+	ew.started = true
+	ew.w.Header().Set("Content-Type", ew.contentType)
+	ew.w.Header().Set("Content-Disposition", `attachment; filename="`+ew.filename+`"`)
+	ew.w.WriteHeader(http.StatusOK)
+}
 
-	response.NoContent(w)
+// finishExport completes a streamed export. An error before the first byte
+// is a 500; after it the body can only be cut short, so the error is logged
+// and nothing more is written.
+func (h *Handler) finishExport(ew *exportWriter, filter audit.Filter, err error) {
+	switch {
+	case err == nil:
+		ew.start()
+	case !ew.started:
+		response.InternalError(ew.w, "failed to export audit log")
+	default:
+		h.logger.Error("audit log export cut short", "organization_id", filter.OrganizationID, "error", err)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
 
 // User invitation handlers (for invitations sent TO the current user)
@@ -574,13 +1886,18 @@ func (h *Handler) MyInvitations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	invitations, err := h.repo.GetPendingInvitationsForEmail(r.Context(), usr.Email)
+	page, ok := parsePage(w, r, InvitationsPage)
+	if !ok {
+		return
+	}
+
+	invitations, next, err := h.repo.GetPendingInvitationsForEmail(r.Context(), usr.Email, page)
 	if err != nil {
 		response.InternalError(w, "failed to list invitations")
 		return
 	}
 
-	response.OK(w, invitations)
+	response.Page(w, invitations, next)
 }
 
 func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
@@ -621,7 +1938,7 @@ func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Add user as member
-	_, err = h.repo.AddMember(r.Context(), inv.OrganizationID, usr.ID, inv.Role)
+	member, err := h.repo.AddMember(r.Context(), inv.OrganizationID, usr.ID, inv.Role)
 	if err != nil {
 		if errors.Is(err, ErrAlreadyMember) {
 			// Already a member, just mark invitation as accepted
@@ -638,6 +1955,8 @@ func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
 		response.InternalError(w, "failed to update invitation status")
 		return
 	}
+	h.publishMember(r.Context(), events.TypeMemberAdded, member)
+	h.publishInvitation(r.Context(), events.TypeInvitationAccepted, &inv.Invitation)
 
 	// Return the organization
 	org, err := h.repo.GetByID(r.Context(), inv.OrganizationID)
@@ -686,10 +2005,23 @@ func (h *Handler) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
 		response.InternalError(w, "failed to decline invitation")
 		return
 	}
+	h.publishInvitation(r.Context(), events.TypeInvitationDeclined, &inv.Invitation)
 
 	response.NoContent(w)
 }
 
+// Current returns the organization selected by the X-Organization-ID header
+// or the session's active organization, with the caller's role and
+// permissions in it.
+func (h *Handler) Current(w http.ResponseWriter, r *http.Request) {
+	oc := FromContext(r.Context())
+	response.OK(w, CurrentOrganization{
+		Organization: oc.Organization,
+		Role:         oc.Member.Role,
+		Permissions:  oc.Permissions,
+	})
+}
+
 // SetActiveOrg sets the active organization for the current session
 func (h *Handler) SetActiveOrg(w http.ResponseWriter, r *http.Request) {
 	usr := middleware.GetUserFromContext(r.Context())
@@ -711,13 +2043,15 @@ func (h *Handler) SetActiveOrg(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify user is a member of the organization
-	_, err := h.repo.GetMember(r.Context(), req.OrganizationID, usr.ID)
-	if err != nil {
-		if errors.Is(err, ErrNotMember) {
+	if _, err := h.authz.Authorize(r.Context(), usr.ID, req.OrganizationID, PermOrgRead); err != nil {
+		switch {
+		case errors.Is(err, ErrNotMember):
 			response.Forbidden(w, "not a member of this organization")
-			return
+		case errors.Is(err, ErrForbidden):
+			response.Forbidden(w, "insufficient permissions")
+		default:
+			response.InternalError(w, "failed to check membership")
 		}
-		response.InternalError(w, "failed to check membership")
 		return
 	}
 
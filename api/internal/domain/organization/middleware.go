@@ -0,0 +1,245 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"base/api/internal/database"
+	"base/api/internal/middleware"
+	"base/api/pkg/response"
+)
+
+type contextKey string
+
+const orgContextKey contextKey = "organization"
+
+// TenantHeader selects the organization a tenant-scoped request acts in.
+const TenantHeader = "X-Organization-ID"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Context is the caller's view of an organization for the current request:
+// the organization itself, their membership and the permissions it grants.
+type Context struct {
+	Organization *Organization
+	Member       *Member
+	Permissions  []Permission
+}
+
+// Can reports whether the caller holds the permission.
+func (c *Context) Can(p Permission) bool {
+	return slices.Contains(c.Permissions, p)
+}
+
+// CanAll reports whether the caller holds every one of perms.
+func (c *Context) CanAll(perms []Permission) bool {
+	for _, p := range perms {
+		if !c.Can(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// FromContext returns the organization context placed by Resolve or Tenant,
+// or nil.
+func FromContext(ctx context.Context) *Context {
+	if oc, ok := ctx.Value(orgContextKey).(*Context); ok {
+		return oc
+	}
+	return nil
+}
+
+// WithContext returns a copy of ctx carrying the organization context. It
+// also scopes database access to the organization, which row-level security
+// enforces when enabled.
+func WithContext(ctx context.Context, oc *Context) context.Context {
+	ctx = database.WithTenant(ctx, database.Tenant{OrganizationID: oc.Organization.ID})
+	return context.WithValue(ctx, orgContextKey, oc)
+}
+
+// Resolve is middleware that loads the organization named by the URL param
+// (an ID or a slug) together with the caller's membership, and stores both in
+// the request context. Non-members get a 403. Must run after RequireAuth.
+func (a *Authorizer) Resolve(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			usr := middleware.GetUserFromContext(r.Context())
+			if usr == nil {
+				response.Unauthorized(w, "authentication required")
+				return
+			}
+
+			oc, err := a.load(r.Context(), chi.URLParam(r, param), usr.ID)
+			if errors.Is(err, ErrNotFound) {
+				if target, ok := a.slugRedirect(r, param); ok {
+					http.Redirect(w, r, target, http.StatusPermanentRedirect)
+					return
+				}
+			}
+			if err != nil {
+				writeLoadError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithContext(r.Context(), oc)))
+		})
+	}
+}
+
+// Tenant is middleware for routes that act in "the current organization"
+// rather than one named in their path. The organization is taken from, in
+// order, the X-Organization-ID header, the session's active organization, and
+// the URL param (pass "" for none); the first one present wins. It then
+// behaves like Resolve. Must run after RequireAuth.
+func (a *Authorizer) Tenant(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			usr := middleware.GetUserFromContext(r.Context())
+			if usr == nil {
+				response.Unauthorized(w, "authentication required")
+				return
+			}
+
+			ref := tenantRef(r, param)
+			if ref == "" {
+				response.BadRequest(w, "no organization selected - send "+TenantHeader+" or set an active organization")
+				return
+			}
+
+			oc, err := a.load(r.Context(), ref, usr.ID)
+			if err != nil {
+				writeLoadError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithContext(r.Context(), oc)))
+		})
+	}
+}
+
+func tenantRef(r *http.Request, param string) string {
+	if ref := strings.TrimSpace(r.Header.Get(TenantHeader)); ref != "" {
+		return ref
+	}
+	if sess := middleware.GetSessionFromContext(r.Context()); sess != nil && sess.ActiveOrgID != "" {
+		return sess.ActiveOrgID
+	}
+	if param != "" {
+		return chi.URLParam(r, param)
+	}
+	return ""
+}
+
+func writeLoadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.NotFound(w, "organization not found")
+	case errors.Is(err, ErrNotMember):
+		response.Forbidden(w, "not a member of this organization")
+	default:
+		response.InternalError(w, "failed to check membership")
+	}
+}
+
+// Require is middleware that rejects callers lacking the permission in the
+// organization resolved earlier in the chain.
+func Require(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			oc := FromContext(r.Context())
+			if oc == nil {
+				response.InternalError(w, "organization not resolved")
+				return
+			}
+			if !oc.Can(perm) {
+				response.Forbidden(w, "insufficient permissions")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireUUID is middleware that answers 404 when the URL param is not a
+// UUID, so malformed IDs never reach a query.
+func requireUUID(param, notFound string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !uuidPattern.MatchString(chi.URLParam(r, param)) {
+				response.NotFound(w, notFound)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// slugRedirect builds the URL of the same request under the organization's
+// current slug when the path refers to a slug it has since changed from.
+func (a *Authorizer) slugRedirect(r *http.Request, param string) (string, bool) {
+	ref := chi.URLParam(r, param)
+	if uuidPattern.MatchString(ref) {
+		return "", false
+	}
+
+	current, err := a.repo.GetSlugRedirect(r.Context(), ref)
+	if err != nil {
+		return "", false
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if segment == ref {
+			segments[i] = current
+			break
+		}
+	}
+
+	target := strings.Join(segments, "/")
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	return target, true
+}
+
+// Load returns the principal's view of an organization, for callers that
+// check access outside an HTTP middleware chain.
+func (a *Authorizer) Load(ctx context.Context, orgID, principalID string) (*Context, error) {
+	if !uuidPattern.MatchString(orgID) {
+		return nil, ErrNotFound
+	}
+	return a.load(ctx, orgID, principalID)
+}
+
+// load resolves an organization reference and the principal's access to it.
+func (a *Authorizer) load(ctx context.Context, ref, principalID string) (*Context, error) {
+	var org *Organization
+	var err error
+	if uuidPattern.MatchString(ref) {
+		org, err = a.repo.GetByID(ctx, ref)
+	} else {
+		org, err = a.repo.GetBySlug(ctx, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := a.repo.GetMember(ctx, org.ID, principalID)
+	if err != nil {
+		return nil, err
+	}
+
+	perms, err := a.Permissions(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Context{Organization: org, Member: member, Permissions: perms}, nil
+}
@@ -1,6 +1,13 @@
 package organization
 
-import "time"
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"time"
+
+	"base/api/pkg/request"
+)
 
 type Role string
 
@@ -10,6 +17,7 @@ const (
 	RoleMember Role = "member"
 )
 
+// IsValid reports whether the role is one of the built-in roles.
 func (r Role) IsValid() bool {
 	switch r {
 	case RoleOwner, RoleAdmin, RoleMember:
@@ -18,18 +26,6 @@ func (r Role) IsValid() bool {
 	return false
 }
 
-func (r Role) CanManageMembers() bool {
-	return r == RoleOwner || r == RoleAdmin
-}
-
-func (r Role) CanDeleteOrg() bool {
-	return r == RoleOwner
-}
-
-func (r Role) CanTransferOwnership() bool {
-	return r == RoleOwner
-}
-
 type InvitationStatus string
 
 const (
@@ -39,13 +35,46 @@ const (
 	StatusExpired  InvitationStatus = "expired"
 )
 
+type DomainJoinMode string
+
+const (
+	JoinModeJoin   DomainJoinMode = "join"
+	JoinModeInvite DomainJoinMode = "invite"
+)
+
+func (m DomainJoinMode) IsValid() bool {
+	return m == JoinModeJoin || m == JoinModeInvite
+}
+
+// TeamRole is a member's role within a team. Maintainers manage the team's
+// details and membership.
+type TeamRole string
+
+const (
+	TeamRoleMaintainer TeamRole = "maintainer"
+	TeamRoleMember     TeamRole = "member"
+)
+
+func (r TeamRole) IsValid() bool {
+	return r == TeamRoleMaintainer || r == TeamRoleMember
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// IsValidCustomName reports whether the role can be used as a custom role name.
+func (r Role) IsValidCustomName() bool {
+	return !r.IsValid() && roleNamePattern.MatchString(string(r))
+}
+
 type Organization struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Slug      string    `json:"slug" db:"slug"`
-	CreatedBy string    `json:"-" db:"created_by"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Slug      string     `json:"slug" db:"slug"`
+	Settings  Settings   `json:"settings" db:"settings"`
+	CreatedBy *string    `json:"-" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type OrganizationWithRole struct {
@@ -62,6 +91,29 @@ type Member struct {
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// CurrentOrganization is the caller's view of the organization a
+// tenant-scoped request resolved to.
+type CurrentOrganization struct {
+	Organization *Organization `json:"organization"`
+	Role         Role          `json:"role"`
+	Permissions  []Permission  `json:"permissions"`
+}
+
+// ListVersion summarizes a collection for conditional GETs.
+type ListVersion struct {
+	Count  int        `db:"count"`
+	Latest *time.Time `db:"latest"`
+}
+
+// ETag returns the tag of the view of the collection that query selects.
+func (v ListVersion) ETag(query url.Values) string {
+	var latest time.Time
+	if v.Latest != nil {
+		latest = *v.Latest
+	}
+	return request.ListETag(v.Count, latest, query)
+}
+
 type MemberWithUser struct {
 	ID             string    `json:"id" db:"id"`
 	OrganizationID string    `json:"organization_id" db:"organization_id"`
@@ -80,27 +132,150 @@ type Invitation struct {
 	Email          string           `json:"email" db:"email"`
 	Role           Role             `json:"role" db:"role"`
 	Token          string           `json:"-" db:"token"`
-	InvitedBy      string           `json:"invited_by" db:"invited_by"`
+	InvitedBy      *string          `json:"invited_by" db:"invited_by"`
 	Status         InvitationStatus `json:"status" db:"status"`
 	ExpiresAt      time.Time        `json:"expires_at" db:"expires_at"`
 	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time        `json:"updated_at" db:"updated_at"`
 }
 
+// InvitationEvent is the part of an invitation streamed to the
+// organization's members; the invitee's address is left out.
+type InvitationEvent struct {
+	ID        string    `json:"id"`
+	Role      Role      `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type InvitationWithDetails struct {
 	Invitation
 	OrganizationName string `json:"organization_name" db:"organization_name"`
 	InvitedByName    string `json:"invited_by_name" db:"invited_by_name"`
 }
 
+// OwnershipTransfer is a pending handover of ownership. It takes effect only
+// when the new owner accepts before ExpiresAt.
+type OwnershipTransfer struct {
+	ID             string    `json:"id" db:"id"`
+	OrganizationID string    `json:"organization_id" db:"organization_id"`
+	FromUserID     string    `json:"from_user_id" db:"from_user_id"`
+	ToUserID       string    `json:"to_user_id" db:"to_user_id"`
+	ExpiresAt      time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type OwnershipTransferWithDetails struct {
+	OwnershipTransfer
+	OrganizationName string `json:"organization_name" db:"organization_name"`
+	OrganizationSlug string `json:"organization_slug" db:"organization_slug"`
+	FromName         string `json:"from_name" db:"from_name"`
+	FromEmail        string `json:"from_email" db:"from_email"`
+	ToName           string `json:"to_name" db:"to_name"`
+	ToEmail          string `json:"to_email" db:"to_email"`
+}
+
+type JoinLink struct {
+	ID             string     `json:"id" db:"id"`
+	OrganizationID string     `json:"organization_id" db:"organization_id"`
+	Token          string     `json:"token" db:"token"`
+	Role           Role       `json:"role" db:"role"`
+	MaxUses        *int       `json:"max_uses" db:"max_uses"`
+	UseCount       int        `json:"use_count" db:"use_count"`
+	AllowedDomain  *string    `json:"allowed_domain" db:"allowed_domain"`
+	CreatedBy      *string    `json:"created_by" db:"created_by"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// JoinLinkPreview is what a user sees before redeeming a join link.
+// It deliberately omits the token and usage counters.
+type JoinLinkPreview struct {
+	OrganizationID   string    `json:"organization_id" db:"organization_id"`
+	OrganizationName string    `json:"organization_name" db:"organization_name"`
+	Role             Role      `json:"role" db:"role"`
+	ExpiresAt        time.Time `json:"expires_at" db:"expires_at"`
+}
+
+type Domain struct {
+	ID                string         `json:"id" db:"id"`
+	OrganizationID    string         `json:"organization_id" db:"organization_id"`
+	Domain            string         `json:"domain" db:"domain"`
+	VerificationToken string         `json:"verification_token" db:"verification_token"`
+	VerifiedAt        *time.Time     `json:"verified_at" db:"verified_at"`
+	DefaultRole       Role           `json:"default_role" db:"default_role"`
+	JoinMode          DomainJoinMode `json:"join_mode" db:"join_mode"`
+	CreatedBy         *string        `json:"created_by" db:"created_by"`
+	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// DomainWithInstructions tells an admin which DNS record proves ownership.
+type DomainWithInstructions struct {
+	Domain
+	RecordName  string `json:"record_name"`
+	RecordValue string `json:"record_value"`
+}
+
+// CustomRole is an organization-defined role: a named set of permissions.
+type CustomRole struct {
+	ID             string         `json:"id" db:"id"`
+	OrganizationID string         `json:"organization_id" db:"organization_id"`
+	Name           Role           `json:"name" db:"name"`
+	Description    string         `json:"description" db:"description"`
+	Permissions    PermissionList `json:"permissions" db:"permissions"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Team is a group of members within an organization. Its permissions are
+// granted to its members, and to the members of its child teams, on top of
+// their organization role.
+type Team struct {
+	ID             string         `json:"id" db:"id"`
+	OrganizationID string         `json:"organization_id" db:"organization_id"`
+	ParentID       *string        `json:"parent_id" db:"parent_id"`
+	Name           string         `json:"name" db:"name"`
+	Description    string         `json:"description" db:"description"`
+	Permissions    PermissionList `json:"permissions" db:"permissions"`
+	MemberCount    int            `json:"member_count" db:"member_count"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+type TeamMember struct {
+	TeamID    string    `json:"team_id" db:"team_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Role      TeamRole  `json:"role" db:"role"`
+	Email     string    `json:"email" db:"email"`
+	Name      string    `json:"name" db:"name"`
+	Picture   string    `json:"picture" db:"picture"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RoleDefinition describes a role available in an organization.
+type RoleDefinition struct {
+	Name        Role         `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"built_in"`
+}
+
 // Request types
 
 type CreateOrgRequest struct {
 	Name string `json:"name"`
+	Slug string `json:"slug,omitempty"`
 }
 
+// UpdateOrgRequest is a JSON merge patch; settings is itself merged into the
+// current settings document.
 type UpdateOrgRequest struct {
-	Name string `json:"name"`
+	Name     request.Field[string]          `json:"name"`
+	Slug     request.Field[string]          `json:"slug"`
+	Settings request.Field[json.RawMessage] `json:"settings"`
 }
 
 type InviteMemberRequest struct {
@@ -108,12 +283,63 @@ type InviteMemberRequest struct {
 	Role  Role   `json:"role"`
 }
 
-type UpdateMemberRoleRequest struct {
-	Role Role `json:"role"`
+type CreateJoinLinkRequest struct {
+	Role          Role   `json:"role"`
+	MaxUses       *int   `json:"max_uses"`
+	ExpiresInDays int    `json:"expires_in_days"`
+	AllowedDomain string `json:"allowed_domain"`
+}
+
+type ClaimDomainRequest struct {
+	Domain      string         `json:"domain"`
+	DefaultRole Role           `json:"default_role"`
+	JoinMode    DomainJoinMode `json:"join_mode"`
+}
+
+type UpdateDomainRequest struct {
+	DefaultRole Role           `json:"default_role"`
+	JoinMode    DomainJoinMode `json:"join_mode"`
+}
+
+type CreateTeamRequest struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	ParentID    *string      `json:"parent_id"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateTeamRequest is a JSON merge patch for a team. A team's parent is
+// fixed when it is created.
+type UpdateTeamRequest struct {
+	Name        request.Field[string]       `json:"name"`
+	Description request.Field[string]       `json:"description"`
+	Permissions request.Field[[]Permission] `json:"permissions"`
+}
+
+type SetTeamMemberRequest struct {
+	Role TeamRole `json:"role"`
+}
+
+type CreateRoleRequest struct {
+	Name        Role         `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateMemberRequest is a JSON merge patch for a membership.
+type UpdateMemberRequest struct {
+	Role request.Field[Role] `json:"role"`
 }
 
 type TransferOwnershipRequest struct {
 	NewOwnerID string `json:"new_owner_id"`
+	// Notify also emails the new owner, if their notification preferences allow
+	Notify bool `json:"notify"`
 }
 
 type SetActiveOrgRequest struct {
@@ -0,0 +1,221 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"base/api/internal/database"
+	"base/api/internal/database/dbtest"
+)
+
+func TestOwnerViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"owner trigger", &pgconn.PgError{Code: checkViolation, ConstraintName: ownerTrigger}, ErrLastOwner},
+		{"wrapped owner trigger", fmt.Errorf("commit: %w", &pgconn.PgError{Code: checkViolation, ConstraintName: ownerTrigger}), ErrLastOwner},
+		{"other check", &pgconn.PgError{Code: checkViolation, ConstraintName: "organization_members_role_check"}, nil},
+		{"message only", errors.New("organization must keep at least one owner"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ownerViolation(tt.err)
+			if tt.want != nil {
+				if !errors.Is(got, tt.want) {
+					t.Errorf("ownerViolation() = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if got != tt.err {
+				t.Errorf("ownerViolation() = %v, want the error unchanged", got)
+			}
+		})
+	}
+}
+
+// ownerRace is one round of a concurrency test: an organization whose owner
+// is owners[0], plus any extra owners and admins, and the operations that
+// race against it.
+type ownerRace struct {
+	repo   *Repository
+	ctx    context.Context
+	orgID  string
+	owners []string
+	admins []string
+}
+
+// Rounds per scenario, so both orders of the racing operations are likely
+// to be exercised.
+const ownerRaceRounds = 10
+
+func newOwnerRace(t *testing.T, db *database.PostgresDB, owners, admins int) *ownerRace {
+	t.Helper()
+	race := &ownerRace{repo: NewRepository(db)}
+	first := dbtest.CreateUser(t, db)
+	race.orgID = dbtest.CreateOrganization(t, db, first)
+	race.owners = append(race.owners, first)
+	for range owners - 1 {
+		id := dbtest.CreateUser(t, db)
+		dbtest.AddMember(t, db, race.orgID, id, string(RoleOwner))
+		race.owners = append(race.owners, id)
+	}
+	for range admins {
+		id := dbtest.CreateUser(t, db)
+		dbtest.AddMember(t, db, race.orgID, id, string(RoleAdmin))
+		race.admins = append(race.admins, id)
+	}
+	race.ctx = database.WithTenant(context.Background(), database.Tenant{OrganizationID: race.orgID})
+	return race
+}
+
+// run starts every operation at once and returns their errors in order.
+func (race *ownerRace) run(ops ...func(ctx context.Context) error) []error {
+	errs := make([]error, len(ops))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, op := range ops {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = op(race.ctx)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func (race *ownerRace) requireOwner(t *testing.T) {
+	t.Helper()
+	var owners int
+	query := `SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = 'owner'`
+	if err := race.repo.postgres.GetContext(race.ctx, &owners, query, race.orgID); err != nil {
+		t.Fatalf("count owners: %v", err)
+	}
+	if owners == 0 {
+		t.Fatal("organization was left without an owner")
+	}
+}
+
+// requireOneLastOwner checks that exactly one operation failed, with
+// ErrLastOwner.
+func requireOneLastOwner(t *testing.T, errs []error) {
+	t.Helper()
+	failed := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrLastOwner):
+			failed++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("errors = %v, want exactly one ErrLastOwner", errs)
+	}
+}
+
+func (race *ownerRace) demote(userID string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := race.repo.UpdateMemberRole(ctx, race.orgID, userID, RoleAdmin, true, nil)
+		return err
+	}
+}
+
+func (race *ownerRace) remove(userID string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return race.repo.RemoveMember(ctx, race.orgID, userID)
+	}
+}
+
+func (race *ownerRace) transfer(toUserID string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := race.repo.TransferOwnership(ctx, race.orgID, toUserID)
+		return err
+	}
+}
+
+func TestConcurrentOwnerDemotions(t *testing.T) {
+	db := dbtest.Open(t)
+
+	scenarios := []struct {
+		name string
+		ops  func(race *ownerRace) []func(ctx context.Context) error
+	}{
+		{"demote both owners", func(race *ownerRace) []func(ctx context.Context) error {
+			return []func(ctx context.Context) error{race.demote(race.owners[0]), race.demote(race.owners[1])}
+		}},
+		{"remove both owners", func(race *ownerRace) []func(ctx context.Context) error {
+			return []func(ctx context.Context) error{race.remove(race.owners[0]), race.remove(race.owners[1])}
+		}},
+		{"demote one owner and remove the other", func(race *ownerRace) []func(ctx context.Context) error {
+			return []func(ctx context.Context) error{race.demote(race.owners[0]), race.remove(race.owners[1])}
+		}},
+	}
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			for range ownerRaceRounds {
+				race := newOwnerRace(t, db, 2, 0)
+				requireOneLastOwner(t, race.run(sc.ops(race)...))
+				race.requireOwner(t)
+			}
+		})
+	}
+}
+
+func TestConcurrentTransferAndRemovalOfNewOwner(t *testing.T) {
+	db := dbtest.Open(t)
+
+	for range ownerRaceRounds {
+		race := newOwnerRace(t, db, 1, 1)
+		owner, admin := race.owners[0], race.admins[0]
+		if _, err := race.repo.CreateOwnershipTransfer(race.ctx, race.orgID, owner, admin, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("create transfer: %v", err)
+		}
+
+		errs := race.run(race.transfer(admin), race.remove(admin))
+		// Either the removal wins and the transfer finds no member to
+		// promote, or the transfer wins and the new sole owner stays
+		switch {
+		case errs[0] == nil && errors.Is(errs[1], ErrLastOwner):
+		case errors.Is(errs[0], ErrNotMember) && errs[1] == nil:
+		default:
+			t.Fatalf("transfer = %v, remove = %v", errs[0], errs[1])
+		}
+		race.requireOwner(t)
+	}
+}
+
+func TestConcurrentTransferAndDemotion(t *testing.T) {
+	db := dbtest.Open(t)
+
+	for range ownerRaceRounds {
+		race := newOwnerRace(t, db, 2, 1)
+		from, other, admin := race.owners[0], race.owners[1], race.admins[0]
+		if _, err := race.repo.CreateOwnershipTransfer(race.ctx, race.orgID, from, admin, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("create transfer: %v", err)
+		}
+
+		errs := race.run(race.transfer(admin), race.demote(other))
+		if errs[0] != nil {
+			t.Fatalf("transfer: %v", errs[0])
+		}
+		// The demotion may be refused conservatively, never wrongly allowed
+		if errs[1] != nil && !errors.Is(errs[1], ErrLastOwner) {
+			t.Fatalf("demote: %v", errs[1])
+		}
+		race.requireOwner(t)
+	}
+}
@@ -0,0 +1,206 @@
+package organization
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrForbidden = errors.New("insufficient permissions")
+
+// Permission is a named capability within an organization, in "resource:action" form.
+type Permission string
+
+const (
+	PermOrgRead     Permission = "org:read"
+	PermOrgUpdate   Permission = "org:update"
+	PermOrgDelete   Permission = "org:delete"
+	PermOrgTransfer Permission = "org:transfer"
+	PermOrgSlug     Permission = "org:update_slug"
+
+	PermMembersRead         Permission = "members:read"
+	PermMembersInvite       Permission = "members:invite"
+	PermMembersUpdateRole   Permission = "members:update_role"
+	PermMembersRemove       Permission = "members:remove"
+	PermMembersManageOwners Permission = "members:manage_owners"
+
+	PermInvitationsRead   Permission = "invitations:read"
+	PermInvitationsCancel Permission = "invitations:cancel"
+
+	PermJoinLinksManage Permission = "join_links:manage"
+	PermDomainsManage   Permission = "domains:manage"
+	PermRolesManage     Permission = "roles:manage"
+	PermTeamsManage     Permission = "teams:manage"
+
+	PermAuditRead Permission = "audit:read"
+
+	// PermProjectsCreate allows creating projects; PermProjectsManage grants
+	// admin access to every project regardless of project membership
+	PermProjectsCreate Permission = "projects:create"
+	PermProjectsManage Permission = "projects:manage"
+)
+
+var memberPermissions = []Permission{
+	PermOrgRead,
+	PermMembersRead,
+	PermProjectsCreate,
+}
+
+var adminPermissions = append(slices.Clone(memberPermissions),
+	PermOrgUpdate,
+	PermMembersInvite,
+	PermMembersUpdateRole,
+	PermMembersRemove,
+	PermInvitationsRead,
+	PermInvitationsCancel,
+	PermJoinLinksManage,
+	PermDomainsManage,
+	PermRolesManage,
+	PermTeamsManage,
+	PermProjectsManage,
+	PermAuditRead,
+)
+
+var ownerPermissions = append(slices.Clone(adminPermissions),
+	PermOrgDelete,
+	PermOrgTransfer,
+	PermOrgSlug,
+	PermMembersManageOwners,
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner:  ownerPermissions,
+	RoleAdmin:  adminPermissions,
+	RoleMember: memberPermissions,
+}
+
+// Custom roles and teams may grant anything an admin can, but never
+// owner-only permissions.
+var grantablePermissions = adminPermissions
+
+// IsGrantable reports whether the permission may be assigned to a custom role
+// or team.
+func (p Permission) IsGrantable() bool {
+	return slices.Contains(grantablePermissions, p)
+}
+
+// PermissionList is a set of permissions stored as a JSON array.
+type PermissionList []Permission
+
+func (l PermissionList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+func (l *PermissionList) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case nil:
+		*l = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into PermissionList", src)
+}
+
+// Permissions returns the permissions granted by a built-in role.
+// Custom roles are resolved through the Authorizer.
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+// Has reports whether the built-in role grants the permission.
+func (r Role) Has(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
+
+// Authorizer decides whether a principal may perform an action in an organization.
+type Authorizer struct {
+	repo *Repository
+}
+
+func NewAuthorizer(repo *Repository) *Authorizer {
+	return &Authorizer{repo: repo}
+}
+
+// Authorize loads the principal's membership in the organization and checks it
+// grants the permission. It returns ErrNotMember or ErrForbidden on denial.
+func (a *Authorizer) Authorize(ctx context.Context, principalID, orgID string, perm Permission) (*Member, error) {
+	member, err := a.repo.GetMember(ctx, orgID, principalID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := a.Permissions(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(perms, perm) {
+		return member, ErrForbidden
+	}
+	return member, nil
+}
+
+// HasPermission reports whether the user is a member of the organization who
+// holds perm, for packages that check access without importing this one.
+func (a *Authorizer) HasPermission(ctx context.Context, userID, orgID, perm string) (bool, error) {
+	_, err := a.Authorize(ctx, userID, orgID, Permission(perm))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrNotMember), errors.Is(err, ErrForbidden):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Permissions resolves the member's effective permissions: those of their
+// role, built-in or custom, plus the grants of their teams.
+func (a *Authorizer) Permissions(ctx context.Context, member *Member) ([]Permission, error) {
+	perms, err := a.rolePermissions(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := a.repo.GetTeamGrants(ctx, member.OrganizationID, member.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(grants) == 0 {
+		return perms, nil
+	}
+
+	return slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(perms), grants...)))), nil
+}
+
+func (a *Authorizer) rolePermissions(ctx context.Context, member *Member) ([]Permission, error) {
+	perms, err := a.RolePermissions(ctx, member.OrganizationID, member.Role)
+	if errors.Is(err, ErrInvalidRole) {
+		return nil, nil
+	}
+	return perms, err
+}
+
+// RolePermissions returns the permissions a role grants in the organization,
+// or ErrInvalidRole if it is neither built in nor defined there.
+func (a *Authorizer) RolePermissions(ctx context.Context, orgID string, role Role) ([]Permission, error) {
+	if role.IsValid() {
+		return role.Permissions(), nil
+	}
+	custom, err := a.repo.GetRole(ctx, orgID, string(role))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidRole
+		}
+		return nil, err
+	}
+	return custom.Permissions, nil
+}
@@ -0,0 +1,54 @@
+package organization
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DeletionRetention is how long a soft-deleted organization can be restored
+// before it is permanently removed.
+const DeletionRetention = 30 * 24 * time.Hour
+
+const purgeInterval = time.Hour
+
+// Purger periodically hard-deletes organizations whose retention window has
+// passed.
+type Purger struct {
+	repo   *Repository
+	logger *slog.Logger
+}
+
+func NewPurger(repo *Repository, logger *slog.Logger) *Purger {
+	return &Purger{repo: repo, logger: logger}
+}
+
+// Run purges once immediately and then every purgeInterval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	orgs, err := p.repo.PurgeDeleted(ctx, time.Now().Add(-DeletionRetention))
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Error("failed to purge deleted organizations", "error", err)
+		}
+		return
+	}
+
+	for _, org := range orgs {
+		p.logger.Info("purged deleted organization", "org_id", org.ID, "slug", org.Slug)
+	}
+}
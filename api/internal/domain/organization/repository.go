@@ -7,21 +7,44 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"base/api/internal/audit"
 	"base/api/internal/database"
+	"base/api/pkg/pagination"
+	"base/api/pkg/request"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
-	ErrNotFound        = errors.New("not found")
-	ErrAlreadyMember   = errors.New("user is already a member")
-	ErrNotMember       = errors.New("user is not a member")
-	ErrLastOwner       = errors.New("cannot remove the last owner")
-	ErrInviteExists    = errors.New("pending invitation already exists")
-	ErrInviteExpired   = errors.New("invitation has expired")
-	ErrSlugExists      = errors.New("slug already exists")
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyMember     = errors.New("user is already a member")
+	ErrNotMember         = errors.New("user is not a member")
+	ErrLastOwner         = errors.New("organization must keep at least one owner")
+	ErrNotOwner          = errors.New("user is not an owner")
+	ErrAlreadyOwner      = errors.New("user is already an owner")
+	ErrTransferPending   = errors.New("an ownership transfer is already pending")
+	ErrInviteExists      = errors.New("pending invitation already exists")
+	ErrInviteExpired     = errors.New("invitation has expired")
+	ErrSlugExists        = errors.New("slug already exists")
+	ErrJoinLinkRevoked   = errors.New("join link has been revoked")
+	ErrJoinLinkExpired   = errors.New("join link has expired")
+	ErrJoinLinkExhausted = errors.New("join link has reached its usage limit")
+	ErrDomainNotAllowed  = errors.New("email domain is not allowed")
+	ErrDomainExists      = errors.New("domain already claimed by this organization")
+	ErrDomainClaimed     = errors.New("domain is verified by another organization")
+	ErrInvalidRole       = errors.New("role does not exist in this organization")
+	ErrOwnerRole         = errors.New("only owners can change owner roles")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrRoleInUse         = errors.New("role is assigned to members")
+	ErrTeamExists        = errors.New("team already exists")
+	ErrTeamTooDeep       = errors.New("teams can only be nested one level deep")
 )
 
 type Repository struct {
@@ -34,13 +57,22 @@ func NewRepository(postgres *database.PostgresDB) *Repository {
 
 // Organization CRUD
 
+// orgColumns lists organization columns; deleted organizations are filtered
+// out of every lookup except the restore path.
+const orgColumns = `id, name, slug, settings, created_by, created_at, updated_at, deleted_at`
+
+const orgColumnsPrefixed = `o.id, o.name, o.slug, o.settings, o.created_by, o.created_at, o.updated_at, o.deleted_at`
+
 func (r *Repository) Create(ctx context.Context, name, slug, createdBy string) (*Organization, error) {
+	if err := checkSlugHistory(ctx, r.postgres, slug, ""); err != nil {
+		return nil, err
+	}
+
 	var org Organization
 	query := `
 		INSERT INTO organizations (name, slug, created_by)
 		VALUES ($1, $2, $3)
-		RETURNING id, name, slug, created_by, created_at, updated_at
-	`
+		RETURNING ` + orgColumns
 	err := r.postgres.GetContext(ctx, &org, query, name, slug, createdBy)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") && strings.Contains(err.Error(), "slug") {
@@ -51,6 +83,48 @@ func (r *Repository) Create(ctx context.Context, name, slug, createdBy string) (
 	return &org, nil
 }
 
+// CreateWithGeneratedSlug creates an organization like CreateWithOwner, with a
+// slug generated from slugSource, usually its name. When that slug is taken or
+// reserved the first free numbered variant is used: "acme-2", "acme-3" and so
+// on.
+func (r *Repository) CreateWithGeneratedSlug(ctx context.Context, name, slugSource, createdBy string) (*Organization, error) {
+	base := GenerateSlug(slugSource)
+	var err error
+	// Another request may take the chosen slug first
+	for range slugAttempts {
+		var slug string
+		if slug, err = r.freeSlug(ctx, base); err != nil {
+			return nil, err
+		}
+		org, err := r.CreateWithOwner(ctx, name, slug, createdBy)
+		if !errors.Is(err, ErrSlugExists) {
+			return org, err
+		}
+	}
+	return nil, ErrSlugExists
+}
+
+// freeSlug returns base, or base with the lowest numeric suffix, that is
+// neither reserved nor in use by an organization or its slug history.
+func (r *Repository) freeSlug(ctx context.Context, base string) (string, error) {
+	var taken []string
+	query := `
+		SELECT slug FROM organizations WHERE slug = $1 OR slug ~ $2
+		UNION
+		SELECT slug FROM organization_slug_history WHERE slug = $1 OR slug ~ $2
+	`
+	pattern := "^" + regexp.QuoteMeta(base) + "-[0-9]+$"
+	if err := r.postgres.SelectContext(ctx, &taken, query, base, pattern); err != nil {
+		return "", err
+	}
+
+	slug := base
+	for n := 2; slices.Contains(taken, slug) || ValidateSlug(slug) != nil; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
 func (r *Repository) CreateWithOwner(ctx context.Context, name, slug, createdBy string) (*Organization, error) {
 	tx, err := r.postgres.BeginTxx(ctx, nil)
 	if err != nil {
@@ -58,12 +132,15 @@ func (r *Repository) CreateWithOwner(ctx context.Context, name, slug, createdBy
 	}
 	defer tx.Rollback()
 
+	if err = checkSlugHistory(ctx, tx, slug, ""); err != nil {
+		return nil, err
+	}
+
 	var org Organization
 	query := `
 		INSERT INTO organizations (name, slug, created_by)
 		VALUES ($1, $2, $3)
-		RETURNING id, name, slug, created_by, created_at, updated_at
-	`
+		RETURNING ` + orgColumns
 	err = tx.GetContext(ctx, &org, query, name, slug, createdBy)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") && strings.Contains(err.Error(), "slug") {
@@ -90,7 +167,7 @@ func (r *Repository) CreateWithOwner(ctx context.Context, name, slug, createdBy
 
 func (r *Repository) GetByID(ctx context.Context, id string) (*Organization, error) {
 	var org Organization
-	query := `SELECT id, name, slug, created_by, created_at, updated_at FROM organizations WHERE id = $1`
+	query := `SELECT ` + orgColumns + ` FROM organizations WHERE id = $1 AND deleted_at IS NULL`
 	err := r.postgres.GetContext(ctx, &org, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
@@ -100,7 +177,7 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*Organization, err
 
 func (r *Repository) GetBySlug(ctx context.Context, slug string) (*Organization, error) {
 	var org Organization
-	query := `SELECT id, name, slug, created_by, created_at, updated_at FROM organizations WHERE slug = $1`
+	query := `SELECT ` + orgColumns + ` FROM organizations WHERE slug = $1 AND deleted_at IS NULL`
 	err := r.postgres.GetContext(ctx, &org, query, slug)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
@@ -108,75 +185,378 @@ func (r *Repository) GetBySlug(ctx context.Context, slug string) (*Organization,
 	return &org, err
 }
 
-func (r *Repository) Update(ctx context.Context, id, name string) (*Organization, error) {
+// Update applies fn to the organization under a row lock and stores its
+// name, slug and settings. A changed slug is kept in history so old links
+// redirect to the new one. Errors returned by fn abort the update unchanged.
+func (r *Repository) Update(ctx context.Context, id string, fn func(*Organization) error) (*Organization, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before Organization
+	beforeQuery := `SELECT ` + orgColumns + ` FROM organizations WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	err = tx.GetContext(ctx, &before, beforeQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := before
+	next.Settings.AllowedEmailDomains = slices.Clone(before.Settings.AllowedEmailDomains)
+	if err = fn(&next); err != nil {
+		return nil, err
+	}
+
+	if next.Slug != before.Slug {
+		// Other organizations' history is outside this tenant
+		if err = checkSlugHistory(database.WithoutTenant(ctx), r.postgres, next.Slug, id); err != nil {
+			return nil, err
+		}
+
+		// Reclaiming one of our own old slugs takes it out of history
+		_, err = tx.ExecContext(ctx, `DELETE FROM organization_slug_history WHERE slug = $1`, next.Slug)
+		if err != nil {
+			return nil, err
+		}
+
+		historyQuery := `INSERT INTO organization_slug_history (slug, organization_id) VALUES ($1, $2)`
+		if _, err = tx.ExecContext(ctx, historyQuery, before.Slug, id); err != nil {
+			return nil, err
+		}
+	}
+
 	var org Organization
 	query := `
 		UPDATE organizations
-		SET name = $2, updated_at = NOW()
+		SET name = $2, slug = $3, settings = $4, updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, slug, created_by, created_at, updated_at
+		RETURNING ` + orgColumns
+	if err = tx.GetContext(ctx, &org, query, id, next.Name, next.Slug, next.Settings); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") && strings.Contains(err.Error(), "slug") {
+			return nil, ErrSlugExists
+		}
+		return nil, err
+	}
+
+	if org.Name != before.Name || org.Slug != before.Slug {
+		err = audit.Record(ctx, tx, audit.Entry{
+			OrganizationID: id,
+			Action:         ActionOrgUpdated,
+			TargetType:     targetOrganization,
+			TargetID:       id,
+			Before:         map[string]any{"name": before.Name, "slug": before.Slug},
+			After:          map[string]any{"name": org.Name, "slug": org.Slug},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !reflect.DeepEqual(org.Settings, before.Settings) {
+		err = audit.Record(ctx, tx, audit.Entry{
+			OrganizationID: id,
+			Action:         ActionOrgSettingsUpdated,
+			TargetType:     targetOrganization,
+			TargetID:       id,
+			Before:         before.Settings,
+			After:          org.Settings,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+// GetSlugRedirect returns the current slug of the organization that
+// previously used slug.
+func (r *Repository) GetSlugRedirect(ctx context.Context, slug string) (string, error) {
+	var current string
+	query := `
+		SELECT o.slug
+		FROM organization_slug_history h
+		JOIN organizations o ON h.organization_id = o.id
+		WHERE h.slug = $1 AND o.deleted_at IS NULL
 	`
-	err := r.postgres.GetContext(ctx, &org, query, id, name)
+	err := r.postgres.GetContext(ctx, &current, query, slug)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrNotFound
+		return "", ErrNotFound
 	}
-	return &org, err
+	return current, err
 }
 
+// Delete soft-deletes an organization. It disappears from every lookup but
+// can be restored by an owner until the purger removes it.
 func (r *Repository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM organizations WHERE id = $1`
-	result, err := r.postgres.ExecContext(ctx, query, id)
+	tx, err := r.postgres.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	var org Organization
+	query := `
+		UPDATE organizations
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + orgColumns
+	err = tx.GetContext(ctx, &org, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return ErrNotFound
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: id,
+		Action:         ActionOrgDeleted,
+		TargetType:     targetOrganization,
+		TargetID:       id,
+		Before:         map[string]any{"name": org.Name, "slug": org.Slug},
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+
+	return tx.Commit()
 }
 
-func (r *Repository) GetUserOrganizations(ctx context.Context, userID string) ([]OrganizationWithRole, error) {
+// GetDeletedOrganizations returns soft-deleted organizations the user owns
+// that are still within the restore window.
+func (r *Repository) GetDeletedOrganizations(ctx context.Context, userID string, since time.Time) ([]OrganizationWithRole, error) {
 	var orgs []OrganizationWithRole
 	query := `
-		SELECT o.id, o.name, o.slug, o.created_by, o.created_at, o.updated_at, m.role
+		SELECT ` + orgColumnsPrefixed + `, m.role
 		FROM organizations o
 		JOIN organization_members m ON o.id = m.organization_id
-		WHERE m.user_id = $1
-		ORDER BY o.name
+		WHERE m.user_id = $1 AND m.role = 'owner' AND o.deleted_at > $2
+		ORDER BY o.deleted_at DESC
 	`
-	err := r.postgres.SelectContext(ctx, &orgs, query, userID)
+	err := r.postgres.SelectContext(ctx, &orgs, query, userID, since)
 	return orgs, err
 }
 
+// Restore undoes a soft delete. Only owners may restore, and only while the
+// organization was deleted after since.
+func (r *Repository) Restore(ctx context.Context, id, userID string, since time.Time) (*Organization, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var org Organization
+	query := `
+		UPDATE organizations o
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE o.id = $1 AND o.deleted_at > $3
+		  AND EXISTS (
+			SELECT 1 FROM organization_members m
+			WHERE m.organization_id = o.id AND m.user_id = $2 AND m.role = 'owner'
+		  )
+		RETURNING ` + orgColumnsPrefixed
+	err = tx.GetContext(ctx, &org, query, id, userID, since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: id,
+		Action:         ActionOrgRestored,
+		TargetType:     targetOrganization,
+		TargetID:       id,
+		After:          map[string]any{"name": org.Name, "slug": org.Slug},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+// PurgeDeleted permanently removes organizations soft-deleted before the
+// cutoff. Members, invitations and other org rows go with them via cascade.
+func (r *Repository) PurgeDeleted(ctx context.Context, before time.Time) ([]Organization, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var orgs []Organization
+	query := `DELETE FROM organizations WHERE deleted_at < $1 RETURNING ` + orgColumns
+	if err = tx.SelectContext(ctx, &orgs, query, before); err != nil {
+		return nil, err
+	}
+
+	for _, org := range orgs {
+		err = audit.Record(ctx, tx, audit.Entry{
+			OrganizationID: org.ID,
+			Action:         ActionOrgPurged,
+			TargetType:     targetOrganization,
+			TargetID:       org.ID,
+			Before:         map[string]any{"name": org.Name, "slug": org.Slug, "deleted_at": org.DeletedAt},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+// UserOrganizationsPage lists a user's organizations.
+var UserOrganizationsPage = pagination.Spec{
+	Sorts: []pagination.Sort{
+		{Key: "name", Columns: []pagination.Column{{Expr: "o.name", Type: "text"}}},
+		{Key: "created", Columns: []pagination.Column{{Expr: "o.created_at", Type: "timestamptz"}}},
+	},
+	Filters:      []string{"role"},
+	IDColumn:     "o.id",
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+func (r *Repository) GetUserOrganizations(ctx context.Context, userID string, p pagination.Params) ([]OrganizationWithRole, string, error) {
+	args := []any{userID}
+	where := []string{"m.user_id = $1", "o.deleted_at IS NULL"}
+	if role := p.Filters["role"]; role != "" {
+		args = append(args, role)
+		where = append(where, fmt.Sprintf("m.role = $%d", len(args)))
+	}
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
+	query := `
+		SELECT ` + orgColumnsPrefixed + `, m.role
+		FROM organizations o
+		JOIN organization_members m ON o.id = m.organization_id
+		WHERE ` + strings.Join(where, " AND ") + ` AND ` + keyset + `
+		` + order
+
+	var orgs []OrganizationWithRole
+	if err := r.postgres.SelectContext(ctx, &orgs, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	orgs, next := pagination.Page(p, orgs, func(o OrganizationWithRole) ([]any, string) {
+		if p.Sort.Key == "created" {
+			return []any{o.CreatedAt}, o.ID
+		}
+		return []any{o.Name}, o.ID
+	})
+	return orgs, next, nil
+}
+
+func (r *Repository) HasOrganizations(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM organization_members m
+			JOIN organizations o ON o.id = m.organization_id
+			WHERE m.user_id = $1 AND o.deleted_at IS NULL
+		)
+	`
+	err := r.postgres.GetContext(ctx, &exists, query, userID)
+	return exists, err
+}
+
+// GetUserOrganizationIDs lists the IDs of every organization the user
+// belongs to.
+func (r *Repository) GetUserOrganizationIDs(ctx context.Context, userID string) ([]string, error) {
+	ids := []string{}
+	query := `
+		SELECT o.id FROM organizations o
+		JOIN organization_members m ON o.id = m.organization_id
+		WHERE m.user_id = $1 AND o.deleted_at IS NULL
+	`
+	err := r.postgres.SelectContext(ctx, &ids, query, userID)
+	return ids, err
+}
+
+// GetUserOrganizationsVersion fingerprints the user's organization list
+// so pollers can skip unchanged responses without loading every row.
+func (r *Repository) GetUserOrganizationsVersion(ctx context.Context, userID string) (ListVersion, error) {
+	var v ListVersion
+	query := `
+		SELECT COUNT(*) AS count, MAX(GREATEST(o.updated_at, m.updated_at)) AS latest
+		FROM organizations o
+		JOIN organization_members m ON o.id = m.organization_id
+		WHERE m.user_id = $1 AND o.deleted_at IS NULL
+	`
+	err := r.postgres.GetContext(ctx, &v, query, userID)
+	return v, err
+}
+
 // Member operations
 
 func (r *Repository) AddMember(ctx context.Context, orgID, userID string, role Role) (*Member, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var member Member
 	query := `
 		INSERT INTO organization_members (organization_id, user_id, role)
 		VALUES ($1, $2, $3)
 		RETURNING id, organization_id, user_id, role, created_at, updated_at
 	`
-	err := r.postgres.GetContext(ctx, &member, query, orgID, userID, role)
+	err = tx.GetContext(ctx, &member, query, orgID, userID, role)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
 			return nil, ErrAlreadyMember
 		}
 		return nil, err
 	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionMemberAdded,
+		TargetType:     targetMember,
+		TargetID:       userID,
+		After:          map[string]any{"role": role},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &member, nil
 }
 
 func (r *Repository) GetMember(ctx context.Context, orgID, userID string) (*Member, error) {
 	var member Member
 	query := `
-		SELECT id, organization_id, user_id, role, created_at, updated_at
-		FROM organization_members
-		WHERE organization_id = $1 AND user_id = $2
+		SELECT m.id, m.organization_id, m.user_id, m.role, m.created_at, m.updated_at
+		FROM organization_members m
+		JOIN organizations o ON m.organization_id = o.id
+		WHERE m.organization_id = $1 AND m.user_id = $2 AND o.deleted_at IS NULL
 	`
 	err := r.postgres.GetContext(ctx, &member, query, orgID, userID)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -185,129 +565,533 @@ func (r *Repository) GetMember(ctx context.Context, orgID, userID string) (*Memb
 	return &member, err
 }
 
-func (r *Repository) GetMembers(ctx context.Context, orgID string) ([]MemberWithUser, error) {
-	var members []MemberWithUser
+func (r *Repository) GetMemberWithUser(ctx context.Context, orgID, userID string) (*MemberWithUser, error) {
+	var member MemberWithUser
 	query := `
 		SELECT m.id, m.organization_id, m.user_id, m.role, m.created_at, m.updated_at,
 			   u.email, u.name, u.picture
 		FROM organization_members m
 		JOIN users u ON m.user_id = u.id
-		WHERE m.organization_id = $1
-		ORDER BY
-			CASE m.role
-				WHEN 'owner' THEN 1
-				WHEN 'admin' THEN 2
-				ELSE 3
-			END,
-			u.name
-	`
-	err := r.postgres.SelectContext(ctx, &members, query, orgID)
-	return members, err
+		JOIN organizations o ON m.organization_id = o.id
+		WHERE m.organization_id = $1 AND m.user_id = $2 AND o.deleted_at IS NULL
+	`
+	err := r.postgres.GetContext(ctx, &member, query, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotMember
+	}
+	return &member, err
 }
 
-func (r *Repository) UpdateMemberRole(ctx context.Context, orgID, userID string, role Role) error {
+// GetMembersVersion fingerprints the member list so pollers can skip
+// unchanged responses without loading every row.
+func (r *Repository) GetMembersVersion(ctx context.Context, orgID string) (ListVersion, error) {
+	var v ListVersion
 	query := `
-		UPDATE organization_members
-		SET role = $3, updated_at = NOW()
-		WHERE organization_id = $1 AND user_id = $2
+		SELECT COUNT(*) AS count, MAX(GREATEST(m.updated_at, u.updated_at)) AS latest
+		FROM organization_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.organization_id = $1
 	`
-	result, err := r.postgres.ExecContext(ctx, query, orgID, userID, role)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return ErrNotMember
+	err := r.postgres.GetContext(ctx, &v, query, orgID)
+	return v, err
+}
+
+// memberRoleRank orders owners, admins, custom roles and then members.
+const memberRoleRank = `CASE m.role WHEN 'owner' THEN 1 WHEN 'admin' THEN 2 WHEN 'member' THEN 4 ELSE 3 END`
+
+func roleRank(role Role) int {
+	switch role {
+	case RoleOwner:
+		return 1
+	case RoleAdmin:
+		return 2
+	case RoleMember:
+		return 4
+	default:
+		return 3
 	}
-	return nil
 }
 
-func (r *Repository) RemoveMember(ctx context.Context, orgID, userID string) error {
-	query := `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`
-	result, err := r.postgres.ExecContext(ctx, query, orgID, userID)
-	if err != nil {
-		return err
+// memberName is the name members sort by. A NULL would fail every keyset
+// comparison and drop rows from later pages, so it sorts as empty.
+const memberName = "COALESCE(u.name, '')"
+
+// MembersPage lists an organization's members.
+var MembersPage = pagination.Spec{
+	Sorts: []pagination.Sort{
+		{Key: "role", Columns: []pagination.Column{{Expr: memberRoleRank, Type: "int"}, {Expr: memberName, Type: "text"}}},
+		{Key: "name", Columns: []pagination.Column{{Expr: memberName, Type: "text"}}},
+		{Key: "email", Columns: []pagination.Column{{Expr: "u.email", Type: "text"}}},
+		{Key: "joined", Columns: []pagination.Column{{Expr: "m.created_at", Type: "timestamptz"}}},
+	},
+	Filters:      []string{"role", "joined_after", "joined_before"},
+	IDColumn:     "m.id",
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+// GetMembers lists a page of members. A non-empty search matches members
+// whose name or email starts with it, or whose name or email is a close
+// trigram match. The joined_after and joined_before filters must already be
+// validated timestamps.
+func (r *Repository) GetMembers(ctx context.Context, orgID, search string, p pagination.Params) ([]MemberWithUser, string, error) {
+	args := []any{orgID}
+	where := []string{"m.organization_id = $1", "o.deleted_at IS NULL"}
+	if role := p.Filters["role"]; role != "" {
+		args = append(args, role)
+		where = append(where, fmt.Sprintf("m.role = $%d", len(args)))
 	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
+	if after := p.Filters["joined_after"]; after != "" {
+		args = append(args, after)
+		where = append(where, fmt.Sprintf("m.created_at >= $%d::timestamptz", len(args)))
 	}
-	if rows == 0 {
-		return ErrNotMember
+	if before := p.Filters["joined_before"]; before != "" {
+		args = append(args, before)
+		where = append(where, fmt.Sprintf("m.created_at < $%d::timestamptz", len(args)))
 	}
-	return nil
+	if search != "" {
+		args = append(args, likePrefix(search), search)
+		prefix, fuzzy := len(args)-1, len(args)
+		where = append(where, fmt.Sprintf(
+			"(u.name ILIKE $%[1]d OR u.email ILIKE $%[1]d OR $%[2]d <%% u.name OR $%[2]d <%% u.email)",
+			prefix, fuzzy,
+		))
+	}
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
+	query := `
+		SELECT m.id, m.organization_id, m.user_id, m.role, m.created_at, m.updated_at,
+			   u.email, u.name, u.picture
+		FROM organization_members m
+		JOIN users u ON m.user_id = u.id
+		JOIN organizations o ON m.organization_id = o.id
+		WHERE ` + strings.Join(where, " AND ") + ` AND ` + keyset + `
+		` + order
+
+	var members []MemberWithUser
+	if err := r.postgres.SelectContext(ctx, &members, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	members, next := pagination.Page(p, members, func(m MemberWithUser) ([]any, string) {
+		switch p.Sort.Key {
+		case "name":
+			return []any{m.Name}, m.ID
+		case "email":
+			return []any{m.Email}, m.ID
+		case "joined":
+			return []any{m.CreatedAt}, m.ID
+		default:
+			return []any{roleRank(m.Role), m.Name}, m.ID
+		}
+	})
+	return members, next, nil
 }
 
-func (r *Repository) CountOwners(ctx context.Context, orgID string) (int, error) {
-	var count int
-	query := `SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = 'owner'`
-	err := r.postgres.GetContext(ctx, &count, query, orgID)
-	return count, err
+// likePrefix escapes LIKE wildcards in s and returns a prefix pattern.
+func likePrefix(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return s + "%"
 }
 
-func (r *Repository) TransferOwnership(ctx context.Context, orgID, currentOwnerID, newOwnerID string) error {
+// UpdateMemberRole changes a member's role. Unless manageOwners is set,
+// promoting to or demoting from owner fails with ErrOwnerRole. When version is
+// set the update only applies if the membership's updated_at still equals it,
+// otherwise request.ErrPreconditionFailed is returned.
+func (r *Repository) UpdateMemberRole(ctx context.Context, orgID, userID string, role Role, manageOwners bool, version *time.Time) (*Member, error) {
 	tx, err := r.postgres.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Demote current owner to admin
-	demoteQuery := `
-		UPDATE organization_members
-		SET role = 'admin', updated_at = NOW()
-		WHERE organization_id = $1 AND user_id = $2
-	`
-	_, err = tx.ExecContext(ctx, demoteQuery, orgID, currentOwnerID)
+	if !role.IsValid() {
+		// Share-lock the custom role so it cannot be deleted before we commit
+		var id string
+		roleQuery := `SELECT id FROM organization_roles WHERE organization_id = $1 AND name = $2 FOR SHARE`
+		err = tx.GetContext(ctx, &id, roleQuery, orgID, role)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRole
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	owners, err := lockOwners(ctx, tx, orgID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Promote new owner
-	promoteQuery := `
-		UPDATE organization_members
-		SET role = 'owner', updated_at = NOW()
+	var before Member
+	beforeQuery := `
+		SELECT id, organization_id, user_id, role, created_at, updated_at
+		FROM organization_members
 		WHERE organization_id = $1 AND user_id = $2
+		FOR UPDATE
 	`
-	result, err := tx.ExecContext(ctx, promoteQuery, orgID, newOwnerID)
+	err = tx.GetContext(ctx, &before, beforeQuery, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotMember
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version != nil && !before.UpdatedAt.Equal(*version) {
+		return nil, request.ErrPreconditionFailed
+	}
+	if (role == RoleOwner || before.Role == RoleOwner) && !manageOwners {
+		return nil, ErrOwnerRole
+	}
+	if before.Role == RoleOwner && role != RoleOwner && len(owners) <= 1 {
+		return nil, ErrLastOwner
+	}
+
+	var member Member
+	query := `
+		UPDATE organization_members
+		SET role = $3, updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+		RETURNING id, organization_id, user_id, role, created_at, updated_at
+	`
+	if err = tx.GetContext(ctx, &member, query, orgID, userID, role); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionMemberRoleChanged,
+		TargetType:     targetMember,
+		TargetID:       userID,
+		Before:         map[string]any{"role": before.Role},
+		After:          map[string]any{"role": role},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, ownerViolation(err)
+	}
+
+	return &member, nil
+}
+
+// RemoveMember deletes a membership. Removing the only owner fails with
+// ErrLastOwner.
+func (r *Repository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	owners, err := lockOwners(ctx, tx, orgID)
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
+	if len(owners) == 1 && owners[0] == userID {
+		return ErrLastOwner
+	}
+
+	var role Role
+	query := `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2 RETURNING role`
+	err = tx.GetContext(ctx, &role, query, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
 		return ErrNotMember
 	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionMemberRemoved,
+		TargetType:     targetMember,
+		TargetID:       userID,
+		Before:         map[string]any{"role": role},
+	})
+	if err != nil {
+		return err
+	}
+
+	return ownerViolation(tx.Commit())
+}
+
+// Ownership transfer operations
+
+const transferDetailsQuery = `
+	SELECT t.id, t.organization_id, t.from_user_id, t.to_user_id, t.expires_at, t.created_at,
+		   o.name AS organization_name, o.slug AS organization_slug,
+		   f.name AS from_name, f.email AS from_email,
+		   u.name AS to_name, u.email AS to_email
+	FROM organization_ownership_transfers t
+	JOIN organizations o ON t.organization_id = o.id
+	JOIN users f ON t.from_user_id = f.id
+	JOIN users u ON t.to_user_id = u.id
+`
+
+// CreateOwnershipTransfer records a pending transfer from an owner to another
+// member. An organization has at most one pending transfer; an expired one is
+// replaced.
+func (r *Repository) CreateOwnershipTransfer(ctx context.Context, orgID, fromUserID, toUserID string, expiresAt time.Time) (*OwnershipTransfer, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	owners, err := lockOwners(ctx, tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(owners, fromUserID) {
+		return nil, ErrNotOwner
+	}
+	if slices.Contains(owners, toUserID) {
+		return nil, ErrAlreadyOwner
+	}
+
+	var isMember bool
+	memberQuery := `SELECT EXISTS (SELECT 1 FROM organization_members WHERE organization_id = $1 AND user_id = $2)`
+	if err := tx.GetContext(ctx, &isMember, memberQuery, orgID, toUserID); err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotMember
+	}
+
+	expiredQuery := `DELETE FROM organization_ownership_transfers WHERE organization_id = $1 AND expires_at <= NOW()`
+	if _, err := tx.ExecContext(ctx, expiredQuery, orgID); err != nil {
+		return nil, err
+	}
+
+	var t OwnershipTransfer
+	query := `
+		INSERT INTO organization_ownership_transfers (organization_id, from_user_id, to_user_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, organization_id, from_user_id, to_user_id, expires_at, created_at
+	`
+	err = tx.GetContext(ctx, &t, query, orgID, fromUserID, toUserID, expiresAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrTransferPending
+		}
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionTransferInitiated,
+		TargetType:     targetTransfer,
+		TargetID:       t.ID,
+		After:          map[string]any{"from_user_id": fromUserID, "to_user_id": toUserID, "expires_at": expiresAt},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetPendingTransfer returns the organization's unexpired transfer.
+func (r *Repository) GetPendingTransfer(ctx context.Context, orgID string) (*OwnershipTransferWithDetails, error) {
+	var t OwnershipTransferWithDetails
+	query := transferDetailsQuery + `WHERE t.organization_id = $1 AND t.expires_at > NOW() AND o.deleted_at IS NULL`
+	err := r.postgres.GetContext(ctx, &t, query, orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetPendingTransfersForUser returns unexpired transfers the user initiated
+// or is being asked to accept.
+func (r *Repository) GetPendingTransfersForUser(ctx context.Context, userID string) ([]OwnershipTransferWithDetails, error) {
+	transfers := []OwnershipTransferWithDetails{}
+	query := transferDetailsQuery + `
+		WHERE (t.from_user_id = $1 OR t.to_user_id = $1) AND t.expires_at > NOW() AND o.deleted_at IS NULL
+		ORDER BY t.created_at DESC
+	`
+	if err := r.postgres.SelectContext(ctx, &transfers, query, userID); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// CancelOwnershipTransfer deletes the organization's pending transfer.
+func (r *Repository) CancelOwnershipTransfer(ctx context.Context, orgID string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var t OwnershipTransfer
+	query := `
+		DELETE FROM organization_ownership_transfers
+		WHERE organization_id = $1 AND expires_at > NOW()
+		RETURNING id, organization_id, from_user_id, to_user_id, expires_at, created_at
+	`
+	err = tx.GetContext(ctx, &t, query, orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionTransferCancelled,
+		TargetType:     targetTransfer,
+		TargetID:       t.ID,
+		Before:         map[string]any{"from_user_id": t.FromUserID, "to_user_id": t.ToUserID},
+	})
+	if err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
 
+// TransferOwnership completes the organization's pending transfer on behalf
+// of newOwnerID, who must be its recipient. The initiating owner is demoted
+// to admin and the recipient promoted to owner in one transaction. It returns
+// ErrNotFound when there is no unexpired transfer to the user, and
+// ErrNotOwner when the initiator is no longer an owner. It returns the
+// completed transfer.
+func (r *Repository) TransferOwnership(ctx context.Context, orgID, newOwnerID string) (*OwnershipTransfer, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var t OwnershipTransfer
+	transferQuery := `
+		SELECT id, organization_id, from_user_id, to_user_id, expires_at, created_at
+		FROM organization_ownership_transfers
+		WHERE organization_id = $1 AND to_user_id = $2 AND expires_at > NOW()
+		FOR UPDATE
+	`
+	err = tx.GetContext(ctx, &t, transferQuery, orgID, newOwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := lockOwners(ctx, tx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(owners, t.FromUserID) {
+		return nil, ErrNotOwner
+	}
+
+	// Demote current owner to admin
+	demoteQuery := `
+		UPDATE organization_members
+		SET role = 'admin', updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+	`
+	_, err = tx.ExecContext(ctx, demoteQuery, orgID, t.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Promote new owner
+	promoteQuery := `
+		UPDATE organization_members
+		SET role = 'owner', updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+	`
+	result, err := tx.ExecContext(ctx, promoteQuery, orgID, newOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrNotMember
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_ownership_transfers WHERE id = $1`, t.ID); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionOwnershipTransferred,
+		TargetType:     targetMember,
+		TargetID:       newOwnerID,
+		Before:         map[string]any{"owner_id": t.FromUserID},
+		After:          map[string]any{"owner_id": newOwnerID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ownerViolation(tx.Commit()); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 // Invitation operations
 
-func (r *Repository) CreateInvitation(ctx context.Context, orgID, email string, role Role, invitedBy string, expiresAt time.Time) (*Invitation, error) {
+func (r *Repository) CreateInvitation(ctx context.Context, orgID, email string, role Role, invitedBy *string, expiresAt time.Time) (*Invitation, error) {
 	token, err := generateToken()
 	if err != nil {
 		return nil, err
 	}
 
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var inv Invitation
 	query := `
 		INSERT INTO organization_invitations (organization_id, email, role, token, invited_by, expires_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, organization_id, email, role, token, invited_by, status, expires_at, created_at, updated_at
 	`
-	err = r.postgres.GetContext(ctx, &inv, query, orgID, email, role, token, invitedBy, expiresAt)
+	err = tx.GetContext(ctx, &inv, query, orgID, email, role, token, invitedBy, expiresAt)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
 			return nil, ErrInviteExists
 		}
 		return nil, err
 	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionInvitationCreated,
+		TargetType:     targetInvitation,
+		TargetID:       inv.ID,
+		After:          map[string]any{"email": email, "role": role},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &inv, nil
 }
 
@@ -316,11 +1100,11 @@ func (r *Repository) GetInvitationByToken(ctx context.Context, token string) (*I
 	query := `
 		SELECT i.id, i.organization_id, i.email, i.role, i.token, i.invited_by, i.status,
 			   i.expires_at, i.created_at, i.updated_at,
-			   o.name as organization_name, u.name as invited_by_name
+			   o.name as organization_name, COALESCE(u.name, '') as invited_by_name
 		FROM organization_invitations i
 		JOIN organizations o ON i.organization_id = o.id
-		JOIN users u ON i.invited_by = u.id
-		WHERE i.token = $1
+		LEFT JOIN users u ON i.invited_by = u.id
+		WHERE i.token = $1 AND o.deleted_at IS NULL
 	`
 	err := r.postgres.GetContext(ctx, &inv, query, token)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -343,32 +1127,87 @@ func (r *Repository) GetInvitationByID(ctx context.Context, id string) (*Invitat
 	return &inv, err
 }
 
-func (r *Repository) GetPendingInvitationsForOrg(ctx context.Context, orgID string) ([]Invitation, error) {
-	var invitations []Invitation
+// InvitationsPage lists pending invitations, newest first by default.
+var InvitationsPage = pagination.Spec{
+	Sorts: []pagination.Sort{
+		{Key: "created", Columns: []pagination.Column{{Expr: "i.created_at", Type: "timestamptz"}}, Desc: true},
+		{Key: "email", Columns: []pagination.Column{{Expr: "i.email", Type: "text"}}},
+		{Key: "expires", Columns: []pagination.Column{{Expr: "i.expires_at", Type: "timestamptz"}}},
+	},
+	Filters:      []string{"role"},
+	IDColumn:     "i.id",
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+func invitationKey(p pagination.Params, inv Invitation) ([]any, string) {
+	switch p.Sort.Key {
+	case "email":
+		return []any{inv.Email}, inv.ID
+	case "expires":
+		return []any{inv.ExpiresAt}, inv.ID
+	default:
+		return []any{inv.CreatedAt}, inv.ID
+	}
+}
+
+func (r *Repository) GetPendingInvitationsForOrg(ctx context.Context, orgID string, p pagination.Params) ([]Invitation, string, error) {
+	args := []any{orgID}
+	where := []string{"i.organization_id = $1", "i.status = 'pending'"}
+	if role := p.Filters["role"]; role != "" {
+		args = append(args, role)
+		where = append(where, fmt.Sprintf("i.role = $%d", len(args)))
+	}
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
 	query := `
-		SELECT id, organization_id, email, role, token, invited_by, status, expires_at, created_at, updated_at
-		FROM organization_invitations
-		WHERE organization_id = $1 AND status = 'pending'
-		ORDER BY created_at DESC
-	`
-	err := r.postgres.SelectContext(ctx, &invitations, query, orgID)
-	return invitations, err
+		SELECT i.id, i.organization_id, i.email, i.role, i.token, i.invited_by, i.status,
+			   i.expires_at, i.created_at, i.updated_at
+		FROM organization_invitations i
+		WHERE ` + strings.Join(where, " AND ") + ` AND ` + keyset + `
+		` + order
+
+	var invitations []Invitation
+	if err := r.postgres.SelectContext(ctx, &invitations, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	invitations, next := pagination.Page(p, invitations, func(inv Invitation) ([]any, string) {
+		return invitationKey(p, inv)
+	})
+	return invitations, next, nil
 }
 
-func (r *Repository) GetPendingInvitationsForEmail(ctx context.Context, email string) ([]InvitationWithDetails, error) {
-	var invitations []InvitationWithDetails
+func (r *Repository) GetPendingInvitationsForEmail(ctx context.Context, email string, p pagination.Params) ([]InvitationWithDetails, string, error) {
+	args := []any{email}
+	where := []string{"i.email = $1", "i.status = 'pending'", "i.expires_at > NOW()", "o.deleted_at IS NULL"}
+	if role := p.Filters["role"]; role != "" {
+		args = append(args, role)
+		where = append(where, fmt.Sprintf("i.role = $%d", len(args)))
+	}
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
 	query := `
 		SELECT i.id, i.organization_id, i.email, i.role, i.token, i.invited_by, i.status,
 			   i.expires_at, i.created_at, i.updated_at,
-			   o.name as organization_name, u.name as invited_by_name
+			   o.name as organization_name, COALESCE(u.name, '') as invited_by_name
 		FROM organization_invitations i
 		JOIN organizations o ON i.organization_id = o.id
-		JOIN users u ON i.invited_by = u.id
-		WHERE i.email = $1 AND i.status = 'pending' AND i.expires_at > NOW()
-		ORDER BY i.created_at DESC
-	`
-	err := r.postgres.SelectContext(ctx, &invitations, query, email)
-	return invitations, err
+		LEFT JOIN users u ON i.invited_by = u.id
+		WHERE ` + strings.Join(where, " AND ") + ` AND ` + keyset + `
+		` + order
+
+	var invitations []InvitationWithDetails
+	if err := r.postgres.SelectContext(ctx, &invitations, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	invitations, next := pagination.Page(p, invitations, func(inv InvitationWithDetails) ([]any, string) {
+		return invitationKey(p, inv.Invitation)
+	})
+	return invitations, next, nil
 }
 
 func (r *Repository) UpdateInvitationStatus(ctx context.Context, id string, status InvitationStatus) error {
@@ -392,19 +1231,37 @@ func (r *Repository) UpdateInvitationStatus(ctx context.Context, id string, stat
 }
 
 func (r *Repository) DeleteInvitation(ctx context.Context, id string) error {
-	query := `DELETE FROM organization_invitations WHERE id = $1`
-	result, err := r.postgres.ExecContext(ctx, query, id)
+	tx, err := r.postgres.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	var inv Invitation
+	query := `
+		DELETE FROM organization_invitations WHERE id = $1
+		RETURNING id, organization_id, email, role, token, invited_by, status, expires_at, created_at, updated_at
+	`
+	err = tx.GetContext(ctx, &inv, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return ErrNotFound
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: inv.OrganizationID,
+		Action:         ActionInvitationCancelled,
+		TargetType:     targetInvitation,
+		TargetID:       inv.ID,
+		Before:         map[string]any{"email": inv.Email, "role": inv.Role, "status": inv.Status},
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+
+	return tx.Commit()
 }
 
 func (r *Repository) IsMemberByEmail(ctx context.Context, orgID, email string) (bool, error) {
@@ -418,27 +1275,1055 @@ func (r *Repository) IsMemberByEmail(ctx context.Context, orgID, email string) (
 	return count > 0, err
 }
 
-// Helper functions
+// Join link operations
 
-func generateToken() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+const joinLinkColumns = `id, organization_id, token, role, max_uses, use_count, allowed_domain,
+	created_by, expires_at, revoked_at, created_at, updated_at`
+
+func (r *Repository) CreateJoinLink(ctx context.Context, orgID string, role Role, maxUses *int, allowedDomain *string, createdBy string, expiresAt time.Time) (*JoinLink, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
 	}
-	return base64.URLEncoding.EncodeToString(bytes), nil
+
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var link JoinLink
+	query := `
+		INSERT INTO organization_join_links (organization_id, token, role, max_uses, allowed_domain, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + joinLinkColumns
+	if err = tx.GetContext(ctx, &link, query, orgID, token, role, maxUses, allowedDomain, createdBy, expiresAt); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionJoinLinkCreated,
+		TargetType:     targetJoinLink,
+		TargetID:       link.ID,
+		After: map[string]any{
+			"role":           role,
+			"max_uses":       maxUses,
+			"allowed_domain": allowedDomain,
+			"expires_at":     link.ExpiresAt,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *Repository) GetJoinLinkByID(ctx context.Context, id string) (*JoinLink, error) {
+	var link JoinLink
+	query := `SELECT ` + joinLinkColumns + ` FROM organization_join_links WHERE id = $1`
+	err := r.postgres.GetContext(ctx, &link, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &link, err
+}
+
+func (r *Repository) GetActiveJoinLinksForOrg(ctx context.Context, orgID string) ([]JoinLink, error) {
+	var links []JoinLink
+	query := `
+		SELECT ` + joinLinkColumns + `
+		FROM organization_join_links
+		WHERE organization_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+	err := r.postgres.SelectContext(ctx, &links, query, orgID)
+	return links, err
+}
+
+func (r *Repository) GetJoinLinkPreview(ctx context.Context, token string) (*JoinLinkPreview, error) {
+	var preview JoinLinkPreview
+	query := `
+		SELECT l.organization_id, o.name as organization_name, l.role, l.expires_at
+		FROM organization_join_links l
+		JOIN organizations o ON l.organization_id = o.id
+		WHERE l.token = $1 AND l.revoked_at IS NULL AND o.deleted_at IS NULL
+	`
+	err := r.postgres.GetContext(ctx, &preview, query, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &preview, err
+}
+
+func (r *Repository) RevokeJoinLink(ctx context.Context, id string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orgID string
+	query := `
+		UPDATE organization_join_links
+		SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING organization_id
+	`
+	err = tx.GetContext(ctx, &orgID, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionJoinLinkRevoked,
+		TargetType:     targetJoinLink,
+		TargetID:       id,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RedeemJoinLink adds the user to the link's organization and consumes one use.
+// The link row is locked so concurrent redemptions cannot exceed max_uses.
+func (r *Repository) RedeemJoinLink(ctx context.Context, token, userID, email string) (*JoinLink, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var link JoinLink
+	query := `
+		SELECT ` + joinLinkColumns + ` FROM organization_join_links
+		WHERE token = $1
+		  AND organization_id IN (SELECT id FROM organizations WHERE deleted_at IS NULL)
+		FOR UPDATE`
+	err = tx.GetContext(ctx, &link, query, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if link.RevokedAt != nil {
+		return nil, ErrJoinLinkRevoked
+	}
+	if time.Now().After(link.ExpiresAt) {
+		return nil, ErrJoinLinkExpired
+	}
+	if link.MaxUses != nil && link.UseCount >= *link.MaxUses {
+		return nil, ErrJoinLinkExhausted
+	}
+	if link.AllowedDomain != nil && EmailDomain(email) != *link.AllowedDomain {
+		return nil, ErrDomainNotAllowed
+	}
+
+	var settings Settings
+	if err = tx.GetContext(ctx, &settings, `SELECT settings FROM organizations WHERE id = $1`, link.OrganizationID); err != nil {
+		return nil, err
+	}
+	if !settings.AllowsEmail(email) {
+		return nil, ErrDomainNotAllowed
+	}
+
+	memberQuery := `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`
+	_, err = tx.ExecContext(ctx, memberQuery, link.OrganizationID, userID, link.Role)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrAlreadyMember
+		}
+		return nil, err
+	}
+
+	useQuery := `
+		UPDATE organization_join_links
+		SET use_count = use_count + 1, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + joinLinkColumns
+	if err = tx.GetContext(ctx, &link, useQuery, link.ID); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: link.OrganizationID,
+		Action:         ActionMemberAdded,
+		TargetType:     targetMember,
+		TargetID:       userID,
+		After:          map[string]any{"role": link.Role, "join_link_id": link.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// Domain operations
+
+const domainColumns = `id, organization_id, domain, verification_token, verified_at,
+	default_role, join_mode, created_by, created_at, updated_at`
+
+func (r *Repository) ClaimDomain(ctx context.Context, orgID, domain string, defaultRole Role, joinMode DomainJoinMode, createdBy string) (*Domain, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var d Domain
+	query := `
+		INSERT INTO organization_domains (organization_id, domain, verification_token, default_role, join_mode, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + domainColumns
+	err = tx.GetContext(ctx, &d, query, orgID, domain, token, defaultRole, joinMode, createdBy)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrDomainExists
+		}
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionDomainClaimed,
+		TargetType:     targetDomain,
+		TargetID:       d.ID,
+		After:          map[string]any{"domain": domain, "default_role": defaultRole, "join_mode": joinMode},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func (r *Repository) GetDomainByID(ctx context.Context, id string) (*Domain, error) {
+	var d Domain
+	query := `SELECT ` + domainColumns + ` FROM organization_domains WHERE id = $1`
+	err := r.postgres.GetContext(ctx, &d, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &d, err
+}
+
+func (r *Repository) GetDomainsForOrg(ctx context.Context, orgID string) ([]Domain, error) {
+	var domains []Domain
+	query := `SELECT ` + domainColumns + ` FROM organization_domains WHERE organization_id = $1 ORDER BY domain`
+	err := r.postgres.SelectContext(ctx, &domains, query, orgID)
+	return domains, err
+}
+
+// GetVerifiedDomain returns the verified claim for an email domain, if any.
+func (r *Repository) GetVerifiedDomain(ctx context.Context, domain string) (*Domain, error) {
+	var d Domain
+	query := `
+		SELECT ` + domainColumns + ` FROM organization_domains
+		WHERE domain = $1 AND verified_at IS NOT NULL
+		  AND organization_id IN (SELECT id FROM organizations WHERE deleted_at IS NULL)`
+	err := r.postgres.GetContext(ctx, &d, query, domain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &d, err
+}
+
+// HasLeft reports whether the user was ever removed from or left the
+// organization, so domain sign-in does not add them back.
+func (r *Repository) HasLeft(ctx context.Context, orgID, userID string) (bool, error) {
+	var left bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM audit_events
+			WHERE organization_id = $1 AND action = $2 AND target_type = $3 AND target_id = $4
+		)
+	`
+	err := r.postgres.GetContext(ctx, &left, query, orgID, ActionMemberRemoved, targetMember, userID)
+	return left, err
+}
+
+// LatestInvitationStatus returns the status of the newest invitation of email
+// to the organization, with lapsed pending invitations reported as expired.
+// It returns ErrNotFound if the email was never invited.
+func (r *Repository) LatestInvitationStatus(ctx context.Context, orgID, email string) (InvitationStatus, error) {
+	var status InvitationStatus
+	query := `
+		SELECT CASE WHEN status = 'pending' AND expires_at <= NOW() THEN 'expired' ELSE status END
+		FROM organization_invitations
+		WHERE organization_id = $1 AND email = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := r.postgres.GetContext(ctx, &status, query, orgID, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return status, err
+}
+
+func (r *Repository) MarkDomainVerified(ctx context.Context, id string) (*Domain, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var d Domain
+	query := `
+		UPDATE organization_domains
+		SET verified_at = COALESCE(verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + domainColumns
+	err = tx.GetContext(ctx, &d, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrDomainClaimed
+		}
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: d.OrganizationID,
+		Action:         ActionDomainVerified,
+		TargetType:     targetDomain,
+		TargetID:       d.ID,
+		After:          map[string]any{"domain": d.Domain},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func (r *Repository) UpdateDomain(ctx context.Context, id string, defaultRole Role, joinMode DomainJoinMode) (*Domain, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before Domain
+	err = tx.GetContext(ctx, &before, `SELECT `+domainColumns+` FROM organization_domains WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var d Domain
+	query := `
+		UPDATE organization_domains
+		SET default_role = $2, join_mode = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + domainColumns
+	if err = tx.GetContext(ctx, &d, query, id, defaultRole, joinMode); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: d.OrganizationID,
+		Action:         ActionDomainUpdated,
+		TargetType:     targetDomain,
+		TargetID:       d.ID,
+		Before:         map[string]any{"default_role": before.DefaultRole, "join_mode": before.JoinMode},
+		After:          map[string]any{"default_role": d.DefaultRole, "join_mode": d.JoinMode},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func (r *Repository) DeleteDomain(ctx context.Context, id string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var d Domain
+	err = tx.GetContext(ctx, &d, `DELETE FROM organization_domains WHERE id = $1 RETURNING `+domainColumns, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: d.OrganizationID,
+		Action:         ActionDomainDeleted,
+		TargetType:     targetDomain,
+		TargetID:       d.ID,
+		Before:         map[string]any{"domain": d.Domain, "verified": d.VerifiedAt != nil},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Custom role operations
+
+const roleColumns = `id, organization_id, name, description, permissions, created_at, updated_at`
+
+func (r *Repository) CreateRole(ctx context.Context, orgID string, name Role, description string, permissions PermissionList) (*CustomRole, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var role CustomRole
+	query := `
+		INSERT INTO organization_roles (organization_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + roleColumns
+	err = tx.GetContext(ctx, &role, query, orgID, name, description, permissions)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrRoleExists
+		}
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionRoleCreated,
+		TargetType:     targetRole,
+		TargetID:       string(name),
+		After:          map[string]any{"description": description, "permissions": permissions},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *Repository) GetRole(ctx context.Context, orgID, name string) (*CustomRole, error) {
+	var role CustomRole
+	query := `SELECT ` + roleColumns + ` FROM organization_roles WHERE organization_id = $1 AND name = $2`
+	err := r.postgres.GetContext(ctx, &role, query, orgID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &role, err
+}
+
+func (r *Repository) GetRoles(ctx context.Context, orgID string) ([]CustomRole, error) {
+	var roles []CustomRole
+	query := `SELECT ` + roleColumns + ` FROM organization_roles WHERE organization_id = $1 ORDER BY name`
+	err := r.postgres.SelectContext(ctx, &roles, query, orgID)
+	return roles, err
+}
+
+func (r *Repository) UpdateRole(ctx context.Context, orgID, name, description string, permissions PermissionList) (*CustomRole, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before CustomRole
+	beforeQuery := `SELECT ` + roleColumns + ` FROM organization_roles WHERE organization_id = $1 AND name = $2 FOR UPDATE`
+	err = tx.GetContext(ctx, &before, beforeQuery, orgID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var role CustomRole
+	query := `
+		UPDATE organization_roles
+		SET description = $3, permissions = $4, updated_at = NOW()
+		WHERE organization_id = $1 AND name = $2
+		RETURNING ` + roleColumns
+	if err = tx.GetContext(ctx, &role, query, orgID, name, description, permissions); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionRoleUpdated,
+		TargetType:     targetRole,
+		TargetID:       name,
+		Before:         map[string]any{"description": before.Description, "permissions": before.Permissions},
+		After:          map[string]any{"description": role.Description, "permissions": role.Permissions},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// DeleteRole removes a custom role. It fails with ErrRoleInUse while any member
+// still holds the role; the row lock blocks concurrent assignments.
+func (r *Repository) DeleteRole(ctx context.Context, orgID, name string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id string
+	lockQuery := `SELECT id FROM organization_roles WHERE organization_id = $1 AND name = $2 FOR UPDATE`
+	err = tx.GetContext(ctx, &id, lockQuery, orgID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var count int
+	countQuery := `SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = $2`
+	if err = tx.GetContext(ctx, &count, countQuery, orgID, name); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM organization_roles WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionRoleDeleted,
+		TargetType:     targetRole,
+		TargetID:       name,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Team operations
+
+const teamColumns = `t.id, t.organization_id, t.parent_id, t.name, t.description, t.permissions,
+	(SELECT COUNT(*) FROM organization_team_members tm WHERE tm.team_id = t.id) AS member_count,
+	t.created_at, t.updated_at`
+
+const teamMemberColumns = `tm.team_id, tm.user_id, tm.role, u.email, u.name, u.picture, tm.created_at, tm.updated_at`
+
+// CreateTeam creates a team. A parent must be a top-level team of the same
+// organization; ErrNotFound or ErrTeamTooDeep is returned otherwise.
+func (r *Repository) CreateTeam(ctx context.Context, orgID, name, description string, parentID *string, permissions PermissionList) (*Team, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if parentID != nil {
+		// A team's parent never changes, so the parent cannot become a child
+		// team after this check
+		var grandparentID *string
+		parentQuery := `SELECT parent_id FROM organization_teams WHERE organization_id = $1 AND id = $2 FOR SHARE`
+		err = tx.GetContext(ctx, &grandparentID, parentQuery, orgID, *parentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if grandparentID != nil {
+			return nil, ErrTeamTooDeep
+		}
+	}
+
+	var id string
+	query := `
+		INSERT INTO organization_teams (organization_id, parent_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = tx.GetContext(ctx, &id, query, orgID, parentID, name, description, permissions)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrTeamExists
+		}
+		return nil, err
+	}
+
+	var team Team
+	teamQuery := `SELECT ` + teamColumns + ` FROM organization_teams t WHERE t.id = $1`
+	if err = tx.GetContext(ctx, &team, teamQuery, id); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionTeamCreated,
+		TargetType:     targetTeam,
+		TargetID:       id,
+		After:          map[string]any{"name": name, "parent_id": parentID, "permissions": permissions},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *Repository) GetTeams(ctx context.Context, orgID string) ([]Team, error) {
+	teams := []Team{}
+	query := `SELECT ` + teamColumns + ` FROM organization_teams t WHERE t.organization_id = $1 ORDER BY t.name`
+	err := r.postgres.SelectContext(ctx, &teams, query, orgID)
+	return teams, err
+}
+
+func (r *Repository) GetTeam(ctx context.Context, orgID, teamID string) (*Team, error) {
+	var team Team
+	query := `SELECT ` + teamColumns + ` FROM organization_teams t WHERE t.organization_id = $1 AND t.id = $2`
+	err := r.postgres.GetContext(ctx, &team, query, orgID, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// UpdateTeam applies fn to the locked team and saves its name, description
+// and permissions.
+func (r *Repository) UpdateTeam(ctx context.Context, orgID, teamID string, fn func(*Team) error) (*Team, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before Team
+	beforeQuery := `SELECT ` + teamColumns + ` FROM organization_teams t WHERE t.organization_id = $1 AND t.id = $2 FOR UPDATE`
+	err = tx.GetContext(ctx, &before, beforeQuery, orgID, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := before
+	next.Permissions = slices.Clone(before.Permissions)
+	if err = fn(&next); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE organization_teams
+		SET name = $2, description = $3, permissions = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err = tx.ExecContext(ctx, query, teamID, next.Name, next.Description, next.Permissions); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrTeamExists
+		}
+		return nil, err
+	}
+
+	var team Team
+	teamQuery := `SELECT ` + teamColumns + ` FROM organization_teams t WHERE t.id = $1`
+	if err = tx.GetContext(ctx, &team, teamQuery, teamID); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionTeamUpdated,
+		TargetType:     targetTeam,
+		TargetID:       teamID,
+		Before:         map[string]any{"name": before.Name, "description": before.Description, "permissions": before.Permissions},
+		After:          map[string]any{"name": team.Name, "description": team.Description, "permissions": team.Permissions},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// DeleteTeam deletes a team and its memberships. Its child teams become
+// top-level teams.
+func (r *Repository) DeleteTeam(ctx context.Context, orgID, teamID string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var name string
+	query := `DELETE FROM organization_teams WHERE organization_id = $1 AND id = $2 RETURNING name`
+	err = tx.GetContext(ctx, &name, query, orgID, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionTeamDeleted,
+		TargetType:     targetTeam,
+		TargetID:       teamID,
+		Before:         map[string]any{"name": name},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetTeamMembers(ctx context.Context, orgID, teamID string) ([]TeamMember, error) {
+	members := []TeamMember{}
+	query := `
+		SELECT ` + teamMemberColumns + `
+		FROM organization_team_members tm
+		JOIN users u ON tm.user_id = u.id
+		WHERE tm.organization_id = $1 AND tm.team_id = $2
+		ORDER BY tm.role, u.name
+	`
+	err := r.postgres.SelectContext(ctx, &members, query, orgID, teamID)
+	return members, err
+}
+
+// GetTeamRole returns the user's role in the team, or ErrNotMember.
+func (r *Repository) GetTeamRole(ctx context.Context, orgID, teamID, userID string) (TeamRole, error) {
+	var role TeamRole
+	query := `SELECT role FROM organization_team_members WHERE organization_id = $1 AND team_id = $2 AND user_id = $3`
+	err := r.postgres.GetContext(ctx, &role, query, orgID, teamID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+	return role, err
+}
+
+// SetTeamMember adds an organization member to a team or changes their role
+// in it. It returns ErrNotFound for an unknown team and ErrNotMember when the
+// user is not in the organization.
+func (r *Repository) SetTeamMember(ctx context.Context, orgID, teamID, userID string, role TeamRole) (*TeamMember, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	teamQuery := `SELECT EXISTS (SELECT 1 FROM organization_teams WHERE organization_id = $1 AND id = $2)`
+	if err = tx.GetContext(ctx, &exists, teamQuery, orgID, teamID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	var before TeamRole
+	beforeQuery := `
+		SELECT role FROM organization_team_members
+		WHERE team_id = $1 AND user_id = $2
+		FOR UPDATE
+	`
+	err = tx.GetContext(ctx, &before, beforeQuery, teamID, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if before != role {
+		query := `
+			INSERT INTO organization_team_members (team_id, organization_id, user_id, role)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = NOW()
+		`
+		if _, err = tx.ExecContext(ctx, query, teamID, orgID, userID, role); err != nil {
+			// The team was checked above, so the failing reference is the membership
+			if strings.Contains(err.Error(), "foreign key") {
+				return nil, ErrNotMember
+			}
+			return nil, err
+		}
+
+		entry := audit.Entry{
+			OrganizationID: orgID,
+			Action:         ActionTeamMemberAdded,
+			TargetType:     targetTeam,
+			TargetID:       teamID,
+			After:          map[string]any{"user_id": userID, "role": role},
+		}
+		if before != "" {
+			entry.Action = ActionTeamMemberRoleChanged
+			entry.Before = map[string]any{"user_id": userID, "role": before}
+		}
+		if err = audit.Record(ctx, tx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	var member TeamMember
+	memberQuery := `
+		SELECT ` + teamMemberColumns + `
+		FROM organization_team_members tm
+		JOIN users u ON tm.user_id = u.id
+		WHERE tm.team_id = $1 AND tm.user_id = $2
+	`
+	if err = tx.GetContext(ctx, &member, memberQuery, teamID, userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *Repository) RemoveTeamMember(ctx context.Context, orgID, teamID, userID string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var role TeamRole
+	query := `
+		DELETE FROM organization_team_members
+		WHERE organization_id = $1 AND team_id = $2 AND user_id = $3
+		RETURNING role
+	`
+	err = tx.GetContext(ctx, &role, query, orgID, teamID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionTeamMemberRemoved,
+		TargetType:     targetTeam,
+		TargetID:       teamID,
+		Before:         map[string]any{"user_id": userID, "role": role},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTeamGrants returns the permissions the user's teams grant them,
+// including those inherited from the parents of their teams.
+func (r *Repository) GetTeamGrants(ctx context.Context, orgID, userID string) ([]Permission, error) {
+	var lists []PermissionList
+	query := `
+		SELECT t.permissions
+		FROM organization_teams t
+		WHERE t.organization_id = $1 AND t.id IN (
+			SELECT tm.team_id FROM organization_team_members tm
+			WHERE tm.organization_id = $1 AND tm.user_id = $2
+			UNION
+			SELECT c.parent_id FROM organization_team_members tm
+			JOIN organization_teams c ON c.id = tm.team_id
+			WHERE tm.organization_id = $1 AND tm.user_id = $2 AND c.parent_id IS NOT NULL
+		)
+	`
+	if err := r.postgres.SelectContext(ctx, &lists, query, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	var grants []Permission
+	for _, list := range lists {
+		grants = append(grants, list...)
+	}
+	return grants, nil
+}
+
+// GetTeamMembershipGrants returns the permissions joining the team grants:
+// its own and those of its parent.
+func (r *Repository) GetTeamMembershipGrants(ctx context.Context, orgID, teamID string) ([]Permission, error) {
+	var lists []PermissionList
+	query := `
+		SELECT t.permissions
+		FROM organization_teams t
+		WHERE t.organization_id = $1 AND t.id IN (
+			SELECT id FROM organization_teams WHERE organization_id = $1 AND id = $2
+			UNION
+			SELECT parent_id FROM organization_teams WHERE organization_id = $1 AND id = $2 AND parent_id IS NOT NULL
+		)
+	`
+	if err := r.postgres.SelectContext(ctx, &lists, query, orgID, teamID); err != nil {
+		return nil, err
+	}
+
+	var grants []Permission
+	for _, list := range lists {
+		grants = append(grants, list...)
+	}
+	return grants, nil
+}
+
+// Helper functions
+
+// lockOwners locks the organization's owner rows and returns their user IDs.
+// Every change that can remove an owner takes these locks first, so
+// concurrent demotions and removals are serialized and each sees the owners
+// left by the last one to commit.
+func lockOwners(ctx context.Context, tx *sqlx.Tx, orgID string) ([]string, error) {
+	var owners []string
+	query := `
+		SELECT user_id FROM organization_members
+		WHERE organization_id = $1 AND role = 'owner'
+		ORDER BY user_id
+		FOR UPDATE
+	`
+	err := tx.SelectContext(ctx, &owners, query, orgID)
+	return owners, err
+}
+
+const (
+	// ownerTrigger is the constraint the deferred owner trigger reports
+	ownerTrigger   = "organization_members_require_owner"
+	checkViolation = "23514"
+)
+
+// ownerViolation maps the deferred owner trigger's error to ErrLastOwner.
+func ownerViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == checkViolation && pgErr.ConstraintName == ownerTrigger {
+		return ErrLastOwner
+	}
+	return err
+}
+
+// getter is the pool or a transaction, for queries that run in either.
+type getter interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// checkSlugHistory fails with ErrSlugExists when slug is a former slug of an
+// organization other than orgID, since it still redirects there.
+func checkSlugHistory(ctx context.Context, q getter, slug, orgID string) error {
+	var owner string
+	err := q.GetContext(ctx, &owner, `SELECT organization_id FROM organization_slug_history WHERE slug = $1`, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owner != orgID {
+		return ErrSlugExists
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(bytes), nil
+}
+
+// EmailDomain returns the lowercased domain part of an email address.
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}$`)
+
+// NormalizeDomain lowercases a domain, strips a leading "@" and reports
+// whether the result looks like a valid hostname.
+func NormalizeDomain(domain string) (string, bool) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "@")
+	return domain, domainPattern.MatchString(domain)
 }
 
-func GenerateSlug(name, userID string) string {
-	slug := strings.ToLower(name)
-	reg := regexp.MustCompile(`[^a-z0-9]+`)
-	slug = reg.ReplaceAllString(slug, "-")
+// GenerateSlug derives a default slug from an organization name. The result
+// passes ValidateSlug unless it is reserved, and leaves room for the numeric
+// suffix CreateWithGeneratedSlug adds when it is taken.
+func GenerateSlug(name string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(name), "-")
 	slug = strings.Trim(slug, "-")
-	if len(slug) > 30 {
-		slug = slug[:30]
+	if len(slug) > maxGeneratedSlug {
+		slug = strings.TrimRight(slug[:maxGeneratedSlug], "-")
 	}
-	// Append part of userID for uniqueness
-	if len(userID) >= 8 {
-		slug = fmt.Sprintf("%s-%s", slug, userID[:8])
+	// Names without enough ASCII letters or digits still need a valid slug
+	if len(slug) < 3 {
+		slug = strings.TrimSuffix("org-"+slug, "-")
 	}
 	return slug
 }
@@ -5,29 +5,82 @@ import (
 )
 
 // RegisterRoutes registers organization routes
-// All routes require authentication (applied at router level)
+// All routes require authentication (applied at router level); routes under
+// /{orgID} declare the permission they require
 func RegisterRoutes(r chi.Router, h *Handler) {
 	// Organization CRUD
 	r.Post("/", h.Create)
 	r.Get("/", h.List)
 	r.Put("/active", h.SetActiveOrg)
+	r.Get("/deleted", h.ListDeleted)
+	r.Get("/transfers", h.MyTransfers)
+	r.With(h.authz.Tenant("")).Get("/current", h.Current)
+
+	// Deleted organizations are invisible to Resolve; Restore checks ownership itself
+	r.Post("/{orgID}/restore", h.Restore)
 
 	r.Route("/{orgID}", func(r chi.Router) {
-		r.Get("/", h.Get)
-		r.Put("/", h.Update)
-		r.Delete("/", h.Delete)
+		// Resolve the org (by ID or slug) and the caller's membership once
+		r.Use(h.authz.Resolve("orgID"))
+
+		r.With(Require(PermOrgRead)).Get("/", h.Get)
+		r.With(Require(PermOrgUpdate)).Patch("/", h.Update)
+		r.With(Require(PermOrgDelete)).Delete("/", h.Delete)
+		r.With(Require(PermOrgRead)).Get("/settings", h.GetSettings)
+		r.With(Require(PermOrgUpdate)).Patch("/settings", h.UpdateSettings)
 		r.Post("/leave", h.Leave)
-		r.Post("/transfer", h.TransferOwnership)
+
+		// Ownership transfer; the recipient may view, decline and accept it
+		r.With(Require(PermOrgTransfer)).Post("/transfer", h.TransferOwnership)
+		r.Get("/transfer", h.GetTransfer)
+		r.Delete("/transfer", h.CancelTransfer)
+		r.Post("/transfer/accept", h.AcceptTransfer)
 
 		// Members
-		r.Get("/members", h.ListMembers)
-		r.Put("/members/{userID}", h.UpdateMemberRole)
-		r.Delete("/members/{userID}", h.RemoveMember)
+		r.With(Require(PermMembersRead)).Get("/members", h.ListMembers)
+		r.With(Require(PermMembersRead)).Get("/members/{userID}", h.GetMember)
+		r.With(Require(PermMembersUpdateRole)).Patch("/members/{userID}", h.UpdateMember)
+		r.With(Require(PermMembersRemove)).Delete("/members/{userID}", h.RemoveMember)
+
+		// Roles
+		r.With(Require(PermOrgRead)).Get("/roles", h.ListRoles)
+		r.With(Require(PermRolesManage)).Post("/roles", h.CreateRole)
+		r.With(Require(PermRolesManage)).Put("/roles/{roleName}", h.UpdateRole)
+		r.With(Require(PermRolesManage)).Delete("/roles/{roleName}", h.DeleteRole)
+
+		// Teams; maintainers manage their own team, checked in the handlers
+		r.With(Require(PermOrgRead)).Get("/teams", h.ListTeams)
+		r.With(Require(PermTeamsManage)).Post("/teams", h.CreateTeam)
+		r.Route("/teams/{teamID}", func(r chi.Router) {
+			r.Use(requireUUID("teamID", "team not found"))
+
+			r.With(Require(PermOrgRead)).Get("/", h.GetTeam)
+			r.Patch("/", h.UpdateTeam)
+			r.With(Require(PermTeamsManage)).Delete("/", h.DeleteTeam)
+			r.With(Require(PermMembersRead)).Get("/members", h.ListTeamMembers)
+			r.Put("/members/{userID}", h.SetTeamMember)
+			r.Delete("/members/{userID}", h.RemoveTeamMember)
+		})
 
 		// Invitations (org-scoped)
-		r.Post("/invitations", h.Invite)
-		r.Get("/invitations", h.ListInvitations)
-		r.Delete("/invitations/{inviteID}", h.CancelInvitation)
+		r.With(Require(PermMembersInvite)).Post("/invitations", h.Invite)
+		r.With(Require(PermInvitationsRead)).Get("/invitations", h.ListInvitations)
+		r.With(Require(PermInvitationsCancel)).Delete("/invitations/{inviteID}", h.CancelInvitation)
+
+		// Join links (org-scoped)
+		r.With(Require(PermJoinLinksManage)).Post("/join-links", h.CreateJoinLink)
+		r.With(Require(PermJoinLinksManage)).Get("/join-links", h.ListJoinLinks)
+		r.With(Require(PermJoinLinksManage)).Delete("/join-links/{linkID}", h.RevokeJoinLink)
+
+		// Verified email domains
+		r.With(Require(PermDomainsManage)).Post("/domains", h.ClaimDomain)
+		r.With(Require(PermDomainsManage)).Get("/domains", h.ListDomains)
+		r.With(Require(PermDomainsManage)).Put("/domains/{domainID}", h.UpdateDomain)
+		r.With(Require(PermDomainsManage)).Delete("/domains/{domainID}", h.DeleteDomain)
+		r.With(Require(PermDomainsManage)).Post("/domains/{domainID}/verify", h.VerifyDomain)
+
+		// Audit log
+		r.With(Require(PermAuditRead)).Get("/audit-log", h.AuditLog)
 	})
 }
 
@@ -38,3 +91,9 @@ func RegisterInvitationRoutes(r chi.Router, h *Handler) {
 	r.Post("/{token}/accept", h.AcceptInvitation)
 	r.Post("/{token}/decline", h.DeclineInvitation)
 }
+
+// RegisterJoinLinkRoutes registers routes for redeeming shareable join links
+func RegisterJoinLinkRoutes(r chi.Router, h *Handler) {
+	r.Get("/{token}", h.PreviewJoinLink)
+	r.Post("/{token}", h.RedeemJoinLink)
+}
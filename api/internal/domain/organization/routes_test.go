@@ -0,0 +1,174 @@
+package organization
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routePermissions is the permission each organization route requires, or ""
+// for routes that check access in their handler.
+var routePermissions = map[string]Permission{
+	"GET /":                          "",
+	"POST /":                         "",
+	"PUT /active":                    "",
+	"GET /current":                   "",
+	"GET /deleted":                   "",
+	"GET /transfers":                 "",
+	"POST /{orgID}/restore":          "",
+	"POST /{orgID}/leave":            "",
+	"GET /{orgID}/transfer":          "",
+	"DELETE /{orgID}/transfer":       "",
+	"POST /{orgID}/transfer/accept":  "",
+	"PATCH /{orgID}/teams/{teamID}/": "",
+	"PUT /{orgID}/teams/{teamID}/members/{userID}":    "",
+	"DELETE /{orgID}/teams/{teamID}/members/{userID}": "",
+
+	"GET /{orgID}/":           PermOrgRead,
+	"PATCH /{orgID}/":         PermOrgUpdate,
+	"DELETE /{orgID}/":        PermOrgDelete,
+	"GET /{orgID}/settings":   PermOrgRead,
+	"PATCH /{orgID}/settings": PermOrgUpdate,
+	"POST /{orgID}/transfer":  PermOrgTransfer,
+
+	"GET /{orgID}/members":             PermMembersRead,
+	"GET /{orgID}/members/{userID}":    PermMembersRead,
+	"PATCH /{orgID}/members/{userID}":  PermMembersUpdateRole,
+	"DELETE /{orgID}/members/{userID}": PermMembersRemove,
+
+	"GET /{orgID}/roles":                  PermOrgRead,
+	"POST /{orgID}/roles":                 PermRolesManage,
+	"PUT /{orgID}/roles/{roleName}":       PermRolesManage,
+	"DELETE /{orgID}/roles/{roleName}":    PermRolesManage,
+	"GET /{orgID}/teams":                  PermOrgRead,
+	"POST /{orgID}/teams":                 PermTeamsManage,
+	"GET /{orgID}/teams/{teamID}/":        PermOrgRead,
+	"DELETE /{orgID}/teams/{teamID}/":     PermTeamsManage,
+	"GET /{orgID}/teams/{teamID}/members": PermMembersRead,
+
+	"POST /{orgID}/invitations":              PermMembersInvite,
+	"GET /{orgID}/invitations":               PermInvitationsRead,
+	"DELETE /{orgID}/invitations/{inviteID}": PermInvitationsCancel,
+
+	"POST /{orgID}/join-links":            PermJoinLinksManage,
+	"GET /{orgID}/join-links":             PermJoinLinksManage,
+	"DELETE /{orgID}/join-links/{linkID}": PermJoinLinksManage,
+
+	"POST /{orgID}/domains":                   PermDomainsManage,
+	"GET /{orgID}/domains":                    PermDomainsManage,
+	"PUT /{orgID}/domains/{domainID}":         PermDomainsManage,
+	"DELETE /{orgID}/domains/{domainID}":      PermDomainsManage,
+	"POST /{orgID}/domains/{domainID}/verify": PermDomainsManage,
+
+	"GET /{orgID}/audit-log": PermAuditRead,
+}
+
+// route is a registered organization route and its Require middleware, if
+// any.
+type route struct {
+	name    string
+	require func(http.Handler) http.Handler
+}
+
+// registeredRoutes walks RegisterRoutes. Require's middleware is the one that
+// turns away a caller without permissions; the others answer before looking
+// at permissions, or need a user or URL params the probe does not carry.
+func registeredRoutes(t *testing.T) []route {
+	t.Helper()
+	r := chi.NewRouter()
+	RegisterRoutes(r, &Handler{authz: NewAuthorizer(nil)})
+
+	var routes []route
+	err := chi.Walk(r, func(method, path string, _ http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		rt := route{name: method + " " + path}
+		for _, mw := range middlewares {
+			if probe(mw, nil) != http.StatusForbidden {
+				continue
+			}
+			if rt.require != nil {
+				t.Errorf("%s: more than one Require", rt.name)
+			}
+			rt.require = mw
+		}
+		routes = append(routes, rt)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	return routes
+}
+
+func TestRoutePermissions(t *testing.T) {
+	custom := []Permission{PermOrgRead, PermMembersRead, PermMembersInvite, PermInvitationsRead}
+	roles := []struct {
+		name  string
+		perms []Permission
+	}{
+		{"owner", RoleOwner.Permissions()},
+		{"admin", RoleAdmin.Permissions()},
+		{"member", RoleMember.Permissions()},
+		{"custom", custom},
+	}
+
+	routes := registeredRoutes(t)
+	seen := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		seen[rt.name] = true
+		want, ok := routePermissions[rt.name]
+		if !ok {
+			t.Errorf("%s: route missing from routePermissions", rt.name)
+			continue
+		}
+		if want == "" {
+			if rt.require != nil {
+				t.Errorf("%s: requires a permission, want none", rt.name)
+			}
+			continue
+		}
+		if rt.require == nil {
+			t.Errorf("%s: requires no permission, want %s", rt.name, want)
+			continue
+		}
+
+		// Exactly want opens the route: it alone is enough, and every other
+		// permission together is not
+		others := slices.DeleteFunc(slices.Clone(RoleOwner.Permissions()), func(p Permission) bool { return p == want })
+		if !allows(rt.require, []Permission{want}) || allows(rt.require, others) {
+			t.Errorf("%s: does not require %s", rt.name, want)
+		}
+
+		for _, role := range roles {
+			if got, wantAllowed := allows(rt.require, role.perms), slices.Contains(role.perms, want); got != wantAllowed {
+				t.Errorf("%s as %s: allowed = %v, want %v", rt.name, role.name, got, wantAllowed)
+			}
+		}
+	}
+
+	for name := range routePermissions {
+		if !seen[name] {
+			t.Errorf("%s: listed in routePermissions but not registered", name)
+		}
+	}
+}
+
+// allows reports whether the middleware lets a caller with perms through.
+func allows(mw func(http.Handler) http.Handler, perms []Permission) bool {
+	return probe(mw, perms) == http.StatusNoContent
+}
+
+// probe runs the middleware for a member holding perms and returns the
+// status it answers with, or 204 if it passes the request on.
+func probe(mw func(http.Handler) http.Handler, perms []Permission) int {
+	oc := &Context{Organization: &Organization{}, Member: &Member{}, Permissions: perms}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(WithContext(req.Context(), oc))
+	rec := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, req)
+	return rec.Code
+}
@@ -0,0 +1,188 @@
+package organization
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"base/api/pkg/request"
+)
+
+// settingsVersion is the current shape of the settings document. Bump it and
+// extend migrate when fields are renamed or reinterpreted.
+const settingsVersion = 1
+
+const (
+	maxDescriptionLength = 500
+	maxURLLength         = 2048
+	maxAllowedDomains    = 50
+	minInvitationExpiry  = 1
+	maxInvitationExpiry  = 30
+)
+
+// Settings is the organization's profile and policy document, stored as
+// versioned JSONB on the organizations row.
+type Settings struct {
+	Version              int      `json:"version"`
+	LogoURL              string   `json:"logo_url"`
+	Description          string   `json:"description"`
+	Website              string   `json:"website"`
+	DefaultMemberRole    Role     `json:"default_member_role"`
+	InvitationExpiryDays int      `json:"invitation_expiry_days"`
+	AllowedEmailDomains  []string `json:"allowed_email_domains"`
+	// RequireMFA is recorded for when sign-in supports a second factor;
+	// Google sign-in does not report one today.
+	RequireMFA bool `json:"require_mfa"`
+}
+
+// DefaultSettings returns the settings of an organization that has never
+// changed them.
+func DefaultSettings() Settings {
+	return Settings{
+		Version:              settingsVersion,
+		DefaultMemberRole:    RoleMember,
+		InvitationExpiryDays: invitationExpiryDays,
+		AllowedEmailDomains:  []string{},
+	}
+}
+
+func (s Settings) Value() (driver.Value, error) {
+	s.Version = settingsVersion
+	return json.Marshal(s)
+}
+
+func (s *Settings) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s = DefaultSettings()
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Settings", src)
+	}
+
+	// Start from defaults so fields added after a document was written get
+	// sensible values
+	parsed := DefaultSettings()
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	*s = parsed.migrate()
+	return nil
+}
+
+// migrate upgrades documents written by older versions.
+func (s Settings) migrate() Settings {
+	if s.AllowedEmailDomains == nil {
+		s.AllowedEmailDomains = []string{}
+	}
+	s.Version = settingsVersion
+	return s
+}
+
+// InvitationExpiry is how long email invitations from this organization stay
+// valid.
+func (s Settings) InvitationExpiry() time.Duration {
+	return time.Duration(s.InvitationExpiryDays) * 24 * time.Hour
+}
+
+// AllowsEmail reports whether the email's domain passes the allowed domain
+// list. An empty list allows every domain.
+func (s Settings) AllowsEmail(email string) bool {
+	if len(s.AllowedEmailDomains) == 0 {
+		return true
+	}
+	domain := EmailDomain(email)
+	for _, allowed := range s.AllowedEmailDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the document and normalizes allowed domains in place.
+func (s *Settings) Validate() error {
+	if len(s.Description) > maxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxDescriptionLength)
+	}
+	if s.LogoURL != "" && !isWebURL(s.LogoURL, true) {
+		return errors.New("logo_url must be an https URL")
+	}
+	if s.Website != "" && !isWebURL(s.Website, false) {
+		return errors.New("website must be an http or https URL")
+	}
+	if s.DefaultMemberRole != RoleAdmin && s.DefaultMemberRole != RoleMember {
+		return errors.New("default_member_role must be admin or member")
+	}
+	if s.InvitationExpiryDays < minInvitationExpiry || s.InvitationExpiryDays > maxInvitationExpiry {
+		return fmt.Errorf("invitation_expiry_days must be between %d and %d", minInvitationExpiry, maxInvitationExpiry)
+	}
+	if len(s.AllowedEmailDomains) > maxAllowedDomains {
+		return fmt.Errorf("at most %d allowed email domains", maxAllowedDomains)
+	}
+
+	domains := make([]string, 0, len(s.AllowedEmailDomains))
+	seen := make(map[string]bool)
+	for _, d := range s.AllowedEmailDomains {
+		domain, ok := NormalizeDomain(d)
+		if !ok {
+			return fmt.Errorf("invalid allowed email domain: %s", d)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	s.AllowedEmailDomains = domains
+
+	return nil
+}
+
+// Merge applies an RFC 7396 merge patch to the settings and validates the
+// result. Unknown keys are rejected.
+func (s Settings) Merge(patch json.RawMessage) (Settings, error) {
+	current, err := json.Marshal(s)
+	if err != nil {
+		return s, err
+	}
+	merged, err := request.MergePatch(current, patch)
+	if err != nil {
+		return s, errors.New("settings must be a JSON object")
+	}
+
+	next := DefaultSettings()
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return s, fmt.Errorf("invalid settings: %w", err)
+	}
+	next.Version = settingsVersion
+
+	if err := next.Validate(); err != nil {
+		return s, err
+	}
+	return next, nil
+}
+
+func isWebURL(raw string, httpsOnly bool) bool {
+	if len(raw) > maxURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if httpsOnly {
+		return u.Scheme == "https"
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
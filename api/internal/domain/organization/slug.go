@@ -0,0 +1,62 @@
+package organization
+
+import (
+	"errors"
+	"regexp"
+)
+
+var (
+	ErrSlugInvalid  = errors.New("slug must be 3-40 lowercase letters, digits or hyphens and cannot start or end with a hyphen")
+	ErrSlugReserved = errors.New("slug is reserved")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,38}[a-z0-9]$`)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+const (
+	// maxGeneratedSlug leaves a generated slug room for a numeric suffix
+	maxGeneratedSlug = 30
+	// slugAttempts bounds retries when a generated slug is taken concurrently
+	slugAttempts = 3
+)
+
+// reservedSlugs collide with routes or are likely to be confused with
+// product pages.
+var reservedSlugs = map[string]bool{
+	"active":        true,
+	"admin":         true,
+	"api":           true,
+	"app":           true,
+	"auth":          true,
+	"billing":       true,
+	"current":       true,
+	"dashboard":     true,
+	"deleted":       true,
+	"help":          true,
+	"invitations":   true,
+	"join":          true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"new":           true,
+	"organizations": true,
+	"settings":      true,
+	"signup":        true,
+	"static":        true,
+	"support":       true,
+	"transfers":     true,
+	"www":           true,
+}
+
+// ValidateSlug reports whether slug may be chosen for an organization.
+func ValidateSlug(slug string) error {
+	// UUID-shaped slugs would be mistaken for IDs when resolving routes
+	if !slugPattern.MatchString(slug) || uuidPattern.MatchString(slug) {
+		return ErrSlugInvalid
+	}
+	if reservedSlugs[slug] {
+		return ErrSlugReserved
+	}
+	return nil
+}
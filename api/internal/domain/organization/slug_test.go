@@ -0,0 +1,89 @@
+package organization
+
+import (
+	"strings"
+	"testing"
+
+	"base/api/internal/database/dbtest"
+)
+
+func TestGenerateSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Acme", "acme"},
+		{"Acme, Inc.", "acme-inc"},
+		{"  Hello   World  ", "hello-world"},
+		{"Café Zürich", "caf-z-rich"},
+		{"A", "org-a"},
+		{"日本語", "org"},
+		{"", "org"},
+		{"---", "org"},
+		{"The Quite Long Organization Name Of Doom", "the-quite-long-organization-na"},
+		{"abcdefghij abcdefghij abcdefg xyz", "abcdefghij-abcdefghij-abcdefg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateSlug(tt.name)
+			if got != tt.want {
+				t.Errorf("GenerateSlug(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			if err := ValidateSlug(got); err != nil {
+				t.Errorf("GenerateSlug(%q) = %q, invalid: %v", tt.name, got, err)
+			}
+			if err := ValidateSlug(got + "-123"); err != nil {
+				t.Errorf("GenerateSlug(%q) = %q leaves no room for a suffix: %v", tt.name, got, err)
+			}
+		})
+	}
+}
+
+func TestCreateWithGeneratedSlug(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewRepository(db)
+	ctx := dbtest.Context()
+	userID := dbtest.CreateUser(t, db)
+
+	// Unique per run so earlier runs' organizations do not interfere
+	name := "Slug Test " + userID[:8]
+	base := GenerateSlug(name)
+
+	var slugs []string
+	for range 3 {
+		org, err := repo.CreateWithGeneratedSlug(ctx, name, name, userID)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		t.Cleanup(func() {
+			db.ExecContext(ctx, `DELETE FROM organizations WHERE id = $1`, org.ID)
+		})
+		slugs = append(slugs, org.Slug)
+	}
+
+	want := []string{base, base + "-2", base + "-3"}
+	for i := range want {
+		if slugs[i] != want[i] {
+			t.Errorf("slugs = %v, want %v", slugs, want)
+			break
+		}
+	}
+}
+
+func TestCreateWithGeneratedSlugSkipsReserved(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := NewRepository(db)
+	ctx := dbtest.Context()
+	userID := dbtest.CreateUser(t, db)
+
+	org, err := repo.CreateWithGeneratedSlug(ctx, "Settings", "Settings", userID)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+	if !strings.HasPrefix(org.Slug, "settings-") {
+		t.Errorf("slug = %q, want a numbered variant of the reserved slug", org.Slug)
+	}
+}
@@ -0,0 +1,50 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+)
+
+const (
+	verificationRecordPrefix = "_base-verification."
+	verificationValuePrefix  = "base-verification="
+)
+
+// TXTResolver looks up DNS TXT records. *net.Resolver satisfies it; tests can
+// substitute a fake that returns canned records.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+var _ TXTResolver = (*net.Resolver)(nil)
+
+func verificationRecordName(domain string) string {
+	return verificationRecordPrefix + domain
+}
+
+func verificationRecordValue(token string) string {
+	return verificationValuePrefix + token
+}
+
+func withInstructions(d Domain) DomainWithInstructions {
+	return DomainWithInstructions{
+		Domain:      d,
+		RecordName:  verificationRecordName(d.Domain),
+		RecordValue: verificationRecordValue(d.VerificationToken),
+	}
+}
+
+// checkDomainTXT reports whether the domain publishes the expected verification record.
+func checkDomainTXT(ctx context.Context, resolver TXTResolver, d *Domain) (bool, error) {
+	records, err := resolver.LookupTXT(ctx, verificationRecordName(d.Domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return slices.Contains(records, verificationRecordValue(d.VerificationToken)), nil
+}
@@ -0,0 +1,136 @@
+package organization
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+// fakeResolver serves canned TXT records by name.
+type fakeResolver struct {
+	records map[string][]string
+	err     error
+	lookups []string
+}
+
+func (f *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	f.lookups = append(f.lookups, name)
+	if f.err != nil {
+		return nil, f.err
+	}
+	records, ok := f.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestCheckDomainTXT(t *testing.T) {
+	d := &Domain{Domain: "example.com", VerificationToken: "tok123"}
+	name := "_base-verification.example.com"
+
+	tests := []struct {
+		name     string
+		resolver *fakeResolver
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "record present",
+			resolver: &fakeResolver{records: map[string][]string{name: {"base-verification=tok123"}}},
+			want:     true,
+		},
+		{
+			name: "record among others",
+			resolver: &fakeResolver{records: map[string][]string{
+				name: {"v=spf1 -all", "base-verification=tok123", "google-site-verification=abc"},
+			}},
+			want: true,
+		},
+		{
+			name:     "wrong token",
+			resolver: &fakeResolver{records: map[string][]string{name: {"base-verification=other"}}},
+		},
+		{
+			name:     "bare token",
+			resolver: &fakeResolver{records: map[string][]string{name: {"tok123"}}},
+		},
+		{
+			name:     "record on the apex only",
+			resolver: &fakeResolver{records: map[string][]string{"example.com": {"base-verification=tok123"}}},
+		},
+		{
+			name:     "no records",
+			resolver: &fakeResolver{records: map[string][]string{name: {}}},
+		},
+		{
+			name:     "name does not exist",
+			resolver: &fakeResolver{},
+		},
+		{
+			name:     "lookup failure",
+			resolver: &fakeResolver{err: &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkDomainTXT(context.Background(), tt.resolver, d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkDomainTXT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("checkDomainTXT() = %v, want %v", got, tt.want)
+			}
+			if len(tt.resolver.lookups) != 1 || tt.resolver.lookups[0] != name {
+				t.Errorf("looked up %v, want [%s]", tt.resolver.lookups, name)
+			}
+		})
+	}
+}
+
+func TestWithInstructions(t *testing.T) {
+	got := withInstructions(Domain{Domain: "example.com", VerificationToken: "tok123"})
+	if got.RecordName != "_base-verification.example.com" {
+		t.Errorf("RecordName = %q", got.RecordName)
+	}
+	if got.RecordValue != "base-verification=tok123" {
+		t.Errorf("RecordValue = %q", got.RecordValue)
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	tests := map[string]string{
+		"jane@example.com":      "example.com",
+		"Jane@Example.COM":      "example.com",
+		"jane@mail.example.com": "mail.example.com",
+		"not-an-email":          "",
+	}
+	for email, want := range tests {
+		if got := EmailDomain(email); got != want {
+			t.Errorf("EmailDomain(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"example.com", "example.com", true},
+		{" Example.COM ", "example.com", true},
+		{"@example.com", "example.com", true},
+		{"sub.example.co.uk", "sub.example.co.uk", true},
+		{"localhost", "localhost", false},
+		{"exa mple.com", "exa mple.com", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeDomain(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeDomain(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
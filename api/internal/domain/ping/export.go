@@ -0,0 +1,95 @@
+package ping
+
+import (
+	"context"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"base/api/internal/export"
+)
+
+// maxExportIPs bounds the addresses looked up per export; each costs a query
+const maxExportIPs = 99
+
+// RegisterExporters adds ping records to personal data exports. Pings are
+// anonymous, so they are matched by the IP addresses recorded in the user's
+// audit events.
+func RegisterExporters(reg *export.Registry, repo *Repository) {
+	reg.Register("ping_records", export.ExporterFunc(repo.exportPings))
+}
+
+type pingExport struct {
+	IPs         []string `json:"ips"`
+	PostgresIPs []SeenIP `json:"postgres_ips"`
+	DynamoPings []Ping   `json:"dynamo_pings"`
+}
+
+func (r *Repository) exportPings(ctx context.Context, userID string) (any, error) {
+	out := pingExport{IPs: []string{}, PostgresIPs: []SeenIP{}, DynamoPings: []Ping{}}
+
+	query := `
+		SELECT ip FROM audit_events
+		WHERE actor_id = $1 AND ip IS NOT NULL
+		GROUP BY ip
+		ORDER BY MAX(created_at) DESC
+		LIMIT $2
+	`
+	if err := r.postgres.SelectContext(ctx, &out.IPs, query, userID, maxExportIPs); err != nil {
+		return nil, err
+	}
+	if len(out.IPs) == 0 {
+		return out, nil
+	}
+
+	query = `SELECT ip, num_visits, last_seen FROM seen_ips WHERE ip = ANY($1) ORDER BY last_seen DESC`
+	if err := r.postgres.SelectContext(ctx, &out.PostgresIPs, query, out.IPs); err != nil {
+		return nil, err
+	}
+
+	pings, err := r.getPingsByIPDynamo(ctx, out.IPs)
+	if err != nil {
+		return nil, err
+	}
+	out.DynamoPings = append(out.DynamoPings, pings...)
+	return out, nil
+}
+
+// getPingsByIPDynamo queries the IP index for each address and returns their
+// pings, newest first.
+func (r *Repository) getPingsByIPDynamo(ctx context.Context, ips []string) ([]Ping, error) {
+	var pings []Ping
+	for _, ip := range ips {
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String("pings"),
+			IndexName:              aws.String("ip-timestamp-index"),
+			KeyConditionExpression: aws.String("ip = :ip"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":ip": &types.AttributeValueMemberS{Value: ip},
+			},
+		}
+
+		paginator := dynamodb.NewQueryPaginator(r.dynamo.Client, input)
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range page.Items {
+				var dp DynamoPing
+				if err := attributevalue.UnmarshalMap(item, &dp); err != nil {
+					return nil, err
+				}
+				pings = append(pings, dp.ToPing())
+			}
+		}
+	}
+
+	slices.SortFunc(pings, func(a, b Ping) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	return pings, nil
+}
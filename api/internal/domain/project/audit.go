@@ -0,0 +1,17 @@
+package project
+
+// Audit actions recorded alongside project mutations
+const (
+	ActionProjectCreated    = "project.created"
+	ActionProjectUpdated    = "project.updated"
+	ActionProjectArchived   = "project.archived"
+	ActionProjectUnarchived = "project.unarchived"
+	ActionProjectDeleted    = "project.deleted"
+
+	ActionMemberAdded       = "project.member_added"
+	ActionMemberRoleChanged = "project.member_role_changed"
+	ActionMemberRemoved     = "project.member_removed"
+)
+
+// Audit target types
+const targetProject = "project"
@@ -0,0 +1,36 @@
+package project
+
+import (
+	"context"
+	"time"
+
+	"base/api/internal/export"
+)
+
+// RegisterExporters adds the user's project access to personal data exports.
+func RegisterExporters(reg *export.Registry, repo *Repository) {
+	reg.Register("projects", export.ExporterFunc(repo.exportProjects))
+}
+
+type projectExport struct {
+	OrganizationID string    `json:"organization_id" db:"organization_id"`
+	ProjectID      string    `json:"project_id" db:"project_id"`
+	ProjectName    string    `json:"project_name" db:"project_name"`
+	Role           Role      `json:"role" db:"role"`
+	Creator        bool      `json:"creator" db:"creator"`
+	JoinedAt       time.Time `json:"joined_at" db:"joined_at"`
+}
+
+func (r *Repository) exportProjects(ctx context.Context, userID string) (any, error) {
+	projects := []projectExport{}
+	query := `
+		SELECT p.organization_id, p.id AS project_id, p.name AS project_name, pm.role,
+			   p.created_by IS NOT DISTINCT FROM pm.user_id AS creator, pm.created_at AS joined_at
+		FROM project_members pm
+		JOIN projects p ON p.id = pm.project_id
+		WHERE pm.user_id = $1
+		ORDER BY pm.created_at
+	`
+	err := r.postgres.SelectContext(ctx, &projects, query, userID)
+	return projects, err
+}
@@ -0,0 +1,286 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"base/api/internal/domain/organization"
+	"base/api/pkg/request"
+	"base/api/pkg/response"
+)
+
+const (
+	maxNameLength        = 100
+	maxDescriptionLength = 2000
+)
+
+// Handler serves projects of the organization resolved by
+// organization.Authorizer.Tenant.
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	oc := organization.FromContext(r.Context())
+
+	page, err := ProjectsPage.Parse(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if v := page.Filters["archived"]; v != "" && v != "true" && v != "false" {
+		response.BadRequest(w, "filter[archived] must be true or false")
+		return
+	}
+	if v := page.Filters["visibility"]; v != "" && !Visibility(v).IsValid() {
+		response.BadRequest(w, "filter[visibility] must be organization or private")
+		return
+	}
+
+	all := oc.Can(organization.PermProjectsManage)
+	projects, next, err := h.repo.List(r.Context(), oc.Organization.ID, oc.Member.UserID, all, page)
+	if err != nil {
+		response.InternalError(w, "failed to list projects")
+		return
+	}
+
+	response.Page(w, projects, next)
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	oc := organization.FromContext(r.Context())
+
+	var req CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	name, description := strings.TrimSpace(req.Name), strings.TrimSpace(req.Description)
+	if req.Visibility == "" {
+		req.Visibility = VisibilityOrganization
+	}
+	if err := validate(name, description, req.Visibility); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	project, err := h.repo.Create(r.Context(), oc.Organization.ID, oc.Member.UserID, name, description, req.Visibility)
+	if err != nil {
+		if errors.Is(err, ErrNameExists) {
+			response.BadRequest(w, "a project with this name already exists")
+			return
+		}
+		response.InternalError(w, "failed to create project")
+		return
+	}
+
+	response.Created(w, project)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	response.OK(w, FromContext(r.Context()).Project)
+}
+
+// Update applies a merge patch to the project. Archived projects are read-only.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	pc := FromContext(r.Context())
+
+	var req UpdateProjectRequest
+	if err := request.DecodePatch(r, &req); err != nil {
+		if errors.Is(err, request.ErrUnsupportedMediaType) {
+			response.UnsupportedMediaType(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if req.Name.Null || req.Description.Null || req.Visibility.Null {
+		response.BadRequest(w, "name, description and visibility cannot be null")
+		return
+	}
+	if req.Visibility.Set && pc.Access < AccessAdmin {
+		response.Forbidden(w, "changing visibility requires project admin access")
+		return
+	}
+
+	project, err := h.repo.Update(r.Context(), pc.Project.OrganizationID, pc.Project.ID, req.apply)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "project not found")
+		case errors.Is(err, ErrArchived):
+			response.BadRequest(w, "archived projects cannot be changed - unarchive it first")
+		case errors.Is(err, ErrNameExists):
+			response.BadRequest(w, "a project with this name already exists")
+		case errors.Is(err, errInvalidProject):
+			response.BadRequest(w, strings.TrimPrefix(err.Error(), errInvalidProject.Error()+": "))
+		default:
+			response.InternalError(w, "failed to update project")
+		}
+		return
+	}
+
+	response.OK(w, project)
+}
+
+var errInvalidProject = errors.New("invalid project")
+
+func (req *UpdateProjectRequest) apply(p *Project) error {
+	if p.IsArchived() {
+		return ErrArchived
+	}
+	if req.Name.Set {
+		p.Name = strings.TrimSpace(req.Name.Value)
+	}
+	if req.Description.Set {
+		p.Description = strings.TrimSpace(req.Description.Value)
+	}
+	if req.Visibility.Set {
+		p.Visibility = req.Visibility.Value
+	}
+	if err := validate(p.Name, p.Description, p.Visibility); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidProject, err)
+	}
+	return nil
+}
+
+func validate(name, description string, visibility Visibility) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	if len(description) > maxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxDescriptionLength)
+	}
+	if !visibility.IsValid() {
+		return errors.New("visibility must be organization or private")
+	}
+	return nil
+}
+
+func (h *Handler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+func (h *Handler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *Handler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	pc := FromContext(r.Context())
+
+	project, err := h.repo.SetArchived(r.Context(), pc.Project.OrganizationID, pc.Project.ID, archived)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "project not found")
+			return
+		}
+		response.InternalError(w, "failed to update project")
+		return
+	}
+
+	response.OK(w, project)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	pc := FromContext(r.Context())
+
+	if err := h.repo.Delete(r.Context(), pc.Project.OrganizationID, pc.Project.ID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "project not found")
+			return
+		}
+		response.InternalError(w, "failed to delete project")
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// Member handlers
+
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	pc := FromContext(r.Context())
+
+	members, err := h.repo.GetMembers(r.Context(), pc.Project.OrganizationID, pc.Project.ID)
+	if err != nil {
+		response.InternalError(w, "failed to list project members")
+		return
+	}
+
+	response.OK(w, members)
+}
+
+// SetMember gives an organization member access to the project or changes
+// their project role.
+func (h *Handler) SetMember(w http.ResponseWriter, r *http.Request) {
+	pc := FromContext(r.Context())
+
+	var req SetMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	if !req.Role.IsValid() {
+		response.BadRequest(w, "role must be admin, editor or viewer")
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	if !uuidPattern.MatchString(userID) {
+		response.BadRequest(w, "user is not a member of the organization")
+		return
+	}
+
+	member, err := h.repo.SetMember(r.Context(), pc.Project.OrganizationID, pc.Project.ID, userID, req.Role)
+	if err != nil {
+		if errors.Is(err, ErrNotMember) {
+			response.BadRequest(w, "user is not a member of the organization")
+			return
+		}
+		response.InternalError(w, "failed to update project member")
+		return
+	}
+
+	response.OK(w, member)
+}
+
+// RemoveMember revokes someone's project access. Members may remove
+// themselves; removing others requires project admin access.
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	pc := FromContext(r.Context())
+	userID := chi.URLParam(r, "userID")
+
+	oc := organization.FromContext(r.Context())
+	if userID != oc.Member.UserID && pc.Access < AccessAdmin {
+		response.Forbidden(w, "insufficient permissions")
+		return
+	}
+	if !uuidPattern.MatchString(userID) {
+		response.NotFound(w, "project member not found")
+		return
+	}
+
+	if err := h.repo.RemoveMember(r.Context(), pc.Project.OrganizationID, pc.Project.ID, userID); err != nil {
+		if errors.Is(err, ErrNotMember) {
+			response.NotFound(w, "project member not found")
+			return
+		}
+		response.InternalError(w, "failed to remove project member")
+		return
+	}
+
+	response.NoContent(w)
+}
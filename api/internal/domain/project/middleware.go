@@ -0,0 +1,98 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+
+	"base/api/internal/domain/organization"
+	"base/api/pkg/response"
+)
+
+type contextKey string
+
+const projectContextKey contextKey = "project"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Context is the project a request addresses and the caller's access to it.
+type Context struct {
+	Project *Project
+	Access  Access
+}
+
+// FromContext returns the project context placed by Resolve, or nil.
+func FromContext(ctx context.Context) *Context {
+	if pc, ok := ctx.Value(projectContextKey).(*Context); ok {
+		return pc
+	}
+	return nil
+}
+
+// Resolve is middleware that loads the project named by the URL param within
+// the tenant organization and works out the caller's access: project admin
+// for holders of projects:manage, otherwise their project role, or view access
+// to organization-visible projects. Projects the caller cannot view are
+// reported as not found. Must run after organization.Authorizer.Tenant.
+func (h *Handler) Resolve(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			oc := organization.FromContext(r.Context())
+			if oc == nil {
+				response.InternalError(w, "organization not resolved")
+				return
+			}
+
+			id := chi.URLParam(r, param)
+			if !uuidPattern.MatchString(id) {
+				response.NotFound(w, "project not found")
+				return
+			}
+
+			project, err := h.repo.Get(r.Context(), oc.Organization.ID, id)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					response.NotFound(w, "project not found")
+					return
+				}
+				response.InternalError(w, "failed to get project")
+				return
+			}
+
+			access, err := h.repo.Access(r.Context(), oc, project)
+			if err != nil {
+				response.InternalError(w, "failed to check project access")
+				return
+			}
+			if access == AccessNone {
+				response.NotFound(w, "project not found")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), projectContextKey, &Context{Project: project, Access: access})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Require is middleware that rejects callers with less than the given access
+// to the project resolved earlier in the chain.
+func Require(level Access) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pc := FromContext(r.Context())
+			if pc == nil {
+				response.InternalError(w, "project not resolved")
+				return
+			}
+			if pc.Access < level {
+				response.Forbidden(w, "insufficient project permissions")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
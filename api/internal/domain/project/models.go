@@ -0,0 +1,89 @@
+package project
+
+import (
+	"time"
+
+	"base/api/pkg/request"
+)
+
+type Visibility string
+
+const (
+	// VisibilityOrganization projects can be viewed by every organization member
+	VisibilityOrganization Visibility = "organization"
+	// VisibilityPrivate projects can only be viewed by their members
+	VisibilityPrivate Visibility = "private"
+)
+
+func (v Visibility) IsValid() bool {
+	return v == VisibilityOrganization || v == VisibilityPrivate
+}
+
+// Role is a member's access level within a project.
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+func (r Role) IsValid() bool {
+	return r == RoleAdmin || r == RoleEditor || r == RoleViewer
+}
+
+// Access is what a caller may do with a project.
+type Access int
+
+const (
+	AccessNone Access = iota
+	AccessView
+	AccessEdit
+	AccessAdmin
+)
+
+type Project struct {
+	ID             string     `json:"id" db:"id"`
+	OrganizationID string     `json:"organization_id" db:"organization_id"`
+	Name           string     `json:"name" db:"name"`
+	Description    string     `json:"description" db:"description"`
+	Visibility     Visibility `json:"visibility" db:"visibility"`
+	CreatedBy      *string    `json:"created_by" db:"created_by"`
+	ArchivedAt     *time.Time `json:"archived_at" db:"archived_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (p *Project) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+type Member struct {
+	ProjectID string    `json:"project_id" db:"project_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Role      Role      `json:"role" db:"role"`
+	Email     string    `json:"email" db:"email"`
+	Name      string    `json:"name" db:"name"`
+	Picture   string    `json:"picture" db:"picture"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Request types
+
+type CreateProjectRequest struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+}
+
+// UpdateProjectRequest is a JSON merge patch for a project.
+type UpdateProjectRequest struct {
+	Name        request.Field[string]     `json:"name"`
+	Description request.Field[string]     `json:"description"`
+	Visibility  request.Field[Visibility] `json:"visibility"`
+}
+
+type SetMemberRequest struct {
+	Role Role `json:"role"`
+}
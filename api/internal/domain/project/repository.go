@@ -0,0 +1,439 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"base/api/internal/audit"
+	"base/api/internal/database"
+	"base/api/internal/domain/organization"
+	"base/api/pkg/pagination"
+)
+
+var (
+	ErrNotFound   = errors.New("project not found")
+	ErrNameExists = errors.New("project name already exists")
+	ErrNotMember  = errors.New("user is not a member")
+	ErrArchived   = errors.New("project is archived")
+)
+
+const projectColumns = `p.id, p.organization_id, p.name, p.description, p.visibility, p.created_by,
+	p.archived_at, p.created_at, p.updated_at`
+
+const memberColumns = `pm.project_id, pm.user_id, pm.role, u.email, u.name, u.picture, pm.created_at, pm.updated_at`
+
+type Repository struct {
+	postgres *database.PostgresDB
+}
+
+func NewRepository(postgres *database.PostgresDB) *Repository {
+	return &Repository{postgres: postgres}
+}
+
+// ProjectsPage lists an organization's projects. filter[archived]=true lists
+// archived projects instead of active ones.
+var ProjectsPage = pagination.Spec{
+	Sorts: []pagination.Sort{
+		{Key: "created", Columns: []pagination.Column{{Expr: "p.created_at", Type: "timestamptz"}}, Desc: true},
+		{Key: "name", Columns: []pagination.Column{{Expr: "p.name", Type: "text"}}},
+		{Key: "updated", Columns: []pagination.Column{{Expr: "p.updated_at", Type: "timestamptz"}}, Desc: true},
+	},
+	Filters:      []string{"archived", "visibility"},
+	IDColumn:     "p.id",
+	DefaultLimit: 50,
+	MaxLimit:     200,
+}
+
+// List returns the projects in the organization the user can view. With all
+// set every project is included, otherwise only organization-visible projects
+// and those the user is a member of.
+func (r *Repository) List(ctx context.Context, orgID, userID string, all bool, p pagination.Params) ([]Project, string, error) {
+	args := []any{orgID}
+	where := []string{"p.organization_id = $1"}
+
+	if p.Filters["archived"] == "true" {
+		where = append(where, "p.archived_at IS NOT NULL")
+	} else {
+		where = append(where, "p.archived_at IS NULL")
+	}
+	if visibility := p.Filters["visibility"]; visibility != "" {
+		args = append(args, visibility)
+		where = append(where, fmt.Sprintf("p.visibility = $%d", len(args)))
+	}
+	if !all {
+		args = append(args, userID)
+		where = append(where, fmt.Sprintf(`(p.visibility = 'organization' OR EXISTS (
+			SELECT 1 FROM project_members pm WHERE pm.project_id = p.id AND pm.user_id = $%d
+		))`, len(args)))
+	}
+
+	keyset, args := p.Where(args)
+	order, args := p.OrderBy(args)
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects p
+		WHERE ` + strings.Join(where, " AND ") + ` AND ` + keyset + `
+		` + order
+
+	var projects []Project
+	if err := r.postgres.SelectContext(ctx, &projects, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	projects, next := pagination.Page(p, projects, func(pr Project) ([]any, string) {
+		switch p.Sort.Key {
+		case "name":
+			return []any{pr.Name}, pr.ID
+		case "updated":
+			return []any{pr.UpdatedAt}, pr.ID
+		default:
+			return []any{pr.CreatedAt}, pr.ID
+		}
+	})
+	return projects, next, nil
+}
+
+func (r *Repository) Get(ctx context.Context, orgID, id string) (*Project, error) {
+	var project Project
+	query := `SELECT ` + projectColumns + ` FROM projects p WHERE p.organization_id = $1 AND p.id = $2`
+	err := r.postgres.GetContext(ctx, &project, query, orgID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetRole returns the user's role in the project, or ErrNotMember.
+func (r *Repository) GetRole(ctx context.Context, orgID, projectID, userID string) (Role, error) {
+	var role Role
+	query := `SELECT role FROM project_members WHERE organization_id = $1 AND project_id = $2 AND user_id = $3`
+	err := r.postgres.GetContext(ctx, &role, query, orgID, projectID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+	return role, err
+}
+
+// Access works out the member's access to the project: admin for holders of
+// projects:manage, otherwise their project role, or view access to
+// organization-visible projects.
+func (r *Repository) Access(ctx context.Context, oc *organization.Context, project *Project) (Access, error) {
+	if oc.Can(organization.PermProjectsManage) {
+		return AccessAdmin, nil
+	}
+
+	role, err := r.GetRole(ctx, project.OrganizationID, project.ID, oc.Member.UserID)
+	if err != nil && !errors.Is(err, ErrNotMember) {
+		return AccessNone, err
+	}
+
+	switch role {
+	case RoleAdmin:
+		return AccessAdmin, nil
+	case RoleEditor:
+		return AccessEdit, nil
+	case RoleViewer:
+		return AccessView, nil
+	}
+	if project.Visibility == VisibilityOrganization {
+		return AccessView, nil
+	}
+	return AccessNone, nil
+}
+
+// Create creates a project with its creator as the first project admin.
+func (r *Repository) Create(ctx context.Context, orgID, userID, name, description string, visibility Visibility) (*Project, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var project Project
+	query := `
+		INSERT INTO projects AS p (organization_id, name, description, visibility, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + projectColumns
+	err = tx.GetContext(ctx, &project, query, orgID, name, description, visibility, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrNameExists
+		}
+		return nil, err
+	}
+
+	memberQuery := `
+		INSERT INTO project_members (project_id, organization_id, user_id, role)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err = tx.ExecContext(ctx, memberQuery, project.ID, orgID, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionProjectCreated,
+		TargetType:     targetProject,
+		TargetID:       project.ID,
+		After:          map[string]any{"name": name, "visibility": visibility},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// Update applies fn to the locked project and saves its name, description
+// and visibility.
+func (r *Repository) Update(ctx context.Context, orgID, id string, fn func(*Project) error) (*Project, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before Project
+	beforeQuery := `SELECT ` + projectColumns + ` FROM projects p WHERE p.organization_id = $1 AND p.id = $2 FOR UPDATE`
+	err = tx.GetContext(ctx, &before, beforeQuery, orgID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := before
+	if err = fn(&next); err != nil {
+		return nil, err
+	}
+
+	var project Project
+	query := `
+		UPDATE projects AS p
+		SET name = $2, description = $3, visibility = $4, updated_at = NOW()
+		WHERE p.id = $1
+		RETURNING ` + projectColumns
+	err = tx.GetContext(ctx, &project, query, id, next.Name, next.Description, next.Visibility)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrNameExists
+		}
+		return nil, err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionProjectUpdated,
+		TargetType:     targetProject,
+		TargetID:       id,
+		Before:         map[string]any{"name": before.Name, "description": before.Description, "visibility": before.Visibility},
+		After:          map[string]any{"name": project.Name, "description": project.Description, "visibility": project.Visibility},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// SetArchived archives or restores a project. Archiving an archived project
+// keeps its original archived_at.
+func (r *Repository) SetArchived(ctx context.Context, orgID, id string, archived bool) (*Project, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var project Project
+	query := `
+		UPDATE projects AS p
+		SET archived_at = CASE WHEN $3 THEN COALESCE(p.archived_at, NOW()) END, updated_at = NOW()
+		WHERE p.organization_id = $1 AND p.id = $2
+		RETURNING ` + projectColumns
+	err = tx.GetContext(ctx, &project, query, orgID, id, archived)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	action := ActionProjectArchived
+	if !archived {
+		action = ActionProjectUnarchived
+	}
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         action,
+		TargetType:     targetProject,
+		TargetID:       id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, orgID, id string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var name string
+	query := `DELETE FROM projects WHERE organization_id = $1 AND id = $2 RETURNING name`
+	err = tx.GetContext(ctx, &name, query, orgID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionProjectDeleted,
+		TargetType:     targetProject,
+		TargetID:       id,
+		Before:         map[string]any{"name": name},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Member operations
+
+func (r *Repository) GetMembers(ctx context.Context, orgID, projectID string) ([]Member, error) {
+	members := []Member{}
+	query := `
+		SELECT ` + memberColumns + `
+		FROM project_members pm
+		JOIN users u ON pm.user_id = u.id
+		WHERE pm.organization_id = $1 AND pm.project_id = $2
+		ORDER BY pm.role, u.name
+	`
+	err := r.postgres.SelectContext(ctx, &members, query, orgID, projectID)
+	return members, err
+}
+
+// SetMember gives an organization member access to the project or changes
+// their role. It returns ErrNotMember when the user is not in the
+// organization.
+func (r *Repository) SetMember(ctx context.Context, orgID, projectID, userID string, role Role) (*Member, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before Role
+	beforeQuery := `SELECT role FROM project_members WHERE project_id = $1 AND user_id = $2 FOR UPDATE`
+	err = tx.GetContext(ctx, &before, beforeQuery, projectID, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if before != role {
+		query := `
+			INSERT INTO project_members (project_id, organization_id, user_id, role)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = NOW()
+		`
+		if _, err = tx.ExecContext(ctx, query, projectID, orgID, userID, role); err != nil {
+			// The caller has loaded the project, so the failing reference is
+			// the organization membership
+			if strings.Contains(err.Error(), "foreign key") {
+				return nil, ErrNotMember
+			}
+			return nil, err
+		}
+
+		entry := audit.Entry{
+			OrganizationID: orgID,
+			Action:         ActionMemberAdded,
+			TargetType:     targetProject,
+			TargetID:       projectID,
+			After:          map[string]any{"user_id": userID, "role": role},
+		}
+		if before != "" {
+			entry.Action = ActionMemberRoleChanged
+			entry.Before = map[string]any{"user_id": userID, "role": before}
+		}
+		if err = audit.Record(ctx, tx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	var member Member
+	memberQuery := `
+		SELECT ` + memberColumns + `
+		FROM project_members pm
+		JOIN users u ON pm.user_id = u.id
+		WHERE pm.project_id = $1 AND pm.user_id = $2
+	`
+	if err = tx.GetContext(ctx, &member, memberQuery, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *Repository) RemoveMember(ctx context.Context, orgID, projectID, userID string) error {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var role Role
+	query := `
+		DELETE FROM project_members
+		WHERE organization_id = $1 AND project_id = $2 AND user_id = $3
+		RETURNING role
+	`
+	err = tx.GetContext(ctx, &role, query, orgID, projectID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+	if err != nil {
+		return err
+	}
+
+	err = audit.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         ActionMemberRemoved,
+		TargetType:     targetProject,
+		TargetID:       projectID,
+		Before:         map[string]any{"user_id": userID, "role": role},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
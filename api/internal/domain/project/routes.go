@@ -0,0 +1,30 @@
+package project
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"base/api/internal/domain/organization"
+)
+
+// RegisterRoutes registers project routes. They act in the current tenant
+// organization, so the router must apply RequireAuth and
+// organization.Authorizer.Tenant first.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.With(organization.Require(organization.PermOrgRead)).Get("/", h.List)
+	r.With(organization.Require(organization.PermProjectsCreate)).Post("/", h.Create)
+
+	r.Route("/{projectID}", func(r chi.Router) {
+		r.Use(h.Resolve("projectID"))
+
+		r.Get("/", h.Get)
+		r.With(Require(AccessEdit)).Patch("/", h.Update)
+		r.With(Require(AccessAdmin)).Delete("/", h.Delete)
+		r.With(Require(AccessAdmin)).Post("/archive", h.Archive)
+		r.With(Require(AccessAdmin)).Post("/unarchive", h.Unarchive)
+
+		// Members
+		r.Get("/members", h.ListMembers)
+		r.With(Require(AccessAdmin)).Put("/members/{userID}", h.SetMember)
+		r.Delete("/members/{userID}", h.RemoveMember)
+	})
+}
@@ -0,0 +1,20 @@
+package user
+
+import "context"
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// FromContext returns the authenticated user placed by RequireAuth, or nil.
+func FromContext(ctx context.Context) *User {
+	if usr, ok := ctx.Value(userContextKey).(*User); ok {
+		return usr
+	}
+	return nil
+}
+
+// WithContext returns a copy of ctx carrying the authenticated user.
+func WithContext(ctx context.Context, usr *User) context.Context {
+	return context.WithValue(ctx, userContextKey, usr)
+}
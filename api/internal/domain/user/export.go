@@ -0,0 +1,24 @@
+package user
+
+import (
+	"context"
+
+	"base/api/internal/export"
+)
+
+// RegisterExporters adds the user's profile to personal data exports.
+func RegisterExporters(reg *export.Registry, repo *Repository) {
+	reg.Register("profile", export.ExporterFunc(repo.exportProfile))
+}
+
+func (r *Repository) exportProfile(ctx context.Context, userID string) (any, error) {
+	usr, err := r.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// The Google ID is hidden from API responses but is the user's data
+	return struct {
+		*User
+		GoogleID string `json:"google_id,omitempty"`
+	}{usr, usr.GoogleID}, nil
+}
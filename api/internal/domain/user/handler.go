@@ -0,0 +1,156 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"base/api/pkg/request"
+	"base/api/pkg/response"
+)
+
+const (
+	maxNameLength = 100
+	maxURLLength  = 2048
+)
+
+var (
+	// BCP 47 tags limited to language, optional script and optional region,
+	// e.g. "en", "pt-BR", "zh-Hant-TW"
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+	uuidPattern   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	response.OK(w, FromContext(r.Context()))
+}
+
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	usr := FromContext(r.Context())
+
+	var req UpdateProfileRequest
+	if err := request.DecodePatch(r, &req); err != nil {
+		if errors.Is(err, request.ErrUnsupportedMediaType) {
+			response.UnsupportedMediaType(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if req.Name.Null || req.Timezone.Null || req.Locale.Null || req.SyncGoogleProfile.Null {
+		response.BadRequest(w, "name, timezone, locale and sync_google_profile cannot be null")
+		return
+	}
+
+	updated, err := h.repo.Update(r.Context(), usr.ID, req.apply)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			response.NotFound(w, "user not found")
+		case errors.Is(err, errInvalidProfile):
+			response.BadRequest(w, strings.TrimPrefix(err.Error(), errInvalidProfile.Error()+": "))
+		default:
+			response.InternalError(w, "failed to update profile")
+		}
+		return
+	}
+
+	response.OK(w, updated)
+}
+
+// GetProfile returns another user's public profile. Only users who share an
+// organization with the caller are visible.
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	usr := FromContext(r.Context())
+	userID := chi.URLParam(r, "userID")
+	if !uuidPattern.MatchString(userID) {
+		response.NotFound(w, "user not found")
+		return
+	}
+
+	profile, err := h.repo.GetProfile(r.Context(), usr.ID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(w, "user not found")
+			return
+		}
+		response.InternalError(w, "failed to get user")
+		return
+	}
+
+	response.OK(w, profile)
+}
+
+var errInvalidProfile = errors.New("invalid profile")
+
+func (req *UpdateProfileRequest) apply(u *User) error {
+	if req.Name.Set {
+		u.Name = strings.TrimSpace(req.Name.Value)
+	}
+	if req.Picture.Set {
+		u.Picture = strings.TrimSpace(req.Picture.Value)
+	}
+	if req.Timezone.Set {
+		u.Timezone = req.Timezone.Value
+	}
+	if req.Locale.Set {
+		u.Locale = req.Locale.Value
+	}
+
+	// Editing the fields Google provides stops sign-in from overwriting them,
+	// unless the same patch opts back in
+	if req.Name.Set || req.Picture.Set {
+		u.SyncGoogleProfile = false
+	}
+	if req.SyncGoogleProfile.Set {
+		u.SyncGoogleProfile = req.SyncGoogleProfile.Value
+	}
+
+	if err := validateProfile(u); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidProfile, err)
+	}
+	return nil
+}
+
+func validateProfile(u *User) error {
+	if u.Name == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(u.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	if u.Picture != "" && !isHTTPSURL(u.Picture) {
+		return errors.New("picture must be an https URL")
+	}
+	// "Local" names the server's zone, not one the user can meaningfully pick
+	if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "" || u.Timezone == "Local" {
+		return errors.New("timezone must be an IANA time zone such as Europe/Berlin")
+	}
+	if !localePattern.MatchString(u.Locale) {
+		return errors.New("locale must be a language tag such as en or pt-BR")
+	}
+	return nil
+}
+
+func isHTTPSURL(raw string) bool {
+	if len(raw) > maxURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
@@ -1,13 +1,44 @@
 package user
 
-import "time"
+import (
+	"time"
+
+	"base/api/pkg/request"
+)
 
 type User struct {
-	ID        string    `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Name      string    `json:"name" db:"name"`
-	Picture   string    `json:"picture,omitempty" db:"picture"`
-	GoogleID  string    `json:"-" db:"google_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID       string `json:"id" db:"id"`
+	Email    string `json:"email" db:"email"`
+	Name     string `json:"name" db:"name"`
+	Picture  string `json:"picture,omitempty" db:"picture"`
+	Timezone string `json:"timezone" db:"timezone"`
+	Locale   string `json:"locale" db:"locale"`
+	// SyncGoogleProfile lets Google sign-in refresh Name and Picture. It is
+	// cleared when the user edits either field.
+	SyncGoogleProfile bool      `json:"sync_google_profile" db:"sync_google_profile"`
+	GoogleID          string    `json:"-" db:"google_id"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is set while the account waits out its deletion grace period
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// Profile is what other members of a shared organization can see.
+type Profile struct {
+	ID       string `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Picture  string `json:"picture,omitempty" db:"picture"`
+	Timezone string `json:"timezone" db:"timezone"`
+}
+
+// Request types
+
+// UpdateProfileRequest is a JSON merge patch for the current user. Sending
+// null for picture removes it.
+type UpdateProfileRequest struct {
+	Name              request.Field[string] `json:"name"`
+	Picture           request.Field[string] `json:"picture"`
+	Timezone          request.Field[string] `json:"timezone"`
+	Locale            request.Field[string] `json:"locale"`
+	SyncGoogleProfile request.Field[bool]   `json:"sync_google_profile"`
 }
@@ -10,6 +10,8 @@ import (
 
 var ErrNotFound = errors.New("user not found")
 
+const userColumns = `id, email, name, picture, timezone, locale, sync_google_profile, google_id, created_at, updated_at, deleted_at`
+
 type Repository struct {
 	postgres *database.PostgresDB
 }
@@ -20,7 +22,7 @@ func NewRepository(postgres *database.PostgresDB) *Repository {
 
 func (r *Repository) GetByID(ctx context.Context, id string) (*User, error) {
 	var user User
-	query := `SELECT id, email, name, picture, google_id, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 	err := r.postgres.GetContext(ctx, &user, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
@@ -30,7 +32,7 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*User, error) {
 
 func (r *Repository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	query := `SELECT id, email, name, picture, google_id, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 	err := r.postgres.GetContext(ctx, &user, query, email)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
@@ -40,7 +42,7 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*User, error
 
 func (r *Repository) GetByGoogleID(ctx context.Context, googleID string) (*User, error) {
 	var user User
-	query := `SELECT id, email, name, picture, google_id, created_at, updated_at FROM users WHERE google_id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE google_id = $1`
 	err := r.postgres.GetContext(ctx, &user, query, googleID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
@@ -48,18 +50,81 @@ func (r *Repository) GetByGoogleID(ctx context.Context, googleID string) (*User,
 	return &user, err
 }
 
+// Upsert creates or refreshes a user from their Google profile. Name and
+// picture are only refreshed while the user has not edited them.
 func (r *Repository) Upsert(ctx context.Context, email, name, picture, googleID string) (*User, error) {
 	var user User
 	query := `
 		INSERT INTO users (email, name, picture, google_id)
 		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (email) DO UPDATE SET
-			name = EXCLUDED.name,
-			picture = EXCLUDED.picture,
+			name = CASE WHEN users.sync_google_profile THEN EXCLUDED.name ELSE users.name END,
+			picture = CASE WHEN users.sync_google_profile THEN EXCLUDED.picture ELSE users.picture END,
 			google_id = COALESCE(users.google_id, EXCLUDED.google_id),
 			updated_at = NOW()
-		RETURNING id, email, name, picture, google_id, created_at, updated_at
-	`
+		RETURNING ` + userColumns
 	err := r.postgres.GetContext(ctx, &user, query, email, name, picture, googleID)
 	return &user, err
 }
+
+// Update applies fn to the user's current profile and saves the result.
+func (r *Repository) Update(ctx context.Context, id string, fn func(*User) error) (*User, error) {
+	tx, err := r.postgres.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before User
+	err = tx.GetContext(ctx, &before, `SELECT `+userColumns+` FROM users WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := before
+	if err = fn(&next); err != nil {
+		return nil, err
+	}
+
+	var user User
+	query := `
+		UPDATE users
+		SET name = $2, picture = $3, timezone = $4, locale = $5, sync_google_profile = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns
+	err = tx.GetContext(ctx, &user, query, id, next.Name, next.Picture, next.Timezone, next.Locale, next.SyncGoogleProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetProfile returns the public profile of a user the viewer shares an active
+// organization with. Anyone else is reported as not found.
+func (r *Repository) GetProfile(ctx context.Context, viewerID, id string) (*Profile, error) {
+	var profile Profile
+	query := `
+		SELECT u.id, u.name, u.picture, u.timezone
+		FROM users u
+		WHERE u.id = $2 AND u.deleted_at IS NULL
+		AND (u.id = $1 OR EXISTS (
+			SELECT 1
+			FROM organization_members viewer
+			JOIN organization_members target ON target.organization_id = viewer.organization_id
+			JOIN organizations o ON o.id = viewer.organization_id
+			WHERE viewer.user_id = $1 AND target.user_id = u.id AND o.deleted_at IS NULL
+		))
+	`
+	err := r.postgres.GetContext(ctx, &profile, query, viewerID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &profile, err
+}
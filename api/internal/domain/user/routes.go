@@ -0,0 +1,13 @@
+package user
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers user profile routes
+// All routes require authentication (applied at router level)
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/me", h.Me)
+	r.Patch("/me", h.UpdateMe)
+	r.Get("/{userID}", h.GetProfile)
+}
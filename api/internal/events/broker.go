@@ -0,0 +1,262 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"base/api/internal/database"
+)
+
+const (
+	channel   = "events"
+	streamKey = "events:stream"
+	// bufferLength bounds the resume buffer; clients further behind reload
+	bufferLength = 1000
+	// subscriberBuffer is how far a client may fall behind before it is
+	// disconnected to resume from the buffer
+	subscriberBuffer = 64
+)
+
+var ErrClosed = errors.New("event broker closed")
+
+// publishScript appends the event to the resume buffer and announces it with
+// the ID Redis assigned, in one step so live delivery and replay agree.
+var publishScript = redis.NewScript(`
+local id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[1], '*', 'event', ARGV[2])
+redis.call('PUBLISH', ARGV[3], id .. ' ' .. ARGV[2])
+return id
+`)
+
+// Broker publishes events to every API instance and delivers them to the
+// clients subscribed on this one.
+type Broker struct {
+	redis  *database.RedisDB
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewBroker(redis *database.RedisDB, logger *slog.Logger) *Broker {
+	return &Broker{
+		redis:  redis,
+		logger: logger,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends ev to its audience on every instance. Events are best effort:
+// a failure is logged rather than failing the change that caused it.
+func (b *Broker) Publish(ctx context.Context, ev Event) {
+	data, err := json.Marshal(ev)
+	if err == nil {
+		err = publishScript.Run(ctx, b.redis.Client, []string{streamKey}, bufferLength, data, channel).Err()
+	}
+	if err != nil {
+		b.logger.ErrorContext(ctx, "failed to publish event", "type", ev.Type, "error", err)
+	}
+}
+
+// Run relays published events to local subscribers until ctx is cancelled.
+func (b *Broker) Run(ctx context.Context) {
+	pubsub := b.redis.Client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			id, data, _ := strings.Cut(msg.Payload, " ")
+			ev, err := decode(id, []byte(data))
+			if err != nil {
+				b.logger.Warn("failed to decode event", "error", err)
+				continue
+			}
+			b.dispatch(ev)
+		}
+	}
+}
+
+func (b *Broker) dispatch(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.accept(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			// Too far behind; it resumes from the buffer when it reconnects
+			delete(b.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// Subscribe registers a client of userID, who is a member of orgIDs.
+func (b *Broker) Subscribe(userID, email string, orgIDs []string) (*Subscription, error) {
+	sub := &Subscription{
+		userID: userID,
+		email:  email,
+		orgs:   make(map[string]bool, len(orgIDs)),
+		events: make(chan Event, subscriberBuffer),
+		done:   make(chan struct{}),
+	}
+	for _, id := range orgIDs {
+		sub.orgs[id] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, sub)
+	sub.close()
+}
+
+// Close ends every subscription so open streams finish and the server can
+// shut down. New subscriptions are refused.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		sub.close()
+	}
+}
+
+// Replay returns the buffered events after lastID that sub may see. It
+// reports false when the buffer no longer reaches back to lastID.
+func (b *Broker) Replay(ctx context.Context, sub *Subscription, lastID string) ([]Event, bool, error) {
+	oldest, err := b.redis.Client.XRangeN(ctx, streamKey, "-", "+", 1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(oldest) == 0 || compareIDs(oldest[0].ID, lastID) > 0 {
+		return nil, false, nil
+	}
+
+	msgs, err := b.redis.Client.XRange(ctx, streamKey, "("+lastID, "+").Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var missed []Event
+	for _, msg := range msgs {
+		data, _ := msg.Values["event"].(string)
+		ev, err := decode(msg.ID, []byte(data))
+		if err != nil {
+			b.logger.WarnContext(ctx, "failed to decode buffered event", "id", msg.ID, "error", err)
+			continue
+		}
+		if sub.matches(ev) {
+			missed = append(missed, ev)
+		}
+	}
+	return missed, true, nil
+}
+
+func decode(id string, data []byte) (Event, error) {
+	if !validID(id) {
+		return Event{}, fmt.Errorf("invalid event id %q", id)
+	}
+	var stored struct {
+		Event
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return Event{}, err
+	}
+	ev := stored.Event
+	ev.ID = id
+	if len(stored.Data) > 0 {
+		ev.Data = stored.Data
+	}
+	return ev, nil
+}
+
+// Subscription is one connected client. It sees events addressed to its user
+// and to the organizations that user belongs to.
+type Subscription struct {
+	userID string
+	email  string
+	events chan Event
+	done   chan struct{}
+
+	mu   sync.Mutex
+	orgs map[string]bool
+}
+
+// Events delivers the subscription's events in order.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription ends.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// accept reports whether the subscription should see ev, first tracking the
+// user joining or leaving organizations.
+func (s *Subscription) accept(ev Event) bool {
+	if ev.UserID == s.userID && ev.OrganizationID != "" {
+		s.mu.Lock()
+		switch ev.Type {
+		case TypeMemberAdded:
+			s.orgs[ev.OrganizationID] = true
+		case TypeMemberRemoved:
+			delete(s.orgs, ev.OrganizationID)
+		}
+		s.mu.Unlock()
+	}
+	return s.matches(ev)
+}
+
+func (s *Subscription) matches(ev Event) bool {
+	if s.addressed(ev) {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orgs[ev.OrganizationID]
+}
+
+// addressed reports whether ev is about the subscription's own user rather
+// than only their organization.
+func (s *Subscription) addressed(ev Event) bool {
+	return ev.UserID == s.userID || (ev.Email != "" && strings.EqualFold(ev.Email, s.email))
+}
+
+// close must be called with the broker's lock held.
+func (s *Subscription) close() {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
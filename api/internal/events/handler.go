@@ -0,0 +1,169 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"base/api/internal/domain/user"
+	"base/api/pkg/response"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	// writeTimeout bounds each write to a stream. The deadline is extended
+	// before every write; the server's WriteTimeout would otherwise end each
+	// stream a few seconds after it opens.
+	writeTimeout = 10 * time.Second
+	// retryInterval is how long clients wait before reconnecting
+	retryInterval = 3 * time.Second
+)
+
+// OrganizationLister finds the organizations a user belongs to.
+type OrganizationLister interface {
+	GetUserOrganizationIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+// Authorizer checks whether a member of an organization holds a permission.
+type Authorizer interface {
+	HasPermission(ctx context.Context, userID, orgID, perm string) (bool, error)
+}
+
+type Handler struct {
+	broker *Broker
+	orgs   OrganizationLister
+	authz  Authorizer
+	logger *slog.Logger
+}
+
+func NewHandler(broker *Broker, orgs OrganizationLister, authz Authorizer, logger *slog.Logger) *Handler {
+	return &Handler{broker: broker, orgs: orgs, authz: authz, logger: logger}
+}
+
+// Stream sends the current user's events as Server-Sent Events. Clients
+// resuming with Last-Event-ID first receive what they missed, or a reset
+// event when the buffer no longer covers the gap.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	usr := user.FromContext(ctx)
+
+	orgIDs, err := h.orgs.GetUserOrganizationIDs(ctx, usr.ID)
+	if err != nil {
+		response.InternalError(w, "failed to list organizations")
+		return
+	}
+
+	sub, err := h.broker.Subscribe(usr.ID, usr.Email, orgIDs)
+	if err != nil {
+		response.Error(w, http.StatusServiceUnavailable, "unavailable", "server is shutting down")
+		return
+	}
+	defer h.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &stream{w: w, rc: http.NewResponseController(w)}
+	if err := s.write(fmt.Sprintf("retry: %d\n\n", retryInterval.Milliseconds())); err != nil {
+		return
+	}
+
+	// Live events already queued may overlap the replay; skip up to the
+	// last event sent
+	lastID := r.Header.Get("Last-Event-ID")
+	if validID(lastID) {
+		missed, complete, err := h.broker.Replay(ctx, sub, lastID)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "failed to replay events", "error", err)
+		}
+		if !complete {
+			missed = []Event{{Type: TypeReset}}
+		}
+		for _, ev := range missed {
+			if !h.permitted(ctx, sub, ev) {
+				continue
+			}
+			if err := s.send(ev); err != nil {
+				return
+			}
+			if ev.ID != "" {
+				lastID = ev.ID
+			}
+		}
+	} else {
+		lastID = ""
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			return
+		case <-heartbeat.C:
+			if err := s.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		case ev := <-sub.Events():
+			if lastID != "" && compareIDs(ev.ID, lastID) <= 0 {
+				continue
+			}
+			if !h.permitted(ctx, sub, ev) {
+				continue
+			}
+			if err := s.send(ev); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// permitted reports whether the subscriber holds the permission ev requires.
+// It is checked as each event is sent, so role changes apply immediately;
+// on failure the event is withheld.
+func (h *Handler) permitted(ctx context.Context, sub *Subscription, ev Event) bool {
+	if ev.Permission == "" || sub.addressed(ev) {
+		return true
+	}
+	ok, err := h.authz.HasPermission(ctx, sub.userID, ev.OrganizationID, ev.Permission)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to check event permission", "type", ev.Type, "error", err)
+		return false
+	}
+	return ok
+}
+
+type stream struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (s *stream) send(ev Event) error {
+	data, err := ev.payload()
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("data: %s\n\n", data)
+	if ev.ID != "" {
+		msg = fmt.Sprintf("id: %s\n", ev.ID) + msg
+	}
+	return s.write(msg)
+}
+
+func (s *stream) write(msg string) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(s.w, msg); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,11 @@
+package events
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the event stream
+// All routes require authentication (applied at router level)
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/stream", h.Stream)
+}
@@ -0,0 +1,76 @@
+// Package export assembles a user's personal data into a downloadable
+// archive. Domain packages register an Exporter for the data they own, so the
+// archive stays complete as domains are added.
+package export
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Exporter returns the personal data a domain holds about a user. The result
+// is marshaled to JSON as one section of the archive.
+type Exporter interface {
+	ExportUserData(ctx context.Context, userID string) (any, error)
+}
+
+// ExporterFunc adapts a function to Exporter.
+type ExporterFunc func(ctx context.Context, userID string) (any, error)
+
+func (f ExporterFunc) ExportUserData(ctx context.Context, userID string) (any, error) {
+	return f(ctx, userID)
+}
+
+// Registry holds the exporters that make up an archive, keyed by section name.
+type Registry struct {
+	mu        sync.RWMutex
+	exporters map[string]Exporter
+}
+
+func NewRegistry() *Registry {
+	return &Registry{exporters: make(map[string]Exporter)}
+}
+
+// Register adds an archive section. Registering a section twice is a
+// programming error and panics.
+func (r *Registry) Register(section string, e Exporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.exporters[section]; ok {
+		panic("export: section registered twice: " + section)
+	}
+	r.exporters[section] = e
+}
+
+// Sections returns the registered section names in order.
+func (r *Registry) Sections() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sections := make([]string, 0, len(r.exporters))
+	for section := range r.exporters {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// Collect runs every exporter for the user. Any failure fails the whole
+// archive, since a partial export would not satisfy the request.
+func (r *Registry) Collect(ctx context.Context, userID string) (map[string]any, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data := make(map[string]any, len(r.exporters))
+	for section, e := range r.exporters {
+		v, err := e.ExportUserData(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", section, err)
+		}
+		data[section] = v
+	}
+	return data, nil
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"base/api/internal/database"
+)
+
+var (
+	ErrNotFound   = errors.New("export not found")
+	ErrInProgress = errors.New("an export is already in progress")
+	ErrNotReady   = errors.New("export is not ready")
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Job is a requested export. The archive itself is only loaded for download.
+type Job struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Status      Status     `json:"status" db:"status"`
+	Error       *string    `json:"error,omitempty" db:"error"`
+	SizeBytes   *int       `json:"size_bytes,omitempty" db:"size_bytes"`
+	StartedAt   *time.Time `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+const jobColumns = `id, user_id, status, error, size_bytes, started_at, completed_at, expires_at, created_at, updated_at`
+
+// maxJobs bounds how many past exports are listed.
+const maxJobs = 20
+
+type Repository struct {
+	postgres *database.PostgresDB
+}
+
+func NewRepository(postgres *database.PostgresDB) *Repository {
+	return &Repository{postgres: postgres}
+}
+
+// Create queues an export for the user. Only one may be pending or running
+// at a time.
+func (r *Repository) Create(ctx context.Context, userID string) (*Job, error) {
+	var job Job
+	query := `INSERT INTO data_exports (user_id) VALUES ($1) RETURNING ` + jobColumns
+	err := r.postgres.GetContext(ctx, &job, query, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrInProgress
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *Repository) Get(ctx context.Context, userID, id string) (*Job, error) {
+	var job Job
+	query := `SELECT ` + jobColumns + ` FROM data_exports WHERE id = $1 AND user_id = $2`
+	err := r.postgres.GetContext(ctx, &job, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &job, err
+}
+
+// List returns the user's most recent exports, newest first.
+func (r *Repository) List(ctx context.Context, userID string) ([]Job, error) {
+	jobs := []Job{}
+	query := `SELECT ` + jobColumns + ` FROM data_exports WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	err := r.postgres.SelectContext(ctx, &jobs, query, userID, maxJobs)
+	return jobs, err
+}
+
+// GetArchive returns a completed, unexpired export's archive.
+func (r *Repository) GetArchive(ctx context.Context, userID, id string) ([]byte, error) {
+	var row struct {
+		Status    Status     `db:"status"`
+		ExpiresAt *time.Time `db:"expires_at"`
+		Archive   []byte     `db:"archive"`
+	}
+	query := `SELECT status, expires_at, archive FROM data_exports WHERE id = $1 AND user_id = $2`
+	err := r.postgres.GetContext(ctx, &row, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.Status != StatusCompleted {
+		return nil, ErrNotReady
+	}
+	if row.ExpiresAt != nil && time.Now().After(*row.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return row.Archive, nil
+}
+
+// Claim takes the oldest pending export, or one whose worker stopped before
+// staleBefore, and marks it running. It returns nil when there is no work.
+// SKIP LOCKED lets every API instance run a worker.
+func (r *Repository) Claim(ctx context.Context, staleBefore time.Time) (*Job, error) {
+	var job Job
+	query := `
+		UPDATE data_exports
+		SET status = 'running', started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'running' AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	err := r.postgres.GetContext(ctx, &job, query, staleBefore)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *Repository) Complete(ctx context.Context, id string, archive []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'completed', archive = $2, size_bytes = $3, completed_at = NOW(), expires_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.postgres.ExecContext(ctx, query, id, archive, len(archive), expiresAt)
+	return err
+}
+
+func (r *Repository) Fail(ctx context.Context, id, message string) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.postgres.ExecContext(ctx, query, id, message)
+	return err
+}
+
+// DeleteExpired removes completed exports past their expiry and failed ones
+// older than the cutoff.
+func (r *Repository) DeleteExpired(ctx context.Context, failedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM data_exports
+		WHERE (status = 'completed' AND expires_at < NOW())
+		   OR (status = 'failed' AND completed_at < $1)
+	`
+	res, err := r.postgres.ExecContext(ctx, query, failedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+const (
+	// Retention is how long a completed archive can be downloaded.
+	Retention = 7 * 24 * time.Hour
+
+	pollInterval = 5 * time.Second
+	jobTimeout   = 5 * time.Minute
+)
+
+// Archive is the document a user downloads.
+type Archive struct {
+	UserID      string         `json:"user_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Sections    map[string]any `json:"sections"`
+}
+
+// Worker processes queued exports.
+type Worker struct {
+	repo     *Repository
+	registry *Registry
+	logger   *slog.Logger
+}
+
+func NewWorker(repo *Repository, registry *Registry, logger *slog.Logger) *Worker {
+	return &Worker{repo: repo, registry: registry, logger: logger}
+}
+
+// Run drains the queue every pollInterval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) drain(ctx context.Context) {
+	if n, err := w.repo.DeleteExpired(ctx, time.Now().Add(-Retention)); err != nil {
+		if ctx.Err() == nil {
+			w.logger.Error("failed to delete expired exports", "error", err)
+		}
+	} else if n > 0 {
+		w.logger.Info("deleted expired exports", "count", n)
+	}
+
+	for ctx.Err() == nil {
+		// A job running for longer than its timeout lost its worker
+		job, err := w.repo.Claim(ctx, time.Now().Add(-2*jobTimeout))
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Error("failed to claim export", "error", err)
+			}
+			return
+		}
+		if job == nil {
+			return
+		}
+		w.process(ctx, job)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	archive, err := w.build(jobCtx, job.UserID)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down; the job is reclaimed once it goes stale
+			return
+		}
+		w.logger.Error("export failed", "export_id", job.ID, "user_id", job.UserID, "error", err)
+		if err := w.repo.Fail(ctx, job.ID, "failed to assemble export"); err != nil {
+			w.logger.Error("failed to mark export failed", "export_id", job.ID, "error", err)
+		}
+		return
+	}
+
+	if err := w.repo.Complete(ctx, job.ID, archive, time.Now().Add(Retention)); err != nil {
+		w.logger.Error("failed to store export", "export_id", job.ID, "error", err)
+		return
+	}
+	w.logger.Info("export completed", "export_id", job.ID, "user_id", job.UserID, "bytes", len(archive))
+}
+
+func (w *Worker) build(ctx context.Context, userID string) ([]byte, error) {
+	sections, err := w.registry.Collect(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(Archive{
+		UserID:      userID,
+		GeneratedAt: time.Now().UTC(),
+		Sections:    sections,
+	}, "", "  ")
+}
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"sync"
+
+	"base/api/internal/domain/user"
+	"base/api/pkg/websocket"
+)
+
+// sendBuffer is how many frames may queue for a client before it is
+// disconnected as too slow
+const sendBuffer = 32
+
+// client is one WebSocket connection.
+type client struct {
+	id   string
+	user *user.User
+	conn *websocket.Conn
+	send chan []byte
+	done chan struct{}
+
+	mu sync.Mutex
+	// rooms maps each joined room to the join request, to re-check access
+	rooms map[string]inbound
+}
+
+func newClient(usr *user.User, conn *websocket.Conn) *client {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &client{
+		id:    hex.EncodeToString(b),
+		user:  usr,
+		conn:  conn,
+		send:  make(chan []byte, sendBuffer),
+		done:  make(chan struct{}),
+		rooms: make(map[string]inbound),
+	}
+}
+
+// enqueue queues a frame without blocking; a client that has fallen too far
+// behind is disconnected.
+func (c *client) enqueue(frame []byte) {
+	select {
+	case c.send <- frame:
+	default:
+		c.conn.Close()
+	}
+}
+
+func (c *client) reply(f outbound) {
+	frame, err := json.Marshal(f)
+	if err != nil {
+		return
+	}
+	c.enqueue(frame)
+}
+
+// add records a joined room, unless the client is already in too many.
+func (c *client) add(room string, join inbound) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.rooms[room]; !ok && len(c.rooms) >= maxRooms {
+		return false
+	}
+	c.rooms[room] = join
+	return true
+}
+
+func (c *client) remove(room string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rooms, room)
+}
+
+func (c *client) in(room string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.rooms[room]
+	return ok
+}
+
+// joined returns a snapshot of the joined rooms.
+func (c *client) joined() map[string]inbound {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.rooms)
+}
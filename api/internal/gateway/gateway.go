@@ -0,0 +1,204 @@
+// Package gateway carries bidirectional messages between WebSocket clients
+// in rooms, one per organization and one per project, and tracks who is
+// present in each. Frames reach clients on every API instance through Redis.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"base/api/internal/database"
+	"base/api/pkg/websocket"
+)
+
+const (
+	channel = "gateway"
+	// writeTimeout bounds each write to a client
+	writeTimeout = 10 * time.Second
+)
+
+var ErrClosed = errors.New("gateway closed")
+
+// Gateway tracks the clients connected to this instance and the rooms they
+// are in.
+type Gateway struct {
+	redis  *database.RedisDB
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	rooms   map[string]map[*client]struct{}
+	closing bool
+	active  sync.WaitGroup
+}
+
+func New(redis *database.RedisDB, logger *slog.Logger) *Gateway {
+	return &Gateway{
+		redis:   redis,
+		logger:  logger,
+		clients: make(map[*client]struct{}),
+		rooms:   make(map[string]map[*client]struct{}),
+	}
+}
+
+// envelope is a frame on its way to a room's clients on every instance.
+type envelope struct {
+	Room string `json:"room"`
+	// Skip is the client that caused the frame, which should not get it back
+	Skip  string          `json:"skip,omitempty"`
+	Frame json.RawMessage `json:"frame"`
+}
+
+// Run relays frames published by every instance to the local clients in
+// their rooms until ctx is cancelled.
+func (g *Gateway) Run(ctx context.Context) {
+	pubsub := g.redis.Client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var env envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				g.logger.Warn("failed to decode gateway frame", "error", err)
+				continue
+			}
+			g.deliver(env)
+		}
+	}
+}
+
+// publish sends f to the room's clients on every instance, except skip.
+func (g *Gateway) publish(ctx context.Context, room, skip string, f outbound) error {
+	frame, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(envelope{Room: room, Skip: skip, Frame: frame})
+	if err != nil {
+		return err
+	}
+	return g.redis.Client.Publish(ctx, channel, data).Err()
+}
+
+func (g *Gateway) deliver(env envelope) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for c := range g.rooms[env.Room] {
+		if c.id != env.Skip {
+			c.enqueue(env.Frame)
+		}
+	}
+}
+
+func (g *Gateway) register(c *client) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closing {
+		return ErrClosed
+	}
+	g.clients[c] = struct{}{}
+	g.active.Add(1)
+	return nil
+}
+
+func (g *Gateway) unregister(c *client) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.clients, c)
+	for room := range c.joined() {
+		g.removeLocked(c, room)
+	}
+	g.active.Done()
+}
+
+// join adds c to the room, marks it present and tells the room. It returns
+// the users now present.
+func (g *Gateway) join(ctx context.Context, c *client, room string) ([]string, error) {
+	g.mu.Lock()
+	if g.rooms[room] == nil {
+		g.rooms[room] = make(map[*client]struct{})
+	}
+	g.rooms[room][c] = struct{}{}
+	g.mu.Unlock()
+
+	if err := g.markPresent(ctx, room, c); err != nil {
+		return nil, err
+	}
+	users, _, err := g.present(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+	return users, g.publish(ctx, room, c.id, outbound{Type: framePresence, Room: room, Data: Presence{Users: users}})
+}
+
+// leave removes c from the room and tells the room.
+func (g *Gateway) leave(ctx context.Context, c *client, room string) error {
+	g.mu.Lock()
+	g.removeLocked(c, room)
+	g.mu.Unlock()
+
+	if err := g.markAbsent(ctx, room, c); err != nil {
+		return err
+	}
+	return g.announce(ctx, room)
+}
+
+func (g *Gateway) removeLocked(c *client, room string) {
+	delete(g.rooms[room], c)
+	if len(g.rooms[room]) == 0 {
+		delete(g.rooms, room)
+	}
+}
+
+// announce tells the room who is present.
+func (g *Gateway) announce(ctx context.Context, room string) error {
+	users, _, err := g.present(ctx, room)
+	if err != nil {
+		return err
+	}
+	return g.publish(ctx, room, "", outbound{Type: framePresence, Room: room, Data: Presence{Users: users}})
+}
+
+// Shutdown asks every client to disconnect and waits for them to go, closing
+// the connections that are still open when ctx ends. New clients are refused.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	g.closing = true
+	clients := make([]*client, 0, len(g.clients))
+	for c := range g.clients {
+		clients = append(clients, c)
+	}
+	g.mu.Unlock()
+
+	for _, c := range clients {
+		c.conn.WriteClose(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		g.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, c := range clients {
+			c.conn.Close()
+		}
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,268 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"base/api/internal/domain/organization"
+	"base/api/internal/domain/project"
+	"base/api/internal/domain/user"
+	"base/api/pkg/response"
+	"base/api/pkg/websocket"
+)
+
+const (
+	// readTimeout disconnects clients that stop answering pings
+	readTimeout = 2 * heartbeatInterval
+	// opTimeout bounds the work done for one frame
+	opTimeout    = 10 * time.Second
+	maxFrameSize = 32 << 10
+	maxRooms     = 20
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var (
+	errRoomNotFound = errors.New("room not found")
+	errNotJoined    = errors.New("room not joined")
+)
+
+type Handler struct {
+	gateway  *Gateway
+	authz    *organization.Authorizer
+	projects *project.Repository
+	origins  []string
+	logger   *slog.Logger
+}
+
+func NewHandler(gateway *Gateway, authz *organization.Authorizer, projects *project.Repository, origins []string, logger *slog.Logger) *Handler {
+	return &Handler{
+		gateway:  gateway,
+		authz:    authz,
+		projects: projects,
+		origins:  origins,
+		logger:   logger,
+	}
+}
+
+// Serve upgrades the request to a WebSocket for the current user. Browsers
+// always send Origin, so checking it keeps other sites from connecting with
+// the user's cookie.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	usr := user.FromContext(r.Context())
+
+	if origin := r.Header.Get("Origin"); origin != "" && !slices.Contains(h.origins, origin) {
+		response.Forbidden(w, "origin not allowed")
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	// Every frame gets its own deadline, including the pongs and close
+	// replies written by the read loop
+	conn.SetWriteTimeout(writeTimeout)
+
+	c := newClient(usr, conn)
+	if err := h.gateway.register(c); err != nil {
+		conn.WriteClose(websocket.CloseGoingAway, "server shutting down")
+		return
+	}
+	defer h.gateway.unregister(c)
+
+	// The request context ends when Serve returns; cleanup outlives it
+	ctx := context.WithoutCancel(r.Context())
+	go h.writeLoop(ctx, c)
+	h.readLoop(ctx, c)
+	close(c.done)
+
+	for room := range c.joined() {
+		h.leave(ctx, c, room)
+	}
+}
+
+func (h *Handler) readLoop(ctx context.Context, c *client) {
+	c.conn.SetReadLimit(maxFrameSize)
+	if err := c.conn.SetReadTimeout(readTimeout); err != nil {
+		return
+	}
+
+	for {
+		op, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var in inbound
+		if op != websocket.TextMessage || json.Unmarshal(data, &in) != nil {
+			c.reply(outbound{Type: frameError, Message: "frames must be JSON objects"})
+			continue
+		}
+
+		opCtx, cancel := context.WithTimeout(ctx, opTimeout)
+		switch in.Type {
+		case frameJoin:
+			h.handleJoin(opCtx, c, in)
+		case frameLeave:
+			h.handleLeave(opCtx, c, in)
+		case frameMessage:
+			h.handleMessage(opCtx, c, in)
+		default:
+			c.reply(outbound{Type: frameError, Ref: in.Ref, Message: "unknown frame type"})
+		}
+		cancel()
+	}
+}
+
+// writeLoop sends queued frames, and on each heartbeat pings the client and
+// renews its presence.
+func (h *Handler) writeLoop(ctx context.Context, c *client) {
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case frame := <-c.send:
+			if err := c.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-heartbeat.C:
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.conn.Close()
+				return
+			}
+			opCtx, cancel := context.WithTimeout(ctx, opTimeout)
+			h.renew(opCtx, c)
+			cancel()
+		}
+	}
+}
+
+func (h *Handler) handleJoin(ctx context.Context, c *client, in inbound) {
+	room, err := h.authorize(ctx, c.user.ID, in)
+	if err != nil {
+		c.reply(outbound{Type: frameError, Ref: in.Ref, Message: h.errorMessage(ctx, err)})
+		return
+	}
+	if !c.add(room, in) {
+		c.reply(outbound{Type: frameError, Ref: in.Ref, Message: "too many rooms joined"})
+		return
+	}
+
+	users, err := h.gateway.join(ctx, c, room)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to join room", "room", room, "error", err)
+		h.leave(ctx, c, room)
+		c.reply(outbound{Type: frameError, Ref: in.Ref, Room: room, Message: "failed to update presence"})
+		return
+	}
+	c.reply(outbound{Type: frameJoined, Ref: in.Ref, Room: room, Data: Presence{Users: users}})
+}
+
+func (h *Handler) handleLeave(ctx context.Context, c *client, in inbound) {
+	if !c.in(in.Room) {
+		c.reply(outbound{Type: frameError, Ref: in.Ref, Room: in.Room, Message: errNotJoined.Error()})
+		return
+	}
+	h.leave(ctx, c, in.Room)
+	c.reply(outbound{Type: frameLeft, Ref: in.Ref, Room: in.Room})
+}
+
+func (h *Handler) handleMessage(ctx context.Context, c *client, in inbound) {
+	if !c.in(in.Room) {
+		c.reply(outbound{Type: frameError, Ref: in.Ref, Room: in.Room, Message: errNotJoined.Error()})
+		return
+	}
+	err := h.gateway.publish(ctx, in.Room, c.id, outbound{Type: frameMessage, Room: in.Room, From: c.user.ID, Data: in.Data})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to publish message", "room", in.Room, "error", err)
+		c.reply(outbound{Type: frameError, Ref: in.Ref, Room: in.Room, Message: "failed to send message"})
+	}
+}
+
+func (h *Handler) leave(ctx context.Context, c *client, room string) {
+	c.remove(room)
+	if err := h.gateway.leave(ctx, c, room); err != nil {
+		h.logger.ErrorContext(ctx, "failed to leave room", "room", room, "error", err)
+	}
+}
+
+// renew re-checks access to each joined room, leaving those the user lost,
+// and extends the client's presence in the rest.
+func (h *Handler) renew(ctx context.Context, c *client) {
+	for room, join := range c.joined() {
+		if _, err := h.authorize(ctx, c.user.ID, join); errors.Is(err, errRoomNotFound) {
+			h.leave(ctx, c, room)
+			c.reply(outbound{Type: frameLeft, Room: room, Message: "access revoked"})
+			continue
+		}
+
+		// The client may have left, or disconnected, since the snapshot
+		if !c.in(room) {
+			continue
+		}
+		if err := h.gateway.markPresent(ctx, room, c); err != nil {
+			h.logger.ErrorContext(ctx, "failed to renew presence", "room", room, "error", err)
+			continue
+		}
+		// Tell the room when connections on a stopped instance expire
+		if _, expired, err := h.gateway.present(ctx, room); err == nil && expired {
+			h.gateway.announce(ctx, room)
+		}
+	}
+}
+
+// authorize returns the room a join request names if the user may enter it:
+// members may join their organization's room, and the room of any project
+// they can view. Everything else is reported as not found.
+func (h *Handler) authorize(ctx context.Context, userID string, in inbound) (string, error) {
+	oc, err := h.authz.Load(ctx, in.OrganizationID, userID)
+	if err != nil {
+		if errors.Is(err, organization.ErrNotFound) || errors.Is(err, organization.ErrNotMember) {
+			return "", errRoomNotFound
+		}
+		return "", err
+	}
+	if in.ProjectID == "" {
+		return orgRoom(oc.Organization.ID), nil
+	}
+	if !uuidPattern.MatchString(in.ProjectID) {
+		return "", errRoomNotFound
+	}
+
+	ctx = organization.WithContext(ctx, oc)
+	p, err := h.projects.Get(ctx, oc.Organization.ID, in.ProjectID)
+	if err != nil {
+		if errors.Is(err, project.ErrNotFound) {
+			return "", errRoomNotFound
+		}
+		return "", err
+	}
+	access, err := h.projects.Access(ctx, oc, p)
+	if err != nil {
+		return "", err
+	}
+	if access == project.AccessNone {
+		return "", errRoomNotFound
+	}
+	return projectRoom(p.ID), nil
+}
+
+func (h *Handler) errorMessage(ctx context.Context, err error) string {
+	if errors.Is(err, errRoomNotFound) {
+		return err.Error()
+	}
+	h.logger.ErrorContext(ctx, "failed to authorize room", "error", err)
+	return "failed to join room"
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	presencePrefix = "presence:"
+	// presenceTTL is how long a connection counts as present without a
+	// heartbeat; it covers instances that stop without cleaning up
+	presenceTTL       = 60 * time.Second
+	heartbeatInterval = 20 * time.Second
+)
+
+// Presence is a sorted set per room of "<user ID>/<client ID>" members
+// scored by when they expire, so each connection is tracked separately.
+func presenceKey(room string) string {
+	return presencePrefix + room
+}
+
+func presenceMember(c *client) string {
+	return c.user.ID + "/" + c.id
+}
+
+func (g *Gateway) markPresent(ctx context.Context, room string, c *client) error {
+	key := presenceKey(room)
+	pipe := g.redis.Client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{
+		Score:  float64(time.Now().Add(presenceTTL).UnixMilli()),
+		Member: presenceMember(c),
+	})
+	pipe.PExpire(ctx, key, presenceTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (g *Gateway) markAbsent(ctx context.Context, room string, c *client) error {
+	return g.redis.Client.ZRem(ctx, presenceKey(room), presenceMember(c)).Err()
+}
+
+// present lists the users connected to the room, dropping connections whose
+// heartbeats stopped. It also reports whether any were dropped.
+func (g *Gateway) present(ctx context.Context, room string) ([]string, bool, error) {
+	key := presenceKey(room)
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	pipe := g.redis.Client.TxPipeline()
+	expired := pipe.ZRemRangeByScore(ctx, key, "-inf", "("+now)
+	members := pipe.ZRange(ctx, key, 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, false, err
+	}
+
+	users := []string{}
+	for _, m := range members.Val() {
+		userID, _, _ := strings.Cut(m, "/")
+		users = append(users, userID)
+	}
+	slices.Sort(users)
+	return slices.Compact(users), expired.Val() > 0, nil
+}
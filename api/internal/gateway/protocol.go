@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"encoding/json"
+)
+
+// Frame types. Clients send join, leave and message; the gateway replies
+// with joined, left and error, and pushes message and presence frames.
+const (
+	frameJoin     = "join"
+	frameLeave    = "leave"
+	frameMessage  = "message"
+	frameJoined   = "joined"
+	frameLeft     = "left"
+	framePresence = "presence"
+	frameError    = "error"
+)
+
+// inbound is a frame sent by a client. Joins name an organization, and
+// optionally one of its projects; other frames name the room joined.
+type inbound struct {
+	Type string `json:"type"`
+	// Ref is echoed in the reply so clients can match it to their request
+	Ref            string          `json:"ref,omitempty"`
+	OrganizationID string          `json:"organization_id,omitempty"`
+	ProjectID      string          `json:"project_id,omitempty"`
+	Room           string          `json:"room,omitempty"`
+	Data           json.RawMessage `json:"data,omitempty"`
+}
+
+type outbound struct {
+	Type string `json:"type"`
+	Ref  string `json:"ref,omitempty"`
+	Room string `json:"room,omitempty"`
+	// From is the user who sent a message
+	From    string `json:"from,omitempty"`
+	Data    any    `json:"data,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Presence lists the users connected to a room.
+type Presence struct {
+	Users []string `json:"users"`
+}
+
+func orgRoom(orgID string) string {
+	return "org:" + orgID
+}
+
+func projectRoom(projectID string) string {
+	return "project:" + projectID
+}
@@ -0,0 +1,11 @@
+package gateway
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the WebSocket endpoint
+// All routes require authentication (applied at router level)
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/", h.Serve)
+}
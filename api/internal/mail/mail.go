@@ -0,0 +1,81 @@
+// Package mail sends transactional email. Without SMTP configuration messages
+// are written to the log instead, which is what development uses.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewSender returns an SMTP sender, or a LogSender when no host is configured.
+func NewSender(cfg Config, logger *slog.Logger) Sender {
+	if cfg.Host == "" {
+		return NewLogSender(logger)
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+// LogSender logs messages instead of delivering them.
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func NewLogSender(logger *slog.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.InfoContext(ctx, "email not sent (no SMTP configured)",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
+
+// SMTPSender delivers plain-text messages through an SMTP relay.
+type SMTPSender struct {
+	cfg Config
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid header in message to %q", msg.To)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	body := "From: " + s.cfg.From + "\r\n" +
+		"To: " + msg.To + "\r\n" +
+		"Subject: " + msg.Subject + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + msg.Body
+
+	addr := net.JoinHostPort(s.cfg.Host, fmt.Sprint(s.cfg.Port))
+	return smtp.SendMail(addr, auth, s.cfg.From, []string{msg.To}, []byte(body))
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"base/api/internal/domain/user"
 	"base/api/internal/session"
@@ -11,36 +12,48 @@ import (
 
 type contextKey string
 
-const (
-	UserContextKey    contextKey = "user"
-	SessionContextKey contextKey = "session"
-)
+const SessionContextKey contextKey = "session"
 
 // RequireAuth is middleware that requires a valid session
 func RequireAuth(sessionStore *session.Store, userRepo *user.Repository) func(http.Handler) http.Handler {
+	return requireSession(sessionStore, userRepo, false)
+}
+
+// RequireAuthOrToken is RequireAuth for clients that cannot send cookies: the
+// session ID may also be sent as a bearer token.
+func RequireAuthOrToken(sessionStore *session.Store, userRepo *user.Repository) func(http.Handler) http.Handler {
+	return requireSession(sessionStore, userRepo, true)
+}
+
+func requireSession(sessionStore *session.Store, userRepo *user.Repository, allowToken bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("session_id")
-			if err != nil {
+			var sessionID string
+			if cookie, err := r.Cookie("session_id"); err == nil {
+				sessionID = cookie.Value
+			} else if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && allowToken {
+				sessionID = token
+			}
+			if sessionID == "" {
 				response.Unauthorized(w, "authentication required")
 				return
 			}
 
-			sess, err := sessionStore.Get(r.Context(), cookie.Value)
+			sess, err := sessionStore.Get(r.Context(), sessionID)
 			if err != nil {
 				response.Unauthorized(w, "invalid or expired session")
 				return
 			}
 
 			usr, err := userRepo.GetByID(r.Context(), sess.UserID)
-			if err != nil {
+			if err != nil || usr.DeletedAt != nil {
 				response.Unauthorized(w, "user not found")
 				return
 			}
 
 			// Add session and user to context
 			ctx := context.WithValue(r.Context(), SessionContextKey, sess)
-			ctx = context.WithValue(ctx, UserContextKey, usr)
+			ctx = user.WithContext(ctx, usr)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -49,10 +62,7 @@ func RequireAuth(sessionStore *session.Store, userRepo *user.Repository) func(ht
 
 // GetUserFromContext retrieves the user from the request context
 func GetUserFromContext(ctx context.Context) *user.User {
-	if usr, ok := ctx.Value(UserContextKey).(*user.User); ok {
-		return usr
-	}
-	return nil
+	return user.FromContext(ctx)
 }
 
 // GetSessionFromContext retrieves the session from the request context
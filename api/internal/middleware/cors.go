@@ -8,6 +8,7 @@ type CORSConfig struct {
 	AllowedOrigins   []string
 	AllowedMethods   []string
 	AllowedHeaders   []string
+	ExposedHeaders   []string
 	AllowCredentials bool
 }
 
@@ -15,7 +16,8 @@ func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match", "X-Organization-ID", "X-Request-ID"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 	}
 }
@@ -51,6 +53,10 @@ func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
 				return
 			}
 
+			if len(cfg.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", joinStrings(cfg.ExposedHeaders))
+			}
+
 			next.ServeHTTP(w, r)
 		})
 	}
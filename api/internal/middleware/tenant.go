@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+
+	"base/api/internal/database"
+)
+
+// CrossTenant lets a request's database access span organizations until it is
+// scoped to one, as organization routes do once they resolve the
+// organization. Without a scope, row-level security hides every tenant row.
+func CrossTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(database.WithoutTenant(r.Context())))
+	})
+}
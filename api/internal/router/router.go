@@ -2,16 +2,25 @@ package router
 
 import (
 	"log/slog"
+	"net"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 
+	"base/api/internal/audit"
 	"base/api/internal/database"
+	"base/api/internal/domain/account"
 	"base/api/internal/domain/auth"
 	"base/api/internal/domain/health"
+	"base/api/internal/domain/notification"
 	"base/api/internal/domain/organization"
 	"base/api/internal/domain/ping"
+	"base/api/internal/domain/project"
 	"base/api/internal/domain/user"
+	"base/api/internal/events"
+	"base/api/internal/export"
+	"base/api/internal/gateway"
+	"base/api/internal/mail"
 	"base/api/internal/middleware"
 	"base/api/internal/observability"
 	"base/api/internal/session"
@@ -23,15 +32,35 @@ type GoogleOAuthConfig struct {
 	RedirectURL  string
 }
 
+// Repositories are created once by main and shared by the handlers and the
+// background workers.
+type Repositories struct {
+	Users         *user.Repository
+	Organizations *organization.Repository
+	Accounts      *account.Repository
+	Notifications *notification.Repository
+	Audit         *audit.Repository
+	Projects      *project.Repository
+	Pings         *ping.Repository
+	Exports       *export.Repository
+}
+
 type Dependencies struct {
-	Logger        *slog.Logger
-	Postgres      *database.PostgresDB
-	Dynamo        *database.DynamoDB
-	Redis         *database.RedisDB
-	Metrics       observability.Metrics
-	SessionSecret string
-	GoogleConfig  GoogleOAuthConfig
-	Environment   string
+	Logger       *slog.Logger
+	Postgres     *database.PostgresDB
+	Dynamo       *database.DynamoDB
+	Redis        *database.RedisDB
+	Repositories Repositories
+	Sessions     *session.Store
+	Mailer       mail.Sender
+	Events       *events.Broker
+	Notifier     *notification.Notifier
+	Gateway      *gateway.Gateway
+	Metrics      observability.Metrics
+	GoogleConfig GoogleOAuthConfig
+	Environment  string
+	// WebSocketOrigins are the browser origins allowed to open WebSockets
+	WebSocketOrigins []string
 }
 
 func New(deps Dependencies) *chi.Mux {
@@ -40,13 +69,14 @@ func New(deps Dependencies) *chi.Mux {
 	// Global middleware
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(audit.Middleware)
 	r.Use(middleware.Recovery(deps.Logger))
 	r.Use(middleware.Logging(deps.Logger))
 	r.Use(middleware.Metrics(deps.Metrics))
 	r.Use(middleware.CORS(middleware.DefaultCORSConfig()))
 
-	// Initialize session store
-	sessionStore := session.NewStore(deps.Redis, deps.SessionSecret)
+	sessionStore := deps.Sessions
+	repos := deps.Repositories
 
 	// Health routes (no auth required)
 	healthHandler := health.NewHandler(deps.Postgres, deps.Dynamo, deps.Redis)
@@ -56,9 +86,8 @@ func New(deps Dependencies) *chi.Mux {
 
 	// API routes
 	r.Route("/api", func(r chi.Router) {
-		// Repositories
-		userRepo := user.NewRepository(deps.Postgres)
-		orgRepo := organization.NewRepository(deps.Postgres)
+		// Sign-in and cross-organization requests are not scoped to a tenant
+		r.Use(middleware.CrossTenant)
 
 		// Auth routes
 		secureCookies := deps.Environment != "development"
@@ -68,23 +97,39 @@ func New(deps Dependencies) *chi.Mux {
 			deps.GoogleConfig.RedirectURL,
 			secureCookies,
 		)
-		authHandler := auth.NewHandler(authConfig, userRepo, orgRepo, sessionStore)
+		authHandler := auth.NewHandler(authConfig, repos.Users, repos.Organizations, repos.Accounts, sessionStore, deps.Events)
 		r.Route("/auth", func(r chi.Router) {
 			auth.RegisterRoutes(r, authHandler)
 		})
 
 		// Ping route
-		pingRepo := ping.NewRepository(deps.Postgres, deps.Dynamo)
-		pingHandler := ping.NewHandler(pingRepo)
+		pingHandler := ping.NewHandler(repos.Pings)
 		r.Route("/ping", func(r chi.Router) {
 			ping.RegisterRoutes(r, pingHandler)
 		})
 
 		// Auth middleware for protected routes
-		authMiddleware := middleware.RequireAuth(sessionStore, userRepo)
+		authMiddleware := middleware.RequireAuth(sessionStore, repos.Users)
+
+		// User profile and account routes (protected)
+		userHandler := user.NewHandler(repos.Users)
+		accountHandler := account.NewHandler(repos.Accounts, repos.Exports, sessionStore, deps.Logger)
+		r.Route("/users", func(r chi.Router) {
+			r.Use(authMiddleware)
+			user.RegisterRoutes(r, userHandler)
+			account.RegisterRoutes(r, accountHandler)
+		})
+
+		// Notification routes (protected)
+		notificationHandler := notification.NewHandler(repos.Notifications, deps.Events)
+		r.Route("/notifications", func(r chi.Router) {
+			r.Use(authMiddleware)
+			notification.RegisterRoutes(r, notificationHandler)
+		})
 
 		// Organization routes (protected)
-		orgHandler := organization.NewHandler(orgRepo, userRepo, sessionStore)
+		orgAuthz := organization.NewAuthorizer(repos.Organizations)
+		orgHandler := organization.NewHandler(repos.Organizations, orgAuthz, repos.Audit, repos.Users, sessionStore, net.DefaultResolver, deps.Notifier, deps.Events, deps.Logger)
 		r.Route("/organizations", func(r chi.Router) {
 			r.Use(authMiddleware)
 			organization.RegisterRoutes(r, orgHandler)
@@ -95,6 +140,35 @@ func New(deps Dependencies) *chi.Mux {
 			r.Use(authMiddleware)
 			organization.RegisterInvitationRoutes(r, orgHandler)
 		})
+
+		// Join link routes (protected)
+		r.Route("/join", func(r chi.Router) {
+			r.Use(authMiddleware)
+			organization.RegisterJoinLinkRoutes(r, orgHandler)
+		})
+
+		// Event stream for the current user and their organizations (protected)
+		eventsHandler := events.NewHandler(deps.Events, repos.Organizations, orgAuthz, deps.Logger)
+		r.Route("/events", func(r chi.Router) {
+			r.Use(authMiddleware)
+			events.RegisterRoutes(r, eventsHandler)
+		})
+
+		// Project routes (protected, scoped to the current organization)
+		projectHandler := project.NewHandler(repos.Projects)
+		r.Route("/projects", func(r chi.Router) {
+			r.Use(authMiddleware)
+			r.Use(orgAuthz.Tenant(""))
+			project.RegisterRoutes(r, projectHandler)
+		})
+
+		// WebSocket gateway; clients that cannot send cookies may send the
+		// session as a bearer token
+		gatewayHandler := gateway.NewHandler(deps.Gateway, orgAuthz, repos.Projects, deps.WebSocketOrigins, deps.Logger)
+		r.Route("/ws", func(r chi.Router) {
+			r.Use(middleware.RequireAuthOrToken(sessionStore, repos.Users))
+			gateway.RegisterRoutes(r, gatewayHandler)
+		})
 	})
 
 	return r
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"base/api/internal/export"
+)
+
+// RegisterExporters adds the user's active sessions to personal data exports.
+func RegisterExporters(reg *export.Registry, store *Store) {
+	reg.Register("sessions", export.ExporterFunc(store.exportSessions))
+}
+
+// sessionExport omits the session ID, which is a bearer credential.
+type sessionExport struct {
+	ActiveOrgID string    `json:"active_org_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (s *Store) exportSessions(ctx context.Context, userID string) (any, error) {
+	sessions := []sessionExport{}
+
+	ids, err := s.redis.Client.SMembers(ctx, userSessionPrefix+userID).Result()
+	if err != nil || len(ids) == 0 {
+		return sessions, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionPrefix + id
+	}
+	values, err := s.redis.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range values {
+		// Expired or deleted sessions are left in the index
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal([]byte(data), &sess); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sessionExport{
+			ActiveOrgID: sess.ActiveOrgID,
+			CreatedAt:   sess.CreatedAt,
+			ExpiresAt:   sess.ExpiresAt,
+		})
+	}
+	return sessions, nil
+}
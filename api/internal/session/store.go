@@ -14,8 +14,9 @@ import (
 )
 
 const (
-	sessionPrefix = "session:"
-	sessionTTL    = 24 * time.Hour
+	sessionPrefix     = "session:"
+	userSessionPrefix = "user_sessions:"
+	sessionTTL        = 24 * time.Hour
 )
 
 var ErrSessionNotFound = errors.New("session not found")
@@ -59,8 +60,14 @@ func (s *Store) Create(ctx context.Context, userID string) (*Session, error) {
 		return nil, err
 	}
 
-	key := sessionPrefix + sessionID
-	if err := s.redis.Client.Set(ctx, key, data, sessionTTL).Err(); err != nil {
+	// Index the session under its user so DeleteAllForUser can find it. The
+	// index lives as long as the user's longest-lived session.
+	userKey := userSessionPrefix + userID
+	pipe := s.redis.Client.TxPipeline()
+	pipe.Set(ctx, sessionPrefix+sessionID, data, sessionTTL)
+	pipe.SAdd(ctx, userKey, sessionID)
+	pipe.Expire(ctx, userKey, sessionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, err
 	}
 
@@ -95,6 +102,22 @@ func (s *Store) Delete(ctx context.Context, sessionID string) error {
 	return s.redis.Client.Del(ctx, key).Err()
 }
 
+// DeleteAllForUser revokes every session belonging to the user.
+func (s *Store) DeleteAllForUser(ctx context.Context, userID string) error {
+	userKey := userSessionPrefix + userID
+	ids, err := s.redis.Client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, sessionPrefix+id)
+	}
+	keys = append(keys, userKey)
+	return s.redis.Client.Del(ctx, keys...).Err()
+}
+
 func (s *Store) Refresh(ctx context.Context, sessionID string) (*Session, error) {
 	session, err := s.Get(ctx, sessionID)
 	if err != nil {
@@ -108,8 +131,10 @@ func (s *Store) Refresh(ctx context.Context, sessionID string) (*Session, error)
 		return nil, err
 	}
 
-	key := sessionPrefix + sessionID
-	if err := s.redis.Client.Set(ctx, key, data, sessionTTL).Err(); err != nil {
+	pipe := s.redis.Client.TxPipeline()
+	pipe.Set(ctx, sessionPrefix+sessionID, data, sessionTTL)
+	pipe.Expire(ctx, userSessionPrefix+session.UserID, sessionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, err
 	}
 
@@ -13,9 +13,21 @@ import (
 	"github.com/lmittmann/tint"
 
 	"base/api/config"
+	"base/api/internal/audit"
 	"base/api/internal/database"
+	"base/api/internal/domain/account"
+	"base/api/internal/domain/notification"
+	"base/api/internal/domain/organization"
+	"base/api/internal/domain/ping"
+	"base/api/internal/domain/project"
+	"base/api/internal/domain/user"
+	"base/api/internal/events"
+	"base/api/internal/export"
+	"base/api/internal/gateway"
+	"base/api/internal/mail"
 	"base/api/internal/observability"
 	"base/api/internal/router"
+	"base/api/internal/session"
 )
 
 func main() {
@@ -71,7 +83,10 @@ func run(logger *slog.Logger) error {
 		return fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 	defer postgres.Close()
-	logger.Info("connected to postgres")
+	if cfg.PostgresRLS {
+		postgres.EnableRowLevelSecurity()
+	}
+	logger.Info("connected to postgres", "row_level_security", cfg.PostgresRLS)
 
 	// Initialize DynamoDB
 	dynamo, err := database.NewDynamo(database.DynamoConfig{
@@ -95,22 +110,86 @@ func run(logger *slog.Logger) error {
 	defer redisDB.Close()
 	logger.Info("connected to redis")
 
-	// Setup router
+	// Email is logged rather than sent until SMTP is configured
+	mailer := mail.NewSender(mail.Config{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.MailFrom,
+	}, logger)
+
+	// Repositories and the session store are shared by the request handlers
+	// and the background workers
+	repos := router.Repositories{
+		Users:         user.NewRepository(postgres),
+		Organizations: organization.NewRepository(postgres),
+		Accounts:      account.NewRepository(postgres),
+		Notifications: notification.NewRepository(postgres),
+		Audit:         audit.NewRepository(postgres),
+		Projects:      project.NewRepository(postgres),
+		Pings:         ping.NewRepository(postgres, dynamo),
+		Exports:       export.NewRepository(postgres),
+	}
+	sessions := session.NewStore(redisDB, cfg.SessionSecret)
+
+	// Real-time events fan out to every instance through Redis
+	broker := events.NewBroker(redisDB, logger)
+
+	// Notifications are delivered in the background and drained on shutdown.
+	// They run in the context of the request that sent them.
+	notifier := notification.NewNotifier(repos.Notifications, repos.Users, mailer, broker, logger)
+
+	// WebSocket rooms and presence, shared across instances through Redis
+	gw := gateway.New(redisDB, logger)
+
+	// Setup router. Requests are scoped to a tenant by its middleware
 	r := router.New(router.Dependencies{
-		Logger:        logger,
-		Postgres:      postgres,
-		Dynamo:        dynamo,
-		Redis:         redisDB,
-		Metrics:       metrics,
-		SessionSecret: cfg.SessionSecret,
+		Logger:       logger,
+		Postgres:     postgres,
+		Dynamo:       dynamo,
+		Redis:        redisDB,
+		Repositories: repos,
+		Sessions:     sessions,
+		Mailer:       mailer,
+		Events:       broker,
+		Notifier:     notifier,
+		Gateway:      gw,
+		Metrics:      metrics,
 		GoogleConfig: router.GoogleOAuthConfig{
 			ClientID:     cfg.GoogleClientID,
 			ClientSecret: cfg.GoogleClientSecret,
 			RedirectURL:  cfg.GoogleRedirectURL,
 		},
-		Environment: cfg.Environment,
+		Environment:      cfg.Environment,
+		WebSocketOrigins: cfg.WebSocketOrigins,
 	})
 
+	// Background workers stop when run returns. They work across
+	// organizations, so every one of them runs in this unscoped context
+	workerCtx, stopWorkers := context.WithCancel(database.WithoutTenant(context.Background()))
+	defer stopWorkers()
+
+	// Permanently remove organizations past their restore window
+	go organization.NewPurger(repos.Organizations, logger).Run(workerCtx)
+
+	// Permanently remove accounts past their deletion grace period
+	go account.NewPurger(repos.Accounts, logger).Run(workerCtx)
+
+	// Assemble personal data exports; each domain contributes its own sections
+	exports := export.NewRegistry()
+	user.RegisterExporters(exports, repos.Users)
+	organization.RegisterExporters(exports, repos.Organizations)
+	notification.RegisterExporters(exports, repos.Notifications)
+	project.RegisterExporters(exports, repos.Projects)
+	session.RegisterExporters(exports, sessions)
+	audit.RegisterExporters(exports, repos.Audit)
+	ping.RegisterExporters(exports, repos.Pings)
+	go export.NewWorker(repos.Exports, exports, logger).Run(workerCtx)
+
+	go broker.Run(workerCtx)
+	go gw.Run(workerCtx)
+
 	// Create server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -120,6 +199,9 @@ func run(logger *slog.Logger) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Shutdown waits for open requests, so end event streams when it starts
+	srv.RegisterOnShutdown(broker.Close)
+
 	// Graceful shutdown
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
@@ -142,6 +224,16 @@ func run(logger *slog.Logger) error {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 
+	// Hijacked WebSocket connections are not tracked by the server
+	if err := gw.Shutdown(ctx); err != nil {
+		return fmt.Errorf("gateway shutdown failed: %w", err)
+	}
+
+	// Requests are done; finish the notifications they started
+	if err := notifier.Shutdown(ctx); err != nil {
+		return fmt.Errorf("notifier shutdown failed: %w", err)
+	}
+
 	logger.Info("server stopped")
 	return nil
 }
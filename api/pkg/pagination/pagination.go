@@ -0,0 +1,264 @@
+// Package pagination parses list query parameters (limit, cursor, sort and
+// filters) against a per-endpoint allowlist and builds keyset pagination
+// clauses for them.
+//
+// Query parameters:
+//
+//	limit=50            page size, bounded by Spec.MaxLimit
+//	cursor=<opaque>     next_cursor from the previous page
+//	sort=name | -name   allowlisted sort key, "-" for descending
+//	filter[role]=admin  allowlisted filters
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// Column is one sort expression and the SQL type its cursor value is cast to.
+// The expression must never be NULL: NULLs fail the keyset comparison, so
+// rows after one would be skipped. Wrap nullable columns in COALESCE.
+type Column struct {
+	Expr string
+	Type string
+}
+
+// Sort is an allowlisted ordering. Rows are ordered by Columns and then by
+// the spec's ID column so every position is unique.
+type Sort struct {
+	Key     string
+	Columns []Column
+	// Desc is the direction used when the client does not choose one
+	Desc bool
+}
+
+// Spec declares what a list endpoint accepts. The first sort is the default.
+type Spec struct {
+	Sorts        []Sort
+	Filters      []string
+	IDColumn     string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Params is a parsed, validated page request.
+type Params struct {
+	Limit   int
+	Sort    Sort
+	Desc    bool
+	Filters map[string]string
+
+	idColumn string
+	after    *cursor
+}
+
+type cursor struct {
+	Sort   string   `json:"s"`
+	Desc   bool     `json:"d"`
+	Values []string `json:"v"`
+	ID     string   `json:"id"`
+}
+
+// Parse reads pagination parameters from the request's query string.
+func (s Spec) Parse(r *http.Request) (Params, error) {
+	q := r.URL.Query()
+
+	p := Params{
+		Limit:    s.DefaultLimit,
+		Sort:     s.Sorts[0],
+		Desc:     s.Sorts[0].Desc,
+		Filters:  make(map[string]string),
+		idColumn: s.IDColumn,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > s.MaxLimit {
+			return Params{}, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLimit, s.MaxLimit)
+		}
+		p.Limit = limit
+	}
+
+	if v := q.Get("sort"); v != "" {
+		key := strings.TrimPrefix(v, "-")
+		sort, ok := s.sort(key)
+		if !ok {
+			return Params{}, fmt.Errorf("%w: %s", ErrInvalidSort, key)
+		}
+		p.Sort = sort
+		p.Desc = strings.HasPrefix(v, "-")
+	}
+
+	for key, values := range q {
+		name, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, "]")
+		if !ok || !s.allowsFilter(name) {
+			return Params{}, fmt.Errorf("%w: %s", ErrInvalidFilter, key)
+		}
+		p.Filters[name] = values[0]
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return Params{}, err
+		}
+		// A cursor is only meaningful for the ordering it was issued under
+		if c.Sort != p.Sort.Key || c.Desc != p.Desc || !c.fits(p.Sort) {
+			return Params{}, ErrInvalidCursor
+		}
+		p.after = c
+	}
+
+	return p, nil
+}
+
+func (s Spec) sort(key string) (Sort, bool) {
+	for _, sort := range s.Sorts {
+		if sort.Key == key {
+			return sort, true
+		}
+	}
+	return Sort{}, false
+}
+
+func (s Spec) allowsFilter(name string) bool {
+	for _, f := range s.Filters {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Where returns the keyset condition that skips rows up to the cursor,
+// appending its arguments to args. It returns "TRUE" on the first page.
+func (p Params) Where(args []any) (string, []any) {
+	if p.after == nil {
+		return "TRUE", args
+	}
+
+	columns := make([]string, 0, len(p.Sort.Columns)+1)
+	placeholders := make([]string, 0, len(p.Sort.Columns)+1)
+	for i, col := range p.Sort.Columns {
+		args = append(args, p.after.Values[i])
+		columns = append(columns, col.Expr)
+		placeholders = append(placeholders, fmt.Sprintf("$%d::%s", len(args), col.Type))
+	}
+	args = append(args, p.after.ID)
+	columns = append(columns, p.idColumn)
+	placeholders = append(placeholders, fmt.Sprintf("$%d::uuid", len(args)))
+
+	op := ">"
+	if p.Desc {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(placeholders, ", ")), args
+}
+
+// OrderBy returns the ORDER BY and LIMIT clauses, fetching one extra row so
+// Page can tell whether another page follows.
+func (p Params) OrderBy(args []any) (string, []any) {
+	dir := "ASC"
+	if p.Desc {
+		dir = "DESC"
+	}
+
+	terms := make([]string, 0, len(p.Sort.Columns)+1)
+	for _, col := range p.Sort.Columns {
+		terms = append(terms, col.Expr+" "+dir)
+	}
+	terms = append(terms, p.idColumn+" "+dir)
+
+	args = append(args, p.Limit+1)
+	return fmt.Sprintf("ORDER BY %s LIMIT $%d", strings.Join(terms, ", "), len(args)), args
+}
+
+// Page trims the extra row fetched by OrderBy and builds the cursor for the
+// next page. key returns a row's sort column values and ID; the cursor is
+// empty on the last page.
+func Page[T any](p Params, rows []T, key func(T) ([]any, string)) ([]T, string) {
+	if rows == nil {
+		rows = []T{}
+	}
+	if len(rows) <= p.Limit {
+		return rows, ""
+	}
+
+	rows = rows[:p.Limit]
+	values, id := key(rows[len(rows)-1])
+
+	c := cursor{Sort: p.Sort.Key, Desc: p.Desc, ID: id, Values: make([]string, len(values))}
+	for i, v := range values {
+		c.Values[i] = formatValue(v)
+	}
+	return rows, encodeCursor(c)
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// fits reports whether the cursor's values can be cast to the sort's column
+// types, so a tampered cursor is a 400 rather than a query error.
+func (c *cursor) fits(sort Sort) bool {
+	if len(c.Values) != len(sort.Columns) || !uuidPattern.MatchString(c.ID) {
+		return false
+	}
+	for i, col := range sort.Columns {
+		switch col.Type {
+		case "timestamptz":
+			if _, err := time.Parse(time.RFC3339Nano, c.Values[i]); err != nil {
+				return false
+			}
+		case "int":
+			if _, err := strconv.Atoi(c.Values[i]); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,242 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	idA = "00000000-0000-0000-0000-00000000000a"
+	idB = "00000000-0000-0000-0000-00000000000b"
+	idC = "00000000-0000-0000-0000-00000000000c"
+)
+
+var testSpec = Spec{
+	Sorts: []Sort{
+		{Key: "created", Columns: []Column{{Expr: "created_at", Type: "timestamptz"}}, Desc: true},
+		{Key: "name", Columns: []Column{{Expr: "name", Type: "text"}}},
+		{Key: "rank", Columns: []Column{{Expr: "rank", Type: "int"}, {Expr: "name", Type: "text"}}},
+	},
+	Filters:      []string{"role"},
+	IDColumn:     "id",
+	DefaultLimit: 2,
+	MaxLimit:     10,
+}
+
+type row struct {
+	id      string
+	name    string
+	rank    int
+	created time.Time
+}
+
+func rowKey(sort string) func(row) ([]any, string) {
+	return func(r row) ([]any, string) {
+		switch sort {
+		case "name":
+			return []any{r.name}, r.id
+		case "rank":
+			return []any{r.rank, r.name}, r.id
+		default:
+			return []any{r.created}, r.id
+		}
+	}
+}
+
+func parse(t *testing.T, query string) (Params, error) {
+	t.Helper()
+	return testSpec.Parse(httptest.NewRequest("GET", "/?"+query, nil))
+}
+
+func mustParse(t *testing.T, query string) Params {
+	t.Helper()
+	p, err := parse(t, query)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", query, err)
+	}
+	return p
+}
+
+func TestParseDefaults(t *testing.T) {
+	p := mustParse(t, "")
+	if p.Limit != 2 || p.Sort.Key != "created" || !p.Desc || len(p.Filters) != 0 {
+		t.Errorf("Parse() = %+v, want the spec's defaults", p)
+	}
+	if where, args := p.Where(nil); where != "TRUE" || len(args) != 0 {
+		t.Errorf("first page Where() = %q, %v, want TRUE", where, args)
+	}
+}
+
+func TestParse(t *testing.T) {
+	p := mustParse(t, "limit=5&sort=-name&filter[role]=admin")
+	if p.Limit != 5 || p.Sort.Key != "name" || !p.Desc || p.Filters["role"] != "admin" {
+		t.Errorf("Parse() = %+v", p)
+	}
+
+	tests := []struct {
+		query string
+		want  error
+	}{
+		{"limit=0", ErrInvalidLimit},
+		{"limit=11", ErrInvalidLimit},
+		{"limit=ten", ErrInvalidLimit},
+		{"sort=email", ErrInvalidSort},
+		{"sort=-email", ErrInvalidSort},
+		{"filter[email]=x", ErrInvalidFilter},
+		{"filter[role=admin", ErrInvalidFilter},
+	}
+	for _, tt := range tests {
+		if _, err := parse(t, tt.query); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "ORDER BY created_at DESC, id DESC LIMIT $2"},
+		{"sort=created", "ORDER BY created_at ASC, id ASC LIMIT $2"},
+		{"sort=name", "ORDER BY name ASC, id ASC LIMIT $2"},
+		{"sort=-rank", "ORDER BY rank DESC, name DESC, id DESC LIMIT $2"},
+	}
+	for _, tt := range tests {
+		p := mustParse(t, tt.query)
+		order, args := p.OrderBy([]any{"org"})
+		if order != tt.want {
+			t.Errorf("%q: OrderBy() = %q, want %q", tt.query, order, tt.want)
+		}
+		if !reflect.DeepEqual(args, []any{"org", p.Limit + 1}) {
+			t.Errorf("%q: OrderBy() args = %v, want the limit plus one", tt.query, args)
+		}
+	}
+}
+
+func TestPageLastPage(t *testing.T) {
+	p := mustParse(t, "")
+	rows, next := Page(p, []row{{id: idA}, {id: idB}}, rowKey("created"))
+	if len(rows) != 2 || next != "" {
+		t.Errorf("Page() = %d rows, cursor %q, want 2 rows and no cursor", len(rows), next)
+	}
+
+	rows, next = Page[row](p, nil, rowKey("created"))
+	if rows == nil || len(rows) != 0 || next != "" {
+		t.Errorf("Page(nil) = %v, %q, want an empty page", rows, next)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2026, 3, 4, 5, 6, 7, 890, time.FixedZone("CET", 3600))
+	tests := []struct {
+		query     string
+		rows      []row
+		wantWhere string
+		wantArgs  []any
+	}{
+		{
+			"",
+			[]row{{id: idA, created: created.Add(time.Hour)}, {id: idB, created: created}, {id: idC}},
+			"(created_at, id) < ($2::timestamptz, $3::uuid)",
+			[]any{"org", created.UTC().Format(time.RFC3339Nano), idB},
+		},
+		{
+			"sort=created",
+			[]row{{id: idA}, {id: idB, created: created}, {id: idC}},
+			"(created_at, id) > ($2::timestamptz, $3::uuid)",
+			[]any{"org", created.UTC().Format(time.RFC3339Nano), idB},
+		},
+		{
+			"sort=name",
+			[]row{{id: idA, name: "Ann"}, {id: idB, name: "Bob, \"Jr\""}, {id: idC, name: "Cy"}},
+			"(name, id) > ($2::text, $3::uuid)",
+			[]any{"org", "Bob, \"Jr\"", idB},
+		},
+		{
+			"sort=-rank",
+			[]row{{id: idA, rank: 4, name: "Zed"}, {id: idB, rank: 3, name: "Amy"}, {id: idC, rank: 1}},
+			"(rank, name, id) < ($2::int, $3::text, $4::uuid)",
+			[]any{"org", "3", "Amy", idB},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			p := mustParse(t, tt.query)
+			rows, next := Page(p, tt.rows, rowKey(p.Sort.Key))
+			if len(rows) != p.Limit || next == "" {
+				t.Fatalf("Page() = %d rows, cursor %q, want %d rows and a cursor", len(rows), next, p.Limit)
+			}
+
+			query := "cursor=" + next
+			if tt.query != "" {
+				query = tt.query + "&" + query
+			}
+			after := mustParse(t, query)
+			where, args := after.Where([]any{"org"})
+			if where != tt.wantWhere {
+				t.Errorf("Where() = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Where() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseRejectsTamperedCursor(t *testing.T) {
+	p := mustParse(t, "sort=rank")
+	_, next := Page(p, []row{{id: idA}, {id: idB, rank: 2, name: "Bo"}, {id: idC}}, rowKey("rank"))
+
+	raw := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"not base64", "sort=rank&cursor=not!base64"},
+		{"not JSON", "sort=rank&cursor=" + raw("{")},
+		{"no ID", "sort=rank&cursor=" + raw(`{"s":"rank","v":["2","Bo"]}`)},
+		{"ID not a UUID", "sort=rank&cursor=" + raw(`{"s":"rank","v":["2","Bo"],"id":"1 OR 1=1"}`)},
+		{"too few values", "sort=rank&cursor=" + raw(`{"s":"rank","v":["2"],"id":"`+idB+`"}`)},
+		{"too many values", "sort=rank&cursor=" + raw(`{"s":"rank","v":["2","Bo","x"],"id":"`+idB+`"}`)},
+		{"value not an int", "sort=rank&cursor=" + raw(`{"s":"rank","v":["two","Bo"],"id":"`+idB+`"}`)},
+		{"value not a timestamp", "cursor=" + raw(`{"s":"created","d":true,"v":["yesterday"],"id":"`+idB+`"}`)},
+		{"other sort", "sort=name&cursor=" + next},
+		{"other direction", "sort=-rank&cursor=" + next},
+		{"default sort", "cursor=" + next},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parse(t, tt.query); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Parse() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	if _, err := parse(t, "sort=rank&cursor="+next); err != nil {
+		t.Errorf("untampered cursor rejected: %v", err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 12, 0, 0, 5, time.FixedZone("X", -7200))
+	for v, want := range map[any]string{
+		ts:    "2026-01-01T14:00:00.000000005Z",
+		"a,b": "a,b",
+		42:    "42",
+	} {
+		if got := formatValue(v); got != want {
+			t.Errorf("formatValue(%v) = %q, want %q", v, got, want)
+		}
+	}
+	if strings.Contains(formatValue(ts), "+") {
+		t.Error("timestamp not normalized to UTC")
+	}
+}
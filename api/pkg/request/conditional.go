@@ -0,0 +1,75 @@
+package request
+
+import (
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPreconditionFailed is returned when an If-Match header no longer
+// matches the stored version of a resource.
+var ErrPreconditionFailed = errors.New("resource has been modified")
+
+// ETag derives a strong entity tag from a row's updated_at.
+func ETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixNano(), 36) + `"`
+}
+
+// ListETag derives a weak entity tag for one view of a collection from its
+// size, the newest updated_at among its rows and the query that selects the
+// view. Adding, removing or changing a row changes one of the first two, and
+// each page, filter and sort order gets a tag of its own.
+func ListETag(count int, latest time.Time, query url.Values) string {
+	view := fnv.New64a()
+	view.Write([]byte(query.Encode()))
+	return `W/"` + strconv.Itoa(count) + "-" + strconv.FormatInt(latest.UnixNano(), 36) +
+		"-" + strconv.FormatUint(view.Sum64(), 36) + `"`
+}
+
+// IfMatch reports whether the request may modify a resource whose current
+// entity tag is etag. Requests without If-Match are unconditional.
+func IfMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	return matches(header, etag, false)
+}
+
+// IfNoneMatch reports whether the client's cached copy, named in
+// If-None-Match, is still current.
+func IfNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	return matches(header, etag, true)
+}
+
+// matches compares a comma-separated list of entity tags against etag.
+// If-Match uses strong comparison, so weak tags never match; If-None-Match
+// uses weak comparison.
+func matches(header, etag string, weak bool) bool {
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"base/api/pkg/response"
+)
+
+var updatedAt = time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+
+func TestETag(t *testing.T) {
+	tag := ETag(updatedAt)
+	if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || strings.HasPrefix(tag, "W/") {
+		t.Errorf("ETag() = %s, want a quoted strong tag", tag)
+	}
+	if ETag(updatedAt) != tag {
+		t.Error("ETag() is not stable")
+	}
+	if ETag(updatedAt.Add(time.Nanosecond)) == tag {
+		t.Error("ETag() did not change with updated_at")
+	}
+}
+
+func TestListETag(t *testing.T) {
+	query := url.Values{"limit": {"20"}, "sort": {"name"}}
+	tag := ListETag(3, updatedAt, query)
+	if !strings.HasPrefix(tag, `W/"`) || !strings.HasSuffix(tag, `"`) {
+		t.Fatalf("ListETag() = %s, want a weak tag", tag)
+	}
+
+	reordered, _ := url.ParseQuery("sort=name&limit=20")
+	if ListETag(3, updatedAt, reordered) != tag {
+		t.Error("parameter order changed the tag")
+	}
+
+	changed := map[string]string{
+		"count":   ListETag(4, updatedAt, query),
+		"latest":  ListETag(3, updatedAt.Add(time.Second), query),
+		"page":    ListETag(3, updatedAt, url.Values{"limit": {"20"}, "sort": {"name"}, "cursor": {"abc"}}),
+		"filter":  ListETag(3, updatedAt, url.Values{"limit": {"20"}, "sort": {"name"}, "filter[role]": {"admin"}}),
+		"sort":    ListETag(3, updatedAt, url.Values{"limit": {"20"}, "sort": {"-name"}}),
+		"no view": ListETag(3, updatedAt, nil),
+	}
+	for name, other := range changed {
+		if other == tag {
+			t.Errorf("%s: tag unchanged", name)
+		}
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tag := ETag(updatedAt)
+	other := ETag(updatedAt.Add(time.Second))
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"absent", "", true},
+		{"same", tag, true},
+		{"different", other, false},
+		{"any", "*", true},
+		{"in a list", other + ", " + tag, true},
+		{"not in a list", other + `, "x"`, false},
+		{"weak form of the tag", "W/" + tag, false},
+		{"weak form in a list", "W/" + tag + ", " + other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			if got := IfMatch(r, tag); got != tt.want {
+				t.Errorf("IfMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	strong := ETag(updatedAt)
+	weak := ListETag(3, updatedAt, nil)
+
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		want   bool
+	}{
+		{"absent", "", strong, false},
+		{"same strong", strong, strong, true},
+		{"same weak", weak, weak, true},
+		{"weak header, strong tag", "W/" + strong, strong, true},
+		{"strong header, weak tag", strings.TrimPrefix(weak, "W/"), weak, true},
+		{"different", ETag(updatedAt.Add(time.Second)), strong, false},
+		{"any", "*", strong, true},
+		{"in a list", `"a", ` + weak + `, "b"`, weak, true},
+		{"not in a list", `"a", "b"`, weak, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-None-Match", tt.header)
+			}
+			if got := IfNoneMatch(r, tt.etag); got != tt.want {
+				t.Errorf("IfNoneMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfMatchPreconditionFailed(t *testing.T) {
+	current := ETag(updatedAt)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IfMatch(r, current) {
+			response.PreconditionFailed(w, "modified by someone else")
+			return
+		}
+		response.NoContent(w)
+	})
+
+	for header, want := range map[string]int{
+		current:                         http.StatusNoContent,
+		ETag(updatedAt.Add(-time.Hour)): http.StatusPreconditionFailed,
+		"W/" + current:                  http.StatusPreconditionFailed,
+	} {
+		r := httptest.NewRequest(http.MethodPatch, "/", nil)
+		r.Header.Set("If-Match", header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Errorf("If-Match %s: status = %d, want %d", header, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package request decodes partial updates sent as JSON Merge Patch
+// (RFC 7396) and evaluates conditional request headers.
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// MergePatchContentType is the media type for RFC 7396 documents. Plain
+// application/json bodies are accepted with the same semantics.
+const MergePatchContentType = "application/merge-patch+json"
+
+// maxPatchSize bounds patch bodies; updates are small documents.
+const maxPatchSize = 1 << 20
+
+var (
+	ErrUnsupportedMediaType = errors.New("content type must be application/merge-patch+json or application/json")
+	ErrInvalidPatch         = errors.New("patch must be a JSON object")
+)
+
+// Field records whether a key was present in a patch document, so an absent
+// field ("leave unchanged") differs from an explicit null ("clear").
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		var zero T
+		f.Value = zero
+		return nil
+	}
+	f.Null = false
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Present reports whether the field was sent with a non-null value.
+func (f Field[T]) Present() bool {
+	return f.Set && !f.Null
+}
+
+// DecodePatch reads a merge patch body into dst, a struct of Field values.
+// Unknown members are rejected so typos do not silently become no-ops.
+func DecodePatch(r *http.Request, dst any) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+			return ErrUnsupportedMediaType
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize))
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return ErrInvalidPatch
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
+
+// MergePatch applies an RFC 7396 patch to a JSON document and returns the
+// result. Objects merge recursively, null removes a member and any other
+// value replaces the target wholesale.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	var t, p any
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := json.Unmarshal(target, &t); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(t, p))
+}
+
+func mergeValue(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
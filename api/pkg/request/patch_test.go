@@ -0,0 +1,148 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type patchDoc struct {
+	Name     Field[string]         `json:"name"`
+	Count    Field[int]            `json:"count"`
+	Settings Field[map[string]any] `json:"settings"`
+}
+
+func patchRequest(body, contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestDecodePatchFields(t *testing.T) {
+	var doc patchDoc
+	err := DecodePatch(patchRequest(`{"name": "Acme", "count": null}`, MergePatchContentType), &doc)
+	if err != nil {
+		t.Fatalf("DecodePatch() error = %v", err)
+	}
+
+	if !doc.Name.Set || doc.Name.Null || doc.Name.Value != "Acme" || !doc.Name.Present() {
+		t.Errorf("name = %+v, want set to \"Acme\"", doc.Name)
+	}
+	if !doc.Count.Set || !doc.Count.Null || doc.Count.Present() {
+		t.Errorf("count = %+v, want set to null", doc.Count)
+	}
+	if doc.Settings.Set || doc.Settings.Null || doc.Settings.Present() {
+		t.Errorf("settings = %+v, want absent", doc.Settings)
+	}
+}
+
+func TestFieldNullClearsValue(t *testing.T) {
+	f := Field[string]{Value: "stale"}
+	if err := json.Unmarshal([]byte(`null`), &f); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !f.Set || !f.Null || f.Value != "" {
+		t.Errorf("field = %+v, want set, null and zero", f)
+	}
+}
+
+func TestDecodePatchErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        error
+	}{
+		{"unknown field", `{"nmae": "Acme"}`, MergePatchContentType, nil},
+		{"array", `[{"name": "Acme"}]`, MergePatchContentType, ErrInvalidPatch},
+		{"null document", `null`, MergePatchContentType, ErrInvalidPatch},
+		{"empty body", ``, MergePatchContentType, ErrInvalidPatch},
+		{"wrong value type", `{"count": "three"}`, MergePatchContentType, nil},
+		{"form content type", `{"name": "Acme"}`, "application/x-www-form-urlencoded", ErrUnsupportedMediaType},
+		{"json patch content type", `{"name": "Acme"}`, "application/json-patch+json", ErrUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc patchDoc
+			err := DecodePatch(patchRequest(tt.body, tt.contentType), &doc)
+			if err == nil {
+				t.Fatal("DecodePatch() succeeded, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("DecodePatch() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePatchContentTypes(t *testing.T) {
+	for _, ct := range []string{"", "application/json", "application/json; charset=utf-8", MergePatchContentType} {
+		var doc patchDoc
+		if err := DecodePatch(patchRequest(`{"name": "Acme"}`, ct), &doc); err != nil {
+			t.Errorf("Content-Type %q: error = %v", ct, err)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396 appendix A, plus an empty target
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":{"b":1}}`, `{"a":{"b":1}}`},
+		{`{"a":{"b":1,"c":{"d":2}}}`, `{"a":{"c":{"e":3}}}`, `{"a":{"b":1,"c":{"d":2,"e":3}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" + "+tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalidJSON(t *testing.T) {
+	if _, err := MergePatch([]byte(`{"a":`), []byte(`{}`)); err == nil {
+		t.Error("invalid target accepted")
+	}
+	if _, err := MergePatch([]byte(`{}`), []byte(`{"a":`)); err == nil {
+		t.Error("invalid patch accepted")
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("unmarshal %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("unmarshal %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -14,6 +14,13 @@ type SuccessResponse struct {
 	Data interface{} `json:"data,omitempty"`
 }
 
+// PageResponse is the envelope for paginated lists. Data is always an array.
+type PageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
 func JSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -26,6 +33,11 @@ func OK(w http.ResponseWriter, data interface{}) {
 	JSON(w, http.StatusOK, SuccessResponse{Data: data})
 }
 
+// Page writes one page of a list; nextCursor is empty on the last page.
+func Page(w http.ResponseWriter, data interface{}, nextCursor string) {
+	JSON(w, http.StatusOK, PageResponse{Data: data, NextCursor: nextCursor, HasMore: nextCursor != ""})
+}
+
 func Created(w http.ResponseWriter, data interface{}) {
 	JSON(w, http.StatusCreated, SuccessResponse{Data: data})
 }
@@ -34,6 +46,10 @@ func NoContent(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func NotModified(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotModified)
+}
+
 func Error(w http.ResponseWriter, status int, err string, message string) {
 	JSON(w, status, ErrorResponse{Error: err, Message: message})
 }
@@ -57,3 +73,11 @@ func NotFound(w http.ResponseWriter, message string) {
 func InternalError(w http.ResponseWriter, message string) {
 	Error(w, http.StatusInternalServerError, "internal_error", message)
 }
+
+func PreconditionFailed(w http.ResponseWriter, message string) {
+	Error(w, http.StatusPreconditionFailed, "precondition_failed", message)
+}
+
+func UnsupportedMediaType(w http.ResponseWriter, message string) {
+	Error(w, http.StatusUnsupportedMediaType, "unsupported_media_type", message)
+}
@@ -0,0 +1,369 @@
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455): the opening handshake and message framing. Extensions and
+// subprotocols are not supported.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"base/api/pkg/response"
+)
+
+// Message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseTooBig          = 1009
+	CloseInternalError   = 1011
+)
+
+const (
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// DefaultReadLimit caps the size of a message, fragments included
+	DefaultReadLimit = 64 << 10
+	maxControlLength = 125
+)
+
+var (
+	ErrBadHandshake  = errors.New("websocket: bad handshake")
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	ErrProtocol      = errors.New("websocket: protocol error")
+	ErrClosed        = errors.New("websocket: close sent")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by peer (%d %s)", e.Code, e.Reason)
+}
+
+// Upgrade completes the opening handshake and takes over the connection. If
+// it fails, the error response has already been written.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		response.Error(w, http.StatusUpgradeRequired, "upgrade_required", "websocket upgrade required")
+		return nil, ErrBadHandshake
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		response.Error(w, http.StatusUpgradeRequired, "upgrade_required", "unsupported websocket version")
+		return nil, ErrBadHandshake
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		response.BadRequest(w, "invalid Sec-WebSocket-Key")
+		return nil, ErrBadHandshake
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		response.InternalError(w, "websocket not supported")
+		return nil, err
+	}
+	// The server's timeouts were set for the HTTP request
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	// A client must wait for the handshake before sending frames
+	if rw.Reader.Buffered() > 0 {
+		netConn.Close()
+		return nil, ErrBadHandshake
+	}
+
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	rw.Writer.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Writer.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: netConn, r: rw.Reader, readLimit: DefaultReadLimit}, nil
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conn is a server-side WebSocket connection. One goroutine may read while
+// others write.
+type Conn struct {
+	conn        net.Conn
+	r           *bufio.Reader
+	readLimit   int64
+	readTimeout time.Duration
+
+	wmu          sync.Mutex
+	writeTimeout time.Duration
+	closeSent    bool
+}
+
+// SetReadLimit sets the largest message ReadMessage accepts. Larger messages
+// close the connection with CloseTooBig.
+func (c *Conn) SetReadLimit(n int64) {
+	c.readLimit = n
+}
+
+// SetReadTimeout requires every frame, pongs included, to arrive within d of
+// the previous one. Zero disables the timeout.
+func (c *Conn) SetReadTimeout(d time.Duration) error {
+	c.readTimeout = d
+	return c.extendReadDeadline()
+}
+
+func (c *Conn) extendReadDeadline() error {
+	if c.readTimeout == 0 {
+		return c.conn.SetReadDeadline(time.Time{})
+	}
+	return c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+}
+
+// SetWriteTimeout bounds every frame written from now on, including the pongs
+// and close replies sent by ReadMessage, to d from when its write starts.
+// Zero disables the timeout.
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.writeTimeout = d
+}
+
+// RemoteAddr returns the peer's network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the underlying connection without a closing handshake.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered
+// and pongs skipped. When the peer closes the connection the close is echoed
+// and a *CloseError returned.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		op  int
+		msg []byte
+	)
+	for {
+		f, err := c.readFrame(int64(len(msg)))
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := c.extendReadDeadline(); err != nil {
+			return 0, nil, err
+		}
+
+		switch f.op {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, f.payload); err != nil && !errors.Is(err, ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if op != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			op = f.op
+		case continuationFrame:
+			if op == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		msg = append(msg, f.payload...)
+		if !f.fin {
+			continue
+		}
+		if op == TextMessage && !utf8.Valid(msg) {
+			return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+		}
+		return op, msg, nil
+	}
+}
+
+type frame struct {
+	fin     bool
+	op      int
+	payload []byte
+}
+
+// readFrame reads one frame; buffered is the size of the message read so
+// far, counted against the read limit.
+func (c *Conn) readFrame(buffered int64) (frame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{fin: header[0]&0x80 != 0, op: int(header[0] & 0x0f)}
+	if header[0]&0x70 != 0 {
+		return frame{}, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	// Clients must mask every frame
+	if header[1]&0x80 == 0 {
+		return frame{}, c.fail(CloseProtocolError, "unmasked frame")
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		if ext[0]&0x80 != 0 {
+			return frame{}, c.fail(CloseProtocolError, "invalid length")
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	if f.op >= CloseMessage && (length > maxControlLength || !f.fin) {
+		return frame{}, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if f.op < CloseMessage && buffered+length > c.readLimit {
+		c.fail(CloseTooBig, "message too big")
+		return frame{}, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return frame{}, err
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.r, f.payload); err != nil {
+		return frame{}, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+	return f, nil
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+	}
+	// Echo the close unless we started the handshake
+	code := closeErr.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	c.WriteClose(code, "")
+	return closeErr
+}
+
+// fail sends a close frame for a protocol violation and returns the error
+// to report.
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	return fmt.Errorf("%w: %s", ErrProtocol, reason)
+}
+
+// WriteMessage sends a single-frame message. Once a close frame has been
+// sent, further writes return ErrClosed.
+func (c *Conn) WriteMessage(op int, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == CloseMessage {
+		c.closeSent = true
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | byte(op)
+	switch n := len(data); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	var deadline time.Time
+	if c.writeTimeout > 0 {
+		deadline = time.Now().Add(c.writeTimeout)
+	}
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	buffers := net.Buffers{header, data}
+	_, err := buffers.WriteTo(c.conn)
+	return err
+}
+
+// WriteClose starts or completes the closing handshake. The peer's reply
+// arrives through ReadMessage as a *CloseError.
+func (c *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > maxControlLength-2 {
+		reason = reason[:maxControlLength-2]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return c.WriteMessage(CloseMessage, append(payload, reason...))
+}
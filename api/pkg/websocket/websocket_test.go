@@ -0,0 +1,361 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// result is what the server's ReadMessage returned.
+type result struct {
+	op  int
+	msg []byte
+	err error
+}
+
+// client is the test's end of an upgraded connection, speaking raw frames.
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	r      *bufio.Reader
+	server chan *Conn
+	read   chan result
+}
+
+// dial upgrades a connection to a server that applies setup and then reads
+// one message.
+func dial(t *testing.T, setup func(c *Conn)) *client {
+	t.Helper()
+	server := make(chan *Conn, 1)
+	read := make(chan result, 1)
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			read <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		if setup != nil {
+			setup(conn)
+		}
+		server <- conn
+		op, msg, err := conn.ReadMessage()
+		read <- result{op, msg, err}
+		<-done
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := "GET / HTTP/1.1\r\n" +
+		"Host: " + srv.Listener.Addr().String() + "\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: " + testKey + "\r\n\r\n"
+	if _, err := io.WriteString(conn, req); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d", resp.StatusCode)
+	}
+	return &client{t: t, conn: conn, r: r, server: server, read: read}
+}
+
+// write sends a frame, masked as clients must unless masked is false.
+func (c *client) write(fin bool, op int, payload []byte, masked bool) {
+	c.t.Helper()
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0, 0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame[1] = byte(n)
+	case n <= 0xffff:
+		frame[1] = 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] = 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	data := payload
+	if masked {
+		frame[1] |= 0x80
+		mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+		frame = append(frame, mask[:]...)
+		data = make([]byte, len(payload))
+		for i := range payload {
+			data[i] = payload[i] ^ mask[i%4]
+		}
+	}
+	if _, err := c.conn.Write(append(frame, data...)); err != nil {
+		c.t.Fatalf("write frame: %v", err)
+	}
+}
+
+// next reads a frame from the server, which never masks.
+func (c *client) next() (int, []byte) {
+	c.t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		c.t.Fatalf("read frame: %v", err)
+	}
+	if header[0]&0x80 == 0 || header[1]&0x80 != 0 {
+		c.t.Fatalf("server frame header %08b %08b, want final and unmasked", header[0], header[1])
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.r, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		c.t.Fatalf("read payload: %v", err)
+	}
+	return int(header[0] & 0x0f), payload
+}
+
+// expectClose reads the server's close frame and checks its code.
+func (c *client) expectClose(code int) string {
+	c.t.Helper()
+	op, payload := c.next()
+	if op != CloseMessage || len(payload) < 2 {
+		c.t.Fatalf("got opcode %d payload %q, want a close frame", op, payload)
+	}
+	if got := int(binary.BigEndian.Uint16(payload)); got != code {
+		c.t.Fatalf("close code = %d, want %d", got, code)
+	}
+	return string(payload[2:])
+}
+
+func (c *client) result() result {
+	c.t.Helper()
+	select {
+	case res := <-c.read:
+		return res
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("server did not return from ReadMessage")
+		return result{}
+	}
+}
+
+func TestUpgradeAccept(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := Upgrade(w, r); err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", testKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The example from RFC 6455 section 1.3
+	sum := sha1.Sum([]byte(testKey + acceptGUID))
+	want := base64.StdEncoding.EncodeToString(sum[:])
+	if want != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("accept key = %q", want)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); resp.StatusCode != http.StatusSwitchingProtocols || got != want {
+		t.Errorf("status = %d, Sec-WebSocket-Accept = %q, want 101 and %q", resp.StatusCode, got, want)
+	}
+}
+
+func TestUpgradeRejectsPlainRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	_, err := Upgrade(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !errors.Is(err, ErrBadHandshake) || rec.Code != http.StatusUpgradeRequired {
+		t.Errorf("err = %v, status = %d, want ErrBadHandshake and 426", err, rec.Code)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	c := dial(t, nil)
+	c.write(true, TextMessage, []byte("hello"), true)
+
+	res := c.result()
+	if res.err != nil || res.op != TextMessage || string(res.msg) != "hello" {
+		t.Errorf("ReadMessage() = %d, %q, %v, want text \"hello\"", res.op, res.msg, res.err)
+	}
+}
+
+func TestReadMessageRejectsUnmaskedFrame(t *testing.T) {
+	c := dial(t, nil)
+	c.write(true, TextMessage, []byte("hello"), false)
+
+	c.expectClose(CloseProtocolError)
+	if res := c.result(); !errors.Is(res.err, ErrProtocol) {
+		t.Errorf("ReadMessage() error = %v, want ErrProtocol", res.err)
+	}
+}
+
+func TestReadMessageReassemblesFragments(t *testing.T) {
+	c := dial(t, nil)
+	c.write(false, TextMessage, []byte("frag"), true)
+	c.write(false, continuationFrame, []byte("men"), true)
+	c.write(true, continuationFrame, []byte("ted"), true)
+
+	res := c.result()
+	if res.err != nil || res.op != TextMessage || string(res.msg) != "fragmented" {
+		t.Errorf("ReadMessage() = %d, %q, %v, want text \"fragmented\"", res.op, res.msg, res.err)
+	}
+}
+
+func TestReadMessageAnswersPingBetweenFragments(t *testing.T) {
+	c := dial(t, nil)
+	c.write(false, BinaryMessage, []byte{1, 2}, true)
+	c.write(true, PingMessage, []byte("are you there"), true)
+
+	op, payload := c.next()
+	if op != PongMessage || string(payload) != "are you there" {
+		t.Fatalf("got opcode %d payload %q, want a pong echoing the ping", op, payload)
+	}
+
+	c.write(true, PongMessage, nil, true)
+	c.write(true, continuationFrame, []byte{3}, true)
+	res := c.result()
+	if res.err != nil || res.op != BinaryMessage || string(res.msg) != "\x01\x02\x03" {
+		t.Errorf("ReadMessage() = %d, %v, %v, want binary 01 02 03", res.op, res.msg, res.err)
+	}
+}
+
+func TestReadMessageRejectsFragmentedControlFrame(t *testing.T) {
+	c := dial(t, nil)
+	c.write(false, PingMessage, []byte("x"), true)
+
+	c.expectClose(CloseProtocolError)
+	if res := c.result(); !errors.Is(res.err, ErrProtocol) {
+		t.Errorf("ReadMessage() error = %v, want ErrProtocol", res.err)
+	}
+}
+
+func TestReadMessageEnforcesReadLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+	}{
+		{"single frame", [][]byte{[]byte(strings.Repeat("a", 11))}},
+		{"across fragments", [][]byte{[]byte(strings.Repeat("a", 6)), []byte(strings.Repeat("a", 6))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, func(conn *Conn) { conn.SetReadLimit(10) })
+			for i, f := range tt.frames {
+				op := TextMessage
+				if i > 0 {
+					op = continuationFrame
+				}
+				c.write(i == len(tt.frames)-1, op, f, true)
+			}
+
+			c.expectClose(CloseTooBig)
+			if res := c.result(); !errors.Is(res.err, ErrMessageTooBig) {
+				t.Errorf("ReadMessage() error = %v, want ErrMessageTooBig", res.err)
+			}
+		})
+	}
+}
+
+func TestReadMessageRejectsInvalidUTF8(t *testing.T) {
+	c := dial(t, nil)
+	c.write(true, TextMessage, []byte{0xff, 0xfe}, true)
+
+	c.expectClose(CloseInvalidPayload)
+	if res := c.result(); !errors.Is(res.err, ErrProtocol) {
+		t.Errorf("ReadMessage() error = %v, want ErrProtocol", res.err)
+	}
+}
+
+func TestReadMessageEchoesClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantCode int
+		echoCode int
+	}{
+		{"with code", append(binary.BigEndian.AppendUint16(nil, CloseGoingAway), "bye"...), CloseGoingAway, CloseGoingAway},
+		{"without code", nil, CloseNoStatus, CloseNormal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, nil)
+			c.write(true, CloseMessage, tt.payload, true)
+
+			c.expectClose(tt.echoCode)
+			res := c.result()
+			var closeErr *CloseError
+			if !errors.As(res.err, &closeErr) || closeErr.Code != tt.wantCode {
+				t.Fatalf("ReadMessage() error = %v, want a CloseError with code %d", res.err, tt.wantCode)
+			}
+			if tt.wantCode == CloseGoingAway && closeErr.Reason != "bye" {
+				t.Errorf("reason = %q, want \"bye\"", closeErr.Reason)
+			}
+		})
+	}
+}
+
+func TestWriteMessageAfterClose(t *testing.T) {
+	c := dial(t, nil)
+	conn := <-c.server
+	c.write(true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseNormal), true)
+	c.expectClose(CloseNormal)
+	c.result()
+
+	if err := conn.WriteMessage(TextMessage, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("WriteMessage() after close = %v, want ErrClosed", err)
+	}
+}
+
+func TestWriteMessageFrameLengths(t *testing.T) {
+	for _, n := range []int{0, 125, 126, 0xffff, 0x10000} {
+		c := dial(t, nil)
+		conn := <-c.server
+		payload := []byte(strings.Repeat("x", n))
+		if err := conn.WriteMessage(BinaryMessage, payload); err != nil {
+			t.Fatalf("WriteMessage(%d bytes): %v", n, err)
+		}
+		op, got := c.next()
+		if op != BinaryMessage || len(got) != n {
+			t.Errorf("%d bytes: got opcode %d with %d bytes", n, op, len(got))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Email domains claimed by an organization, verified via DNS TXT record
+CREATE TABLE organization_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL,
+    verification_token TEXT NOT NULL,
+    verified_at TIMESTAMPTZ,
+    default_role TEXT NOT NULL DEFAULT 'member' CHECK (default_role IN ('admin', 'member')),
+    join_mode TEXT NOT NULL DEFAULT 'invite' CHECK (join_mode IN ('join', 'invite')),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(organization_id, domain)
+);
+
+CREATE INDEX idx_org_domains_org_id ON organization_domains(organization_id);
+
+-- A domain can only be verified by one organization at a time
+CREATE UNIQUE INDEX idx_org_domains_verified ON organization_domains(domain) WHERE verified_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS organization_domains;
+
+-- +goose StatementEnd
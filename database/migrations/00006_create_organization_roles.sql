@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Custom roles: named permission sets defined per organization
+CREATE TABLE organization_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (name NOT IN ('owner', 'admin', 'member')),
+    description TEXT NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(organization_id, name)
+);
+
+CREATE INDEX idx_org_roles_org_id ON organization_roles(organization_id);
+
+-- Members may now hold a built-in role or a custom role name
+ALTER TABLE organization_members DROP CONSTRAINT organization_members_role_check;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE organization_members SET role = 'member' WHERE role NOT IN ('owner', 'admin', 'member');
+ALTER TABLE organization_members ADD CONSTRAINT organization_members_role_check CHECK (role IN ('owner', 'admin', 'member'));
+DROP TABLE IF EXISTS organization_roles;
+
+-- +goose StatementEnd
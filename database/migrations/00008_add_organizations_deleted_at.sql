@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Soft deletion: deleted organizations can be restored until they are purged
+ALTER TABLE organizations ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_organizations_deleted_at ON organizations(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM organizations WHERE deleted_at IS NOT NULL;
+ALTER TABLE organizations DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Versioned settings document (profile, branding and membership policy).
+-- Missing keys fall back to application defaults.
+ALTER TABLE organizations ADD COLUMN settings JSONB NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE organizations DROP COLUMN settings;
+
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Backstop for the "at least one owner" invariant. The repository serializes
+-- ownership changes with row locks; this deferred check catches any write
+-- path that bypasses it. Organizations being deleted are exempt.
+CREATE OR REPLACE FUNCTION organization_members_require_owner() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM organizations WHERE id = OLD.organization_id)
+       AND NOT EXISTS (
+           SELECT 1 FROM organization_members
+           WHERE organization_id = OLD.organization_id AND role = 'owner'
+       ) THEN
+        RAISE EXCEPTION 'organization % must keep at least one owner', OLD.organization_id
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER organization_members_require_owner
+    AFTER UPDATE OF role OR DELETE ON organization_members
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    WHEN (OLD.role = 'owner')
+    EXECUTE FUNCTION organization_members_require_owner();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS organization_members_require_owner ON organization_members;
+DROP FUNCTION IF EXISTS organization_members_require_owner();
+
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes serve both the prefix (ILIKE 'q%') and fuzzy (<%) matches
+-- used by member search.
+CREATE INDEX idx_users_name_trgm ON users USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_users_email_trgm ON users USING GIN (email gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_name_trgm;
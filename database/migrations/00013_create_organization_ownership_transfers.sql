@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Ownership transfers awaiting the new owner's acceptance. Rows are deleted
+-- once accepted or cancelled; the audit log keeps the history.
+CREATE TABLE organization_ownership_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL UNIQUE REFERENCES organizations(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX idx_ownership_transfers_from_user ON organization_ownership_transfers(from_user_id);
+CREATE INDEX idx_ownership_transfers_to_user ON organization_ownership_transfers(to_user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS organization_ownership_transfers;
+
+-- +goose StatementEnd
@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Tenant isolation policies. The API sets app.current_org_id and
+-- app.current_user_id per transaction when POSTGRES_RLS is enabled; a
+-- statement run for one organization then cannot see or write another
+-- organization's rows, even if it forgets its organization_id filter.
+-- Statements without a tenant (sign-in, cross-organization listings,
+-- background jobs) are unrestricted.
+--
+-- Policies do not apply to superusers or BYPASSRLS roles. FORCE makes them
+-- apply to the table owner, which is usually the role the API connects as.
+
+CREATE OR REPLACE FUNCTION app_current_org_id() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.current_org_id', true), '')::uuid
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION app_current_user_id() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.current_user_id', true), '')::uuid
+$$ LANGUAGE sql STABLE;
+
+-- app_tenant_visible reports whether a row owned by org is visible to the
+-- current tenant. Tenant tables use it for both USING and WITH CHECK:
+--
+--   ALTER TABLE t ENABLE ROW LEVEL SECURITY;
+--   ALTER TABLE t FORCE ROW LEVEL SECURITY;
+--   CREATE POLICY tenant_isolation ON t
+--       USING (app_tenant_visible(organization_id))
+--       WITH CHECK (app_tenant_visible(organization_id));
+CREATE OR REPLACE FUNCTION app_tenant_visible(org UUID) RETURNS BOOLEAN AS $$
+    SELECT app_current_org_id() IS NULL OR org = app_current_org_id()
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE organization_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_members FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_members
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE organization_invitations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_invitations FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_invitations
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE organization_ownership_transfers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_ownership_transfers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_ownership_transfers
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_ownership_transfers;
+ALTER TABLE organization_ownership_transfers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_ownership_transfers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_invitations;
+ALTER TABLE organization_invitations NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_invitations DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_members;
+ALTER TABLE organization_members NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_members DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS app_tenant_visible(UUID);
+DROP FUNCTION IF EXISTS app_current_user_id();
+DROP FUNCTION IF EXISTS app_current_org_id();
+
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Teams group members within an organization and grant them extra
+-- permissions. A team may have a parent, but a child team cannot itself have
+-- children; the repository enforces the depth when teams are created.
+CREATE TABLE organization_teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    parent_id UUID,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, name),
+    UNIQUE (organization_id, id),
+    CHECK (parent_id IS NULL OR parent_id <> id),
+    -- Deleting a parent promotes its children to top-level teams
+    FOREIGN KEY (organization_id, parent_id)
+        REFERENCES organization_teams(organization_id, id) ON DELETE SET NULL (parent_id)
+);
+
+CREATE INDEX idx_teams_parent_id ON organization_teams(parent_id);
+
+-- Team memberships reference the organization membership, so removing someone
+-- from the organization removes them from all of its teams.
+CREATE TABLE organization_team_members (
+    team_id UUID NOT NULL,
+    organization_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('maintainer', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id),
+    FOREIGN KEY (organization_id, team_id)
+        REFERENCES organization_teams(organization_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id, user_id)
+        REFERENCES organization_members(organization_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_team_members_member ON organization_team_members(organization_id, user_id);
+
+ALTER TABLE organization_teams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_teams FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_teams
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE organization_team_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_team_members FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_team_members
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS organization_team_members;
+DROP TABLE IF EXISTS organization_teams;
+
+-- +goose StatementEnd
@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    visibility TEXT NOT NULL DEFAULT 'organization' CHECK (visibility IN ('organization', 'private')),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    archived_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, name),
+    UNIQUE (organization_id, id)
+);
+
+CREATE INDEX idx_projects_org_created ON projects(organization_id, created_at);
+
+-- Per-project access. Rows reference the organization membership so leaving
+-- the organization revokes project access too.
+CREATE TABLE project_members (
+    project_id UUID NOT NULL,
+    organization_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'editor', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id),
+    FOREIGN KEY (organization_id, project_id)
+        REFERENCES projects(organization_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id, user_id)
+        REFERENCES organization_members(organization_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_project_members_member ON project_members(organization_id, user_id);
+
+ALTER TABLE projects ENABLE ROW LEVEL SECURITY;
+ALTER TABLE projects FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON projects
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE project_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE project_members FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON project_members
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Profile preferences. sync_google_profile is cleared once a user edits their
+-- name or picture so signing in with Google no longer overwrites them.
+ALTER TABLE users
+    ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC',
+    ADD COLUMN locale TEXT NOT NULL DEFAULT 'en',
+    ADD COLUMN sync_google_profile BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users
+    DROP COLUMN sync_google_profile,
+    DROP COLUMN locale,
+    DROP COLUMN timezone;
+
+-- +goose StatementEnd
@@ -0,0 +1,85 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Accounts are soft-deleted first and purged once the grace period passes.
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Rows a user created outlive them, attributed to no one.
+ALTER TABLE organizations ALTER COLUMN created_by DROP NOT NULL;
+ALTER TABLE organizations DROP CONSTRAINT organizations_created_by_fkey;
+ALTER TABLE organizations ADD CONSTRAINT organizations_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE organization_invitations ALTER COLUMN invited_by DROP NOT NULL;
+ALTER TABLE organization_invitations DROP CONSTRAINT organization_invitations_invited_by_fkey;
+ALTER TABLE organization_invitations ADD CONSTRAINT organization_invitations_invited_by_fkey
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE organization_join_links ALTER COLUMN created_by DROP NOT NULL;
+ALTER TABLE organization_join_links DROP CONSTRAINT organization_join_links_created_by_fkey;
+ALTER TABLE organization_join_links ADD CONSTRAINT organization_join_links_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE organization_domains ALTER COLUMN created_by DROP NOT NULL;
+ALTER TABLE organization_domains DROP CONSTRAINT organization_domains_created_by_fkey;
+ALTER TABLE organization_domains ADD CONSTRAINT organization_domains_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Audit events stay append-only, except that a purge may replace a deleted
+-- user's references with a pseudonym. The purging transaction opts in with
+-- SET LOCAL app.audit_anonymize = 'on' and may only touch the columns that
+-- identify people.
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND current_setting('app.audit_anonymize', true) = 'on'
+        AND NEW.id = OLD.id
+        AND NEW.organization_id = OLD.organization_id
+        AND NEW.action = OLD.action
+        AND NEW.target_type = OLD.target_type
+        AND NEW.request_id IS NOT DISTINCT FROM OLD.request_id
+        AND NEW.created_at = OLD.created_at
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE organization_domains DROP CONSTRAINT organization_domains_created_by_fkey;
+ALTER TABLE organization_domains ADD CONSTRAINT organization_domains_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id);
+ALTER TABLE organization_domains ALTER COLUMN created_by SET NOT NULL;
+
+ALTER TABLE organization_join_links DROP CONSTRAINT organization_join_links_created_by_fkey;
+ALTER TABLE organization_join_links ADD CONSTRAINT organization_join_links_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id);
+ALTER TABLE organization_join_links ALTER COLUMN created_by SET NOT NULL;
+
+ALTER TABLE organization_invitations DROP CONSTRAINT organization_invitations_invited_by_fkey;
+ALTER TABLE organization_invitations ADD CONSTRAINT organization_invitations_invited_by_fkey
+    FOREIGN KEY (invited_by) REFERENCES users(id);
+ALTER TABLE organization_invitations ALTER COLUMN invited_by SET NOT NULL;
+
+ALTER TABLE organizations DROP CONSTRAINT organizations_created_by_fkey;
+ALTER TABLE organizations ADD CONSTRAINT organizations_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id);
+ALTER TABLE organizations ALTER COLUMN created_by SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
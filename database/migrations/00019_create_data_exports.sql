@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Personal data exports. A worker claims pending rows, assembles the archive
+-- from every registered exporter and stores it until expires_at.
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    error TEXT,
+    archive BYTEA,
+    size_bytes INTEGER,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_data_exports_user_created ON data_exports(user_id, created_at DESC);
+CREATE INDEX idx_data_exports_pending ON data_exports(created_at) WHERE status IN ('pending', 'running');
+
+-- One export in flight per user
+CREATE UNIQUE INDEX idx_data_exports_user_active ON data_exports(user_id) WHERE status IN ('pending', 'running');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS data_exports;
+
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Tenant isolation fails closed: a statement that sets neither a tenant nor
+-- the bypass sees no tenant rows and cannot write any. Work that spans
+-- organizations (sign-in, cross-organization listings, background jobs) sets
+-- app.bypass_rls for its transaction instead of relying on an unset tenant.
+CREATE OR REPLACE FUNCTION app_rls_bypassed() RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on'
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION app_tenant_visible(org UUID) RETURNS BOOLEAN AS $$
+    SELECT app_rls_bypassed() OR org = app_current_org_id()
+$$ LANGUAGE sql STABLE;
+
+-- No policy scopes rows by user
+DROP FUNCTION IF EXISTS app_current_user_id();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION app_current_user_id() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.current_user_id', true), '')::uuid
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION app_tenant_visible(org UUID) RETURNS BOOLEAN AS $$
+    SELECT app_current_org_id() IS NULL OR org = app_current_org_id()
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS app_rls_bypassed();
+
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Report a missing owner under the trigger's name so the API can recognise
+-- the violation without matching on the message.
+CREATE OR REPLACE FUNCTION organization_members_require_owner() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM organizations WHERE id = OLD.organization_id)
+       AND NOT EXISTS (
+           SELECT 1 FROM organization_members
+           WHERE organization_id = OLD.organization_id AND role = 'owner'
+       ) THEN
+        RAISE EXCEPTION 'organization % must keep at least one owner', OLD.organization_id
+            USING ERRCODE = 'check_violation',
+                  CONSTRAINT = 'organization_members_require_owner',
+                  TABLE = 'organization_members';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION organization_members_require_owner() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM organizations WHERE id = OLD.organization_id)
+       AND NOT EXISTS (
+           SELECT 1 FROM organization_members
+           WHERE organization_id = OLD.organization_id AND role = 'owner'
+       ) THEN
+        RAISE EXCEPTION 'organization % must keep at least one owner', OLD.organization_id
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose StatementEnd
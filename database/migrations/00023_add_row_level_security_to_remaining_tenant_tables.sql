@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Tenant isolation for the remaining tables keyed by organization_id. Lookups
+-- that must see other organizations' rows (slug redirects, verified domain
+-- claims, join link tokens) run outside any tenant with app.bypass_rls set.
+
+ALTER TABLE organization_join_links ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_join_links FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_join_links
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE organization_domains ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_domains FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_domains
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE organization_roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_roles FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_roles
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE audit_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON audit_events
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+ALTER TABLE organization_slug_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_slug_history FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON organization_slug_history
+    USING (app_tenant_visible(organization_id))
+    WITH CHECK (app_tenant_visible(organization_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_slug_history;
+ALTER TABLE organization_slug_history NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_slug_history DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON audit_events;
+ALTER TABLE audit_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_events DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_roles;
+ALTER TABLE organization_roles NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_roles DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_domains;
+ALTER TABLE organization_domains NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_domains DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON organization_join_links;
+ALTER TABLE organization_join_links NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_join_links DISABLE ROW LEVEL SECURITY;
+
+-- +goose StatementEnd
@@ -18,6 +18,11 @@ resource "aws_dynamodb_table" "pings" {
     type = "S"
   }
 
+  attribute {
+    name = "ip"
+    type = "S"
+  }
+
   global_secondary_index {
     name            = "pk-timestamp-index"
     hash_key        = "pk"
@@ -25,6 +30,14 @@ resource "aws_dynamodb_table" "pings" {
     projection_type = "ALL"
   }
 
+  # Looks up one address's pings, e.g. for personal data exports
+  global_secondary_index {
+    name            = "ip-timestamp-index"
+    hash_key        = "ip"
+    range_key       = "timestamp"
+    projection_type = "ALL"
+  }
+
   tags = var.is_local ? {} : {
     Environment = "production"
   }
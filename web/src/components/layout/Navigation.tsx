@@ -3,6 +3,7 @@ import { useAuth } from '../../hooks/useAuth'
 import { GoogleLoginButton } from '../auth/GoogleLoginButton'
 import { UserAvatar } from '../auth/UserAvatar'
 import { OrgSwitcher } from '../organization/OrgSwitcher'
+import { NotificationBell } from '../notifications/NotificationBell'
 
 export function Navigation() {
   const { user, isLoading } = useAuth()
@@ -36,7 +37,10 @@ export function Navigation() {
           {isLoading ? (
             <span className="text-sm text-gray-500">Loading...</span>
           ) : user ? (
-            <UserAvatar user={user} />
+            <div className="flex items-center gap-4">
+              <NotificationBell />
+              <UserAvatar user={user} />
+            </div>
           ) : (
             <GoogleLoginButton />
           )}
@@ -0,0 +1,54 @@
+import { useState } from 'react'
+import { useNotifications } from '../../hooks/useNotifications'
+
+export function NotificationBell() {
+  const { notifications, unreadCount, markRead, markAllRead } = useNotifications()
+  const [isOpen, setIsOpen] = useState(false)
+
+  return (
+    <div className="relative">
+      <button
+        onClick={() => setIsOpen((open) => !open)}
+        className="relative px-2 py-1 text-sm text-gray-400 hover:text-white transition-colors"
+        aria-label="Notifications"
+      >
+        Notifications
+        {unreadCount > 0 && (
+          <span className="ml-1 px-1.5 py-0.5 bg-blue-600 text-white text-xs rounded-full">
+            {unreadCount}
+          </span>
+        )}
+      </button>
+
+      {isOpen && (
+        <div className="absolute right-0 mt-2 w-80 bg-gray-800 border border-gray-700 rounded-lg shadow-lg z-10">
+          <div className="flex items-center justify-between px-4 py-2 border-b border-gray-700">
+            <span className="text-sm font-medium text-white">Notifications</span>
+            {unreadCount > 0 && (
+              <button onClick={() => markAllRead()} className="text-xs text-blue-400 hover:underline">
+                Mark all read
+              </button>
+            )}
+          </div>
+          {notifications.length === 0 ? (
+            <p className="px-4 py-6 text-sm text-gray-400 text-center">You're all caught up.</p>
+          ) : (
+            <ul className="max-h-96 overflow-y-auto divide-y divide-gray-700">
+              {notifications.map((n) => (
+                <li
+                  key={n.id}
+                  onClick={() => !n.read_at && markRead(n.id)}
+                  className={`px-4 py-3 ${n.read_at ? '' : 'bg-gray-700/50 cursor-pointer'}`}
+                >
+                  <p className="text-sm text-white">{n.title}</p>
+                  {n.body && <p className="text-xs text-gray-400 mt-1">{n.body}</p>}
+                  <p className="text-xs text-gray-500 mt-1">{new Date(n.created_at).toLocaleString()}</p>
+                </li>
+              ))}
+            </ul>
+          )}
+        </div>
+      )}
+    </div>
+  )
+}
@@ -1,6 +1,8 @@
 import { useState } from 'react'
 import { useAuth } from '../../hooks/useAuth'
 import { useMembers } from '../../hooks/useMembers'
+import { usePresence } from '../../hooks/usePresence'
+import { useDebouncedValue } from '../../hooks/useDebouncedValue'
 import { Role } from '../../types/organization'
 
 interface MemberListProps {
@@ -10,7 +12,10 @@ interface MemberListProps {
 
 export function MemberList({ orgId, currentUserRole }: MemberListProps) {
   const { user } = useAuth()
-  const { members, isLoading, error, updateRole, removeMember } = useMembers(orgId)
+  const [search, setSearch] = useState('')
+  const query = useDebouncedValue(search.trim(), 300)
+  const { members, isLoading, error, hasMore, loadMore, updateRole, removeMember } = useMembers(orgId, query)
+  const online = usePresence(orgId)
   const [actionError, setActionError] = useState<string | null>(null)
 
   const canManageMembers = currentUserRole === 'owner' || currentUserRole === 'admin'
@@ -37,21 +42,20 @@ export function MemberList({ orgId, currentUserRole }: MemberListProps) {
     }
   }
 
-  if (isLoading) {
-    return <div className="text-gray-400">Loading members...</div>
-  }
+  const renderMembers = () => {
+    if (isLoading) {
+      return <div className="text-gray-400">Loading members...</div>
+    }
 
-  if (error) {
-    return <div className="text-red-400">{error}</div>
-  }
+    if (error) {
+      return <div className="text-red-400">{error}</div>
+    }
 
-  return (
-    <div>
-      {actionError && (
-        <div className="mb-4 p-3 bg-red-900/50 border border-red-700 rounded-md text-red-300 text-sm">
-          {actionError}
-        </div>
-      )}
+    if (members.length === 0) {
+      return <div className="text-gray-400">No members match "{query}".</div>
+    }
+
+    return (
       <div className="bg-gray-800 rounded-lg overflow-hidden">
         <ul className="divide-y divide-gray-700">
           {members.map((member) => {
@@ -79,6 +83,9 @@ export function MemberList({ orgId, currentUserRole }: MemberListProps) {
                       {isCurrentUser && (
                         <span className="ml-2 text-xs text-gray-500">(you)</span>
                       )}
+                      {online.includes(member.user_id) && (
+                        <span className="ml-2 text-xs text-green-400">online</span>
+                      )}
                     </p>
                     <p className="text-sm text-gray-400">{member.email}</p>
                   </div>
@@ -118,6 +125,32 @@ export function MemberList({ orgId, currentUserRole }: MemberListProps) {
           })}
         </ul>
       </div>
+    )
+  }
+
+  return (
+    <div>
+      {actionError && (
+        <div className="mb-4 p-3 bg-red-900/50 border border-red-700 rounded-md text-red-300 text-sm">
+          {actionError}
+        </div>
+      )}
+      <input
+        type="search"
+        value={search}
+        onChange={(e) => setSearch(e.target.value)}
+        placeholder="Search by name or email"
+        className="mb-4 w-full max-w-md px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-white placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+      />
+      {renderMembers()}
+      {!isLoading && !error && hasMore && (
+        <button
+          onClick={() => loadMore().catch((err) => setActionError(err.message))}
+          className="mt-4 text-sm text-blue-400 hover:text-blue-300 transition-colors"
+        >
+          Load more
+        </button>
+      )}
     </div>
   )
 }
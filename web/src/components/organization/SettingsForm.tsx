@@ -0,0 +1,180 @@
+import { useState } from 'react'
+import { OrganizationSettings, Role } from '../../types/organization'
+
+interface SettingsFormProps {
+  orgId: string
+  settings: OrganizationSettings
+  canEdit: boolean
+  onSaved: () => Promise<void>
+}
+
+const inputClass =
+  'w-full max-w-md px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-white placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent disabled:opacity-50 disabled:cursor-not-allowed'
+
+export function SettingsForm({ orgId, settings, canEdit, onSaved }: SettingsFormProps) {
+  const [logoUrl, setLogoUrl] = useState(settings.logo_url)
+  const [description, setDescription] = useState(settings.description)
+  const [website, setWebsite] = useState(settings.website)
+  const [defaultRole, setDefaultRole] = useState<Role>(settings.default_member_role)
+  const [expiryDays, setExpiryDays] = useState(settings.invitation_expiry_days)
+  const [domains, setDomains] = useState(settings.allowed_email_domains.join(', '))
+  const [requireMfa, setRequireMfa] = useState(settings.require_mfa)
+  const [isSaving, setIsSaving] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+  const [success, setSuccess] = useState<string | null>(null)
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault()
+
+    setIsSaving(true)
+    setError(null)
+    setSuccess(null)
+
+    try {
+      const res = await fetch(`/api/organizations/${orgId}/settings`, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/merge-patch+json' },
+        body: JSON.stringify({
+          logo_url: logoUrl,
+          description,
+          website,
+          default_member_role: defaultRole,
+          invitation_expiry_days: expiryDays,
+          allowed_email_domains: domains
+            .split(',')
+            .map((d) => d.trim())
+            .filter(Boolean),
+          require_mfa: requireMfa,
+        }),
+      })
+
+      if (!res.ok) {
+        const data = await res.json()
+        throw new Error(data.message || 'Failed to update settings')
+      }
+
+      await onSaved()
+      setSuccess('Settings updated successfully')
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to update settings')
+    } finally {
+      setIsSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={handleSubmit} className="bg-gray-800 rounded-lg p-6">
+      <h2 className="text-lg font-medium text-white mb-4">Profile & Policies</h2>
+      <div className="mb-4">
+        <label htmlFor="description" className="block text-sm font-medium text-gray-300 mb-2">
+          Description
+        </label>
+        <textarea
+          id="description"
+          value={description}
+          onChange={(e) => setDescription(e.target.value)}
+          disabled={!canEdit}
+          rows={3}
+          className={inputClass}
+        />
+      </div>
+      <div className="mb-4">
+        <label htmlFor="website" className="block text-sm font-medium text-gray-300 mb-2">
+          Website
+        </label>
+        <input
+          type="url"
+          id="website"
+          value={website}
+          onChange={(e) => setWebsite(e.target.value)}
+          disabled={!canEdit}
+          placeholder="https://example.com"
+          className={inputClass}
+        />
+      </div>
+      <div className="mb-4">
+        <label htmlFor="logo_url" className="block text-sm font-medium text-gray-300 mb-2">
+          Logo URL
+        </label>
+        <input
+          type="url"
+          id="logo_url"
+          value={logoUrl}
+          onChange={(e) => setLogoUrl(e.target.value)}
+          disabled={!canEdit}
+          placeholder="https://example.com/logo.png"
+          className={inputClass}
+        />
+      </div>
+      <div className="mb-4 flex gap-6">
+        <div>
+          <label htmlFor="default_role" className="block text-sm font-medium text-gray-300 mb-2">
+            Default role for new members
+          </label>
+          <select
+            id="default_role"
+            value={defaultRole}
+            onChange={(e) => setDefaultRole(e.target.value as Role)}
+            disabled={!canEdit}
+            className="px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-gray-300 focus:outline-none focus:ring-2 focus:ring-blue-500 disabled:opacity-50"
+          >
+            <option value="member">Member</option>
+            <option value="admin">Admin</option>
+          </select>
+        </div>
+        <div>
+          <label htmlFor="expiry_days" className="block text-sm font-medium text-gray-300 mb-2">
+            Invitation expiry (days)
+          </label>
+          <input
+            type="number"
+            id="expiry_days"
+            min={1}
+            max={30}
+            value={expiryDays}
+            onChange={(e) => setExpiryDays(Number(e.target.value))}
+            disabled={!canEdit}
+            className="w-24 px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-white focus:outline-none focus:ring-2 focus:ring-blue-500 disabled:opacity-50"
+          />
+        </div>
+      </div>
+      <div className="mb-4">
+        <label htmlFor="domains" className="block text-sm font-medium text-gray-300 mb-2">
+          Allowed email domains
+        </label>
+        <input
+          type="text"
+          id="domains"
+          value={domains}
+          onChange={(e) => setDomains(e.target.value)}
+          disabled={!canEdit}
+          placeholder="example.com, example.org"
+          className={inputClass}
+        />
+        <p className="mt-1 text-xs text-gray-500">Leave empty to allow any domain.</p>
+      </div>
+      <div className="mb-4">
+        <label className="flex items-center gap-2 text-sm text-gray-300">
+          <input
+            type="checkbox"
+            checked={requireMfa}
+            onChange={(e) => setRequireMfa(e.target.checked)}
+            disabled={!canEdit}
+          />
+          Require multi-factor authentication
+        </label>
+      </div>
+      {canEdit && (
+        <button
+          type="submit"
+          disabled={isSaving}
+          className="px-4 py-2 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-600 disabled:cursor-not-allowed text-white text-sm font-medium rounded-md transition-colors"
+        >
+          {isSaving ? 'Saving...' : 'Save Settings'}
+        </button>
+      )}
+      {error && <p className="mt-2 text-sm text-red-400">{error}</p>}
+      {success && <p className="mt-2 text-sm text-green-400">{success}</p>}
+    </form>
+  )
+}
@@ -0,0 +1,161 @@
+import { useState, useEffect, useCallback } from 'react'
+import { useAuth } from '../../hooks/useAuth'
+import { useMembers } from '../../hooks/useMembers'
+import { useDebouncedValue } from '../../hooks/useDebouncedValue'
+import { Member, OwnershipTransfer } from '../../types/organization'
+
+interface TransferOwnershipProps {
+  orgId: string
+  canTransfer: boolean
+  onTransferred: () => void
+}
+
+export function TransferOwnership({ orgId, canTransfer, onTransferred }: TransferOwnershipProps) {
+  const { user } = useAuth()
+  const [pending, setPending] = useState<OwnershipTransfer | null>(null)
+  const [search, setSearch] = useState('')
+  const [notify, setNotify] = useState(true)
+  const query = useDebouncedValue(search.trim(), 300)
+  const { members, isLoading } = useMembers(canTransfer && query ? orgId : undefined, query)
+  const [isBusy, setIsBusy] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+
+  const fetchPending = useCallback(async () => {
+    const res = await fetch(`/api/organizations/${orgId}/transfer`)
+    if (res.ok) {
+      const data = await res.json()
+      setPending(data.data)
+    } else {
+      setPending(null)
+    }
+  }, [orgId])
+
+  useEffect(() => {
+    fetchPending()
+  }, [fetchPending])
+
+  const candidates = members.filter((m) => m.user_id !== user?.id && m.role !== 'owner')
+
+  const request = async (method: string, path: string, body?: unknown) => {
+    setIsBusy(true)
+    setError(null)
+    try {
+      const res = await fetch(`/api/organizations/${orgId}/transfer${path}`, {
+        method,
+        headers: body ? { 'Content-Type': 'application/json' } : undefined,
+        body: body ? JSON.stringify(body) : undefined,
+      })
+      if (!res.ok) {
+        const data = await res.json()
+        throw new Error(data.message || 'Ownership transfer failed')
+      }
+      return true
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Ownership transfer failed')
+      return false
+    } finally {
+      setIsBusy(false)
+    }
+  }
+
+  const handleInitiate = async (member: Member) => {
+    if (!confirm(`Ask ${member.name} to take over ownership? You will become an admin once they accept.`)) return
+    if (await request('POST', '', { new_owner_id: member.user_id, notify })) {
+      setSearch('')
+      await fetchPending()
+    }
+  }
+
+  const handleCancel = async () => {
+    if (await request('DELETE', '')) {
+      setPending(null)
+    }
+  }
+
+  const handleAccept = async () => {
+    if (!confirm('Accept ownership of this organization?')) return
+    if (await request('POST', '/accept')) {
+      setPending(null)
+      onTransferred()
+    }
+  }
+
+  if (pending) {
+    const isRecipient = pending.to_user_id === user?.id
+    const expires = new Date(pending.expires_at).toLocaleDateString()
+
+    return (
+      <div>
+        <h3 className="text-sm font-medium text-white mb-2">Transfer Ownership</h3>
+        <p className="text-sm text-gray-300">
+          {isRecipient
+            ? `${pending.from_name} has asked you to become the owner. This request expires on ${expires}.`
+            : `Waiting for ${pending.to_name} (${pending.to_email}) to accept ownership. Expires on ${expires}.`}
+        </p>
+        {error && <p className="mt-2 text-sm text-red-400">{error}</p>}
+        <div className="mt-3 flex gap-3">
+          {isRecipient && (
+            <button
+              onClick={handleAccept}
+              disabled={isBusy}
+              className="px-4 py-2 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-600 text-white text-sm font-medium rounded-md transition-colors"
+            >
+              Accept Ownership
+            </button>
+          )}
+          <button
+            onClick={handleCancel}
+            disabled={isBusy}
+            className="px-4 py-2 bg-gray-700 hover:bg-gray-600 disabled:bg-gray-600 text-white text-sm font-medium rounded-md transition-colors"
+          >
+            {isRecipient ? 'Decline' : 'Cancel Transfer'}
+          </button>
+        </div>
+      </div>
+    )
+  }
+
+  if (!canTransfer) {
+    return null
+  }
+
+  return (
+    <div>
+      <h3 className="text-sm font-medium text-white mb-2">Transfer Ownership</h3>
+      <input
+        type="search"
+        value={search}
+        onChange={(e) => setSearch(e.target.value)}
+        placeholder="Find a member by name or email"
+        className="w-full max-w-md px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-white placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+      />
+      <label className="mt-2 flex items-center gap-2 text-sm text-gray-400">
+        <input type="checkbox" checked={notify} onChange={(e) => setNotify(e.target.checked)} />
+        Email them about the request
+      </label>
+      {error && <p className="mt-2 text-sm text-red-400">{error}</p>}
+      {query && !isLoading && (
+        <ul className="mt-2 max-w-md divide-y divide-gray-700 bg-gray-900 rounded-md">
+          {candidates.length === 0 && (
+            <li className="px-3 py-2 text-sm text-gray-400">No matching members</li>
+          )}
+          {candidates.map((member) => (
+            <li key={member.id} className="px-3 py-2 flex items-center justify-between">
+              <div>
+                <p className="text-sm text-white">{member.name}</p>
+                <p className="text-xs text-gray-400">{member.email}</p>
+              </div>
+              <button
+                onClick={() => handleInitiate(member)}
+                disabled={isBusy}
+                className="px-3 py-1 bg-red-600 hover:bg-red-700 disabled:bg-gray-600 text-white text-xs font-medium rounded-md transition-colors"
+              >
+                Request transfer
+              </button>
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  )
+}
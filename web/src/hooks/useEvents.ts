@@ -0,0 +1,47 @@
+import { useEffect, useRef } from 'react'
+import { ServerEvent } from '../types/event'
+
+type Listener = (event: ServerEvent) => void
+
+// Every hook shares one stream, open while any of them is mounted. The
+// browser reconnects on its own and resumes from the last event it saw.
+const listeners = new Set<Listener>()
+let source: EventSource | null = null
+
+function connect() {
+  if (source) return
+  source = new EventSource('/api/events/stream')
+  source.onmessage = (e) => {
+    const event: ServerEvent = JSON.parse(e.data)
+    listeners.forEach((listener) => listener(event))
+  }
+}
+
+function disconnect() {
+  if (listeners.size > 0 || !source) return
+  source.close()
+  source = null
+}
+
+// useEvents calls onEvent for each server event while enabled. A 'reset'
+// event means some were missed and the caller should reload.
+export function useEvents(onEvent: Listener, enabled = true) {
+  const handler = useRef(onEvent)
+
+  useEffect(() => {
+    handler.current = onEvent
+  })
+
+  useEffect(() => {
+    if (!enabled) return
+
+    const listener: Listener = (event) => handler.current(event)
+    listeners.add(listener)
+    connect()
+
+    return () => {
+      listeners.delete(listener)
+      disconnect()
+    }
+  }, [enabled])
+}
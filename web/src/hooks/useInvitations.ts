@@ -1,5 +1,6 @@
 import { useState, useCallback, useEffect } from 'react'
 import { Invitation, InvitationWithDetails, Role } from '../types/organization'
+import { useEvents } from './useEvents'
 
 interface UseOrgInvitationsResult {
   invitations: Invitation[]
@@ -92,6 +93,12 @@ export function useOrgInvitations(orgId: string | undefined): UseOrgInvitationsR
     fetchInvitations()
   }, [fetchInvitations])
 
+  useEvents((event) => {
+    if (event.type === 'reset' || (event.organization_id === orgId && event.type.startsWith('invitation.'))) {
+      fetchInvitations()
+    }
+  }, !!orgId)
+
   return {
     invitations,
     isLoading,
@@ -156,6 +163,14 @@ export function useMyInvitations(): UseMyInvitationsResult {
     fetchInvitations()
   }, [fetchInvitations])
 
+  // Invitations to the current user also arrive for organizations they
+  // manage; reloading for those is harmless
+  useEvents((event) => {
+    if (event.type === 'reset' || event.type.startsWith('invitation.')) {
+      fetchInvitations()
+    }
+  })
+
   return {
     invitations,
     isLoading,
@@ -1,17 +1,29 @@
 import { useState, useCallback, useEffect } from 'react'
-import { Member, Role } from '../types/organization'
+import { Member, Page, Role } from '../types/organization'
+import { useEvents } from './useEvents'
 
 interface UseMembersResult {
   members: Member[]
   isLoading: boolean
   error: string | null
+  hasMore: boolean
+  loadMore: () => Promise<void>
   updateRole: (userId: string, role: Role) => Promise<void>
   removeMember: (userId: string) => Promise<void>
   refetch: () => Promise<void>
 }
 
-export function useMembers(orgId: string | undefined): UseMembersResult {
+function membersUrl(orgId: string, query: string, cursor?: string): string {
+  const params = new URLSearchParams()
+  if (query) params.set('q', query)
+  if (cursor) params.set('cursor', cursor)
+  const qs = params.toString()
+  return `/api/organizations/${orgId}/members${qs ? `?${qs}` : ''}`
+}
+
+export function useMembers(orgId: string | undefined, query = ''): UseMembersResult {
   const [members, setMembers] = useState<Member[]>([])
+  const [nextCursor, setNextCursor] = useState<string | null>(null)
   const [isLoading, setIsLoading] = useState(true)
   const [error, setError] = useState<string | null>(null)
 
@@ -26,26 +38,40 @@ export function useMembers(orgId: string | undefined): UseMembersResult {
     setError(null)
 
     try {
-      const res = await fetch(`/api/organizations/${orgId}/members`)
+      const res = await fetch(membersUrl(orgId, query))
       if (!res.ok) {
         throw new Error('Failed to fetch members')
       }
-      const data = await res.json()
+      const data: Page<Member> = await res.json()
       setMembers(data.data || [])
+      setNextCursor(data.next_cursor ?? null)
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to fetch members')
       setMembers([])
+      setNextCursor(null)
     } finally {
       setIsLoading(false)
     }
-  }, [orgId])
+  }, [orgId, query])
+
+  const loadMore = useCallback(async () => {
+    if (!orgId || !nextCursor) return
+
+    const res = await fetch(membersUrl(orgId, query, nextCursor))
+    if (!res.ok) {
+      throw new Error('Failed to fetch members')
+    }
+    const data: Page<Member> = await res.json()
+    setMembers((prev) => [...prev, ...(data.data || [])])
+    setNextCursor(data.next_cursor ?? null)
+  }, [orgId, query, nextCursor])
 
   const updateRole = useCallback(async (userId: string, role: Role) => {
     if (!orgId) return
 
     const res = await fetch(`/api/organizations/${orgId}/members/${userId}`, {
-      method: 'PUT',
-      headers: { 'Content-Type': 'application/json' },
+      method: 'PATCH',
+      headers: { 'Content-Type': 'application/merge-patch+json' },
       body: JSON.stringify({ role }),
     })
 
@@ -80,10 +106,18 @@ export function useMembers(orgId: string | undefined): UseMembersResult {
     fetchMembers()
   }, [fetchMembers])
 
+  useEvents((event) => {
+    if (event.type === 'reset' || (event.organization_id === orgId && event.type.startsWith('member.'))) {
+      fetchMembers()
+    }
+  }, !!orgId)
+
   return {
     members,
     isLoading,
     error,
+    hasMore: nextCursor !== null,
+    loadMore,
     updateRole,
     removeMember,
     refetch: fetchMembers,
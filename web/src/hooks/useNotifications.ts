@@ -0,0 +1,86 @@
+import { useState, useCallback, useEffect } from 'react'
+import { Notification } from '../types/notification'
+import { useEvents } from './useEvents'
+
+interface UseNotificationsResult {
+  notifications: Notification[]
+  unreadCount: number
+  isLoading: boolean
+  error: string | null
+  markRead: (id: string) => Promise<void>
+  markAllRead: () => Promise<void>
+  refetch: () => Promise<void>
+}
+
+export function useNotifications(): UseNotificationsResult {
+  const [notifications, setNotifications] = useState<Notification[]>([])
+  const [unreadCount, setUnreadCount] = useState(0)
+  const [isLoading, setIsLoading] = useState(true)
+  const [error, setError] = useState<string | null>(null)
+
+  const fetchNotifications = useCallback(async () => {
+    setError(null)
+
+    try {
+      const [listRes, countRes] = await Promise.all([
+        fetch('/api/notifications?limit=20'),
+        fetch('/api/notifications/unread-count'),
+      ])
+      if (!listRes.ok || !countRes.ok) {
+        throw new Error('Failed to fetch notifications')
+      }
+      const list = await listRes.json()
+      const count = await countRes.json()
+      setNotifications(list.data || [])
+      setUnreadCount(count.data?.count ?? 0)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to fetch notifications')
+    } finally {
+      setIsLoading(false)
+    }
+  }, [])
+
+  const markRead = useCallback(async (id: string) => {
+    const res = await fetch(`/api/notifications/${id}/read`, { method: 'POST' })
+    if (!res.ok) {
+      const data = await res.json()
+      throw new Error(data.message || 'Failed to mark notification read')
+    }
+
+    const data = await res.json()
+    setNotifications((prev) => prev.map((n) => (n.id === id ? data.data : n)))
+    setUnreadCount((c) => Math.max(0, c - 1))
+  }, [])
+
+  const markAllRead = useCallback(async () => {
+    const res = await fetch('/api/notifications/read-all', { method: 'POST' })
+    if (!res.ok) {
+      const data = await res.json()
+      throw new Error(data.message || 'Failed to mark notifications read')
+    }
+
+    const now = new Date().toISOString()
+    setNotifications((prev) => prev.map((n) => (n.read_at ? n : { ...n, read_at: now })))
+    setUnreadCount(0)
+  }, [])
+
+  useEffect(() => {
+    fetchNotifications()
+  }, [fetchNotifications])
+
+  useEvents((event) => {
+    if (event.type === 'reset' || event.type.startsWith('notification.')) {
+      fetchNotifications()
+    }
+  })
+
+  return {
+    notifications,
+    unreadCount,
+    isLoading,
+    error,
+    markRead,
+    markAllRead,
+    refetch: fetchNotifications,
+  }
+}
@@ -1,6 +1,7 @@
 import { createContext, useContext, useEffect, useState, ReactNode, useCallback } from 'react'
 import { OrganizationWithRole } from '../types/organization'
 import { useAuth } from './useAuth'
+import { useEvents } from './useEvents'
 
 interface OrganizationContextValue {
   organizations: OrganizationWithRole[]
@@ -15,7 +16,7 @@ const OrganizationContext = createContext<OrganizationContextValue | null>(null)
 const ACTIVE_ORG_KEY = 'activeOrgId'
 
 export function OrganizationProvider({ children }: { children: ReactNode }) {
-  const { isAuthenticated, isLoading: authLoading } = useAuth()
+  const { user, isAuthenticated, isLoading: authLoading } = useAuth()
   const [organizations, setOrganizations] = useState<OrganizationWithRole[]>([])
   const [currentOrg, setCurrentOrg] = useState<OrganizationWithRole | null>(null)
   const [isLoading, setIsLoading] = useState(true)
@@ -71,6 +72,13 @@ export function OrganizationProvider({ children }: { children: ReactNode }) {
     }
   }, [authLoading, fetchOrganizations])
 
+  // Joining, leaving or a role change for the current user changes the list
+  useEvents((event) => {
+    if (event.type === 'reset' || (event.user_id === user?.id && event.type.startsWith('member.'))) {
+      fetchOrganizations()
+    }
+  }, isAuthenticated)
+
   return (
     <OrganizationContext.Provider
       value={{
@@ -0,0 +1,67 @@
+import { useEffect, useState } from 'react'
+
+interface GatewayFrame {
+  type: 'joined' | 'left' | 'presence' | 'message' | 'error'
+  ref?: string
+  room?: string
+  from?: string
+  data?: { users?: string[] }
+  message?: string
+}
+
+const RECONNECT_DELAY_MS = 3000
+
+function gatewayUrl(): string {
+  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+  return `${protocol}//${window.location.host}/api/ws`
+}
+
+// usePresence returns the IDs of users connected to the organization's room,
+// or a project's room when projectId is given.
+export function usePresence(orgId: string | undefined, projectId?: string): string[] {
+  const [users, setUsers] = useState<string[]>([])
+
+  useEffect(() => {
+    if (!orgId) return
+
+    let socket: WebSocket | null = null
+    let room: string | null = null
+    let retry: ReturnType<typeof setTimeout> | undefined
+    let closed = false
+
+    const connect = () => {
+      socket = new WebSocket(gatewayUrl())
+
+      socket.onopen = () => {
+        socket?.send(JSON.stringify({ type: 'join', organization_id: orgId, project_id: projectId }))
+      }
+
+      socket.onmessage = (e) => {
+        const frame: GatewayFrame = JSON.parse(e.data)
+        if (frame.type === 'joined') room = frame.room ?? null
+        if ((frame.type === 'joined' || frame.type === 'presence') && frame.room === room) {
+          setUsers(frame.data?.users ?? [])
+        }
+        if (frame.type === 'left' && frame.room === room) {
+          setUsers([])
+        }
+      }
+
+      socket.onclose = () => {
+        room = null
+        setUsers([])
+        if (!closed) retry = setTimeout(connect, RECONNECT_DELAY_MS)
+      }
+    }
+
+    connect()
+
+    return () => {
+      closed = true
+      clearTimeout(retry)
+      socket?.close()
+    }
+  }, [orgId, projectId])
+
+  return users
+}
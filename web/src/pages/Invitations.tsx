@@ -66,7 +66,7 @@ export function Invitations() {
                   <div>
                     <h3 className="text-white font-medium">{inv.organization_name}</h3>
                     <p className="text-sm text-gray-400">
-                      Invited by {inv.invited_by_name} as{' '}
+                      Invited by {inv.invited_by_name || 'a former member'} as{' '}
                       <span className="capitalize">{inv.role}</span>
                     </p>
                     <p className="text-xs text-gray-500 mt-1">
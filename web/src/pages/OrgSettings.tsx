@@ -3,6 +3,8 @@ import { useParams, useNavigate } from 'react-router-dom'
 import { useOrganization } from '../hooks/useOrganization'
 import { MemberList } from '../components/organization/MemberList'
 import { InviteForm } from '../components/organization/InviteForm'
+import { SettingsForm } from '../components/organization/SettingsForm'
+import { TransferOwnership } from '../components/organization/TransferOwnership'
 import { OrganizationWithRole } from '../types/organization'
 
 type Tab = 'general' | 'members' | 'invitations'
@@ -14,6 +16,7 @@ export function OrgSettings() {
   const [org, setOrg] = useState<OrganizationWithRole | null>(null)
   const [activeTab, setActiveTab] = useState<Tab>('general')
   const [name, setName] = useState('')
+  const [slug, setSlug] = useState('')
   const [isSaving, setIsSaving] = useState(false)
   const [isDeleting, setIsDeleting] = useState(false)
   const [error, setError] = useState<string | null>(null)
@@ -24,6 +27,7 @@ export function OrgSettings() {
     if (foundOrg) {
       setOrg(foundOrg)
       setName(foundOrg.name)
+      setSlug(foundOrg.slug)
     }
   }, [organizations, orgId])
 
@@ -40,7 +44,8 @@ export function OrgSettings() {
 
   const handleSave = async (e: React.FormEvent) => {
     e.preventDefault()
-    if (!name.trim() || name === org.name) return
+    const isDirty = name !== org.name || slug !== org.slug
+    if (!name.trim() || !slug.trim() || !isDirty) return
 
     setIsSaving(true)
     setError(null)
@@ -48,9 +53,9 @@ export function OrgSettings() {
 
     try {
       const res = await fetch(`/api/organizations/${orgId}`, {
-        method: 'PUT',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ name }),
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/merge-patch+json' },
+        body: JSON.stringify(slug === org.slug ? { name } : { name, slug }),
       })
 
       if (!res.ok) {
@@ -175,15 +180,30 @@ export function OrgSettings() {
               />
             </div>
             <div className="mb-4">
-              <label className="block text-sm font-medium text-gray-300 mb-2">
+              <label htmlFor="slug" className="block text-sm font-medium text-gray-300 mb-2">
                 Slug
               </label>
-              <p className="text-gray-400">{org.slug}</p>
+              {canDeleteOrg ? (
+                <>
+                  <input
+                    type="text"
+                    id="slug"
+                    value={slug}
+                    onChange={(e) => setSlug(e.target.value.toLowerCase())}
+                    className="w-full max-w-md px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-white placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                  />
+                  <p className="mt-1 text-xs text-gray-500">
+                    Links using the old slug will keep working.
+                  </p>
+                </>
+              ) : (
+                <p className="text-gray-400">{org.slug}</p>
+              )}
             </div>
             {canManageMembers && (
               <button
                 type="submit"
-                disabled={isSaving || name === org.name}
+                disabled={isSaving || (name === org.name && slug === org.slug)}
                 className="px-4 py-2 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-600 disabled:cursor-not-allowed text-white text-sm font-medium rounded-md transition-colors"
               >
                 {isSaving ? 'Saving...' : 'Save Changes'}
@@ -191,6 +211,14 @@ export function OrgSettings() {
             )}
           </form>
 
+          <SettingsForm
+            key={org.updated_at}
+            orgId={org.id}
+            settings={org.settings}
+            canEdit={canManageMembers}
+            onSaved={refetch}
+          />
+
           <div className="bg-gray-800 rounded-lg p-6 border border-red-900/50">
             <h2 className="text-lg font-medium text-white mb-4">Danger Zone</h2>
             <div className="space-y-4">
@@ -207,6 +235,14 @@ export function OrgSettings() {
                   </p>
                 </div>
               )}
+              <TransferOwnership
+                orgId={org.id}
+                canTransfer={canDeleteOrg}
+                onTransferred={() => {
+                  refetch()
+                  setSuccess('You are now an owner of this organization')
+                }}
+              />
               {canDeleteOrg && (
                 <div>
                   <button
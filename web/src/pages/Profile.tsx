@@ -1,7 +1,13 @@
+import { useEffect, useState } from 'react'
+import { Link } from 'react-router-dom'
 import { useAuth } from '../hooks/useAuth'
+import { User } from '../types/user'
+
+const inputClass =
+  'w-full px-3 py-2 bg-gray-700 border border-gray-600 rounded-md text-white placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-transparent'
 
 export function Profile() {
-  const { user } = useAuth()
+  const { user, refetch } = useAuth()
 
   if (!user) {
     return null
@@ -11,13 +17,15 @@ export function Profile() {
     <div className="max-w-md mx-auto">
       <h1 className="text-2xl font-bold mb-6">Profile</h1>
 
-      <div className="bg-gray-800 rounded-lg p-6">
+      <div className="bg-gray-800 rounded-lg p-6 mb-6">
         <div className="flex items-center gap-4 mb-6">
-          <img
-            src={user.picture}
-            alt={user.name}
-            className="w-16 h-16 rounded-full"
-          />
+          {user.picture && (
+            <img
+              src={user.picture}
+              alt={user.name}
+              className="w-16 h-16 rounded-full"
+            />
+          )}
           <div>
             <h2 className="text-xl font-semibold">{user.name}</h2>
             <p className="text-gray-400">{user.email}</p>
@@ -35,6 +43,298 @@ export function Profile() {
           </div>
         </div>
       </div>
+
+      <ProfileForm key={user.updated_at} user={user} onSaved={refetch} />
+
+      <DataExport />
+
+      <DeleteAccount onDeleted={refetch} />
+    </div>
+  )
+}
+
+function ProfileForm({ user, onSaved }: { user: User; onSaved: () => Promise<void> }) {
+  const [name, setName] = useState(user.name)
+  const [picture, setPicture] = useState(user.picture ?? '')
+  const [timezone, setTimezone] = useState(user.timezone)
+  const [locale, setLocale] = useState(user.locale)
+  const [syncGoogle, setSyncGoogle] = useState(user.sync_google_profile)
+  const [isSaving, setIsSaving] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+  const [success, setSuccess] = useState<string | null>(null)
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault()
+
+    // Only send what changed so untouched Google fields keep syncing
+    const patch: Record<string, unknown> = {}
+    if (name !== user.name) patch.name = name
+    if (picture !== (user.picture ?? '')) patch.picture = picture || null
+    if (timezone !== user.timezone) patch.timezone = timezone
+    if (locale !== user.locale) patch.locale = locale
+    if (syncGoogle !== user.sync_google_profile) patch.sync_google_profile = syncGoogle
+    if (Object.keys(patch).length === 0) return
+
+    setIsSaving(true)
+    setError(null)
+    setSuccess(null)
+
+    try {
+      const res = await fetch('/api/users/me', {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/merge-patch+json' },
+        body: JSON.stringify(patch),
+      })
+
+      if (!res.ok) {
+        const data = await res.json()
+        throw new Error(data.message || 'Failed to update profile')
+      }
+
+      await onSaved()
+      setSuccess('Profile updated successfully')
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to update profile')
+    } finally {
+      setIsSaving(false)
+    }
+  }
+
+  return (
+    <form onSubmit={handleSubmit} className="bg-gray-800 rounded-lg p-6">
+      <h2 className="text-lg font-medium text-white mb-4">Edit Profile</h2>
+      <div className="mb-4">
+        <label htmlFor="name" className="block text-sm font-medium text-gray-300 mb-2">
+          Display name
+        </label>
+        <input
+          type="text"
+          id="name"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          maxLength={100}
+          className={inputClass}
+        />
+      </div>
+      <div className="mb-4">
+        <label htmlFor="picture" className="block text-sm font-medium text-gray-300 mb-2">
+          Avatar URL
+        </label>
+        <input
+          type="url"
+          id="picture"
+          value={picture}
+          onChange={(e) => setPicture(e.target.value)}
+          placeholder="https://example.com/avatar.png"
+          className={inputClass}
+        />
+      </div>
+      <div className="mb-4 flex gap-4">
+        <div className="flex-1">
+          <label htmlFor="timezone" className="block text-sm font-medium text-gray-300 mb-2">
+            Timezone
+          </label>
+          <input
+            type="text"
+            id="timezone"
+            value={timezone}
+            onChange={(e) => setTimezone(e.target.value)}
+            placeholder="Europe/Berlin"
+            className={inputClass}
+          />
+        </div>
+        <div className="w-28">
+          <label htmlFor="locale" className="block text-sm font-medium text-gray-300 mb-2">
+            Locale
+          </label>
+          <input
+            type="text"
+            id="locale"
+            value={locale}
+            onChange={(e) => setLocale(e.target.value)}
+            placeholder="en"
+            className={inputClass}
+          />
+        </div>
+      </div>
+      <div className="mb-4">
+        <label className="flex items-center gap-2 text-sm text-gray-300">
+          <input
+            type="checkbox"
+            checked={syncGoogle}
+            onChange={(e) => setSyncGoogle(e.target.checked)}
+          />
+          Update name and avatar from Google when I sign in
+        </label>
+        <p className="mt-1 text-xs text-gray-500">
+          Editing your name or avatar turns this off.
+        </p>
+      </div>
+      <button
+        type="submit"
+        disabled={isSaving}
+        className="px-4 py-2 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-600 disabled:cursor-not-allowed text-white text-sm font-medium rounded-md transition-colors"
+      >
+        {isSaving ? 'Saving...' : 'Save Profile'}
+      </button>
+      {error && <p className="mt-2 text-sm text-red-400">{error}</p>}
+      {success && <p className="mt-2 text-sm text-green-400">{success}</p>}
+    </form>
+  )
+}
+
+interface BlockingOrganization {
+  id: string
+  name: string
+  slug: string
+  member_count: number
+}
+
+function DeleteAccount({ onDeleted }: { onDeleted: () => Promise<void> }) {
+  const [isDeleting, setIsDeleting] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+  const [blocking, setBlocking] = useState<BlockingOrganization[]>([])
+
+  const handleDelete = async () => {
+    if (
+      !confirm(
+        'Delete your account? Your personal organizations are deleted with it. Sign in again within 14 days to undo.'
+      )
+    ) {
+      return
+    }
+
+    setIsDeleting(true)
+    setError(null)
+    setBlocking([])
+
+    try {
+      const res = await fetch('/api/users/me', { method: 'DELETE' })
+
+      if (!res.ok) {
+        const data = await res.json()
+        if (res.status === 409) {
+          setBlocking(data.organizations)
+        }
+        throw new Error(data.message || 'Failed to delete account')
+      }
+
+      await onDeleted()
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to delete account')
+    } finally {
+      setIsDeleting(false)
+    }
+  }
+
+  return (
+    <div className="bg-gray-800 rounded-lg p-6 mt-6 border border-red-900/50">
+      <h2 className="text-lg font-medium text-white mb-4">Danger Zone</h2>
+      <button
+        onClick={handleDelete}
+        disabled={isDeleting}
+        className="px-4 py-2 bg-red-600 hover:bg-red-700 disabled:bg-gray-600 text-white text-sm font-medium rounded-md transition-colors"
+      >
+        {isDeleting ? 'Deleting...' : 'Delete Account'}
+      </button>
+      <p className="mt-1 text-sm text-gray-400">
+        You will be signed out everywhere. Signing in again within 14 days restores your account.
+      </p>
+      {error && <p className="mt-2 text-sm text-red-400">{error}</p>}
+      {blocking.length > 0 && (
+        <ul className="mt-2 text-sm text-gray-300 list-disc list-inside">
+          {blocking.map((org) => (
+            <li key={org.id}>
+              <Link to={`/organizations/${org.id}/settings`} className="text-blue-400 hover:underline">
+                {org.name}
+              </Link>{' '}
+              ({org.member_count} members)
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  )
+}
+
+interface ExportJob {
+  id: string
+  status: 'pending' | 'running' | 'completed' | 'failed'
+  error?: string
+  size_bytes?: number
+  expires_at?: string
+  created_at: string
+}
+
+function DataExport() {
+  const [job, setJob] = useState<ExportJob | null>(null)
+  const [error, setError] = useState<string | null>(null)
+
+  useEffect(() => {
+    fetch('/api/users/me/exports')
+      .then((res) => res.json())
+      .then((data) => setJob(data.data?.[0] ?? null))
+      .catch(() => setJob(null))
+  }, [])
+
+  const inFlight = job?.status === 'pending' || job?.status === 'running'
+
+  // Poll until the worker finishes the export
+  useEffect(() => {
+    if (!job || !inFlight) return
+    const timer = setTimeout(async () => {
+      try {
+        const res = await fetch(`/api/users/me/exports/${job.id}`)
+        const data = await res.json()
+        if (res.ok) setJob(data.data)
+      } catch {
+        // Try again on the next tick
+      }
+    }, 3000)
+    return () => clearTimeout(timer)
+  }, [job, inFlight])
+
+  const handleRequest = async () => {
+    setError(null)
+    try {
+      const res = await fetch('/api/users/me/exports', { method: 'POST' })
+      const data = await res.json()
+      if (!res.ok) {
+        throw new Error(data.message || 'Failed to request export')
+      }
+      setJob(data.data)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to request export')
+    }
+  }
+
+  return (
+    <div className="bg-gray-800 rounded-lg p-6 mt-6">
+      <h2 className="text-lg font-medium text-white mb-2">Your Data</h2>
+      <p className="text-sm text-gray-400 mb-4">
+        Download a copy of your profile, memberships, invitations, sessions and activity.
+      </p>
+      {job?.status === 'completed' && (
+        <p className="mb-3 text-sm text-gray-300">
+          <a href={`/api/users/me/exports/${job.id}/download`} className="text-blue-400 hover:underline">
+            Download export
+          </a>
+          {job.expires_at && (
+            <span className="text-gray-500"> (available until {new Date(job.expires_at).toLocaleDateString()})</span>
+          )}
+        </p>
+      )}
+      {job?.status === 'failed' && (
+        <p className="mb-3 text-sm text-red-400">The last export failed. Please try again.</p>
+      )}
+      <button
+        onClick={handleRequest}
+        disabled={inFlight}
+        className="px-4 py-2 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-600 disabled:cursor-not-allowed text-white text-sm font-medium rounded-md transition-colors"
+      >
+        {inFlight ? 'Preparing export...' : 'Request Export'}
+      </button>
+      {error && <p className="mt-2 text-sm text-red-400">{error}</p>}
     </div>
   )
 }
@@ -0,0 +1,18 @@
+export type ServerEventType =
+  | 'member.added'
+  | 'member.updated'
+  | 'member.removed'
+  | 'invitation.created'
+  | 'invitation.accepted'
+  | 'invitation.declined'
+  | 'invitation.cancelled'
+  | 'notification.created'
+  | 'notification.read'
+  | 'reset'
+
+export interface ServerEvent {
+  type: ServerEventType
+  organization_id?: string
+  user_id?: string
+  data?: unknown
+}
@@ -0,0 +1,18 @@
+export type NotificationType = 'invitation' | 'role_changed' | 'removed' | 'ownership_transfer'
+
+export interface Notification {
+  id: string
+  user_id: string
+  type: NotificationType
+  organization_id: string | null
+  title: string
+  body: string
+  read_at: string | null
+  created_at: string
+}
+
+export interface NotificationPreference {
+  type: NotificationType
+  in_app: boolean
+  email: boolean
+}
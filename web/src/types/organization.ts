@@ -1,10 +1,22 @@
 export type Role = 'owner' | 'admin' | 'member'
 export type InvitationStatus = 'pending' | 'accepted' | 'declined' | 'expired'
 
+export interface OrganizationSettings {
+  version: number
+  logo_url: string
+  description: string
+  website: string
+  default_member_role: Role
+  invitation_expiry_days: number
+  allowed_email_domains: string[]
+  require_mfa: boolean
+}
+
 export interface Organization {
   id: string
   name: string
   slug: string
+  settings: OrganizationSettings
   created_at: string
   updated_at: string
 }
@@ -30,7 +42,7 @@ export interface Invitation {
   organization_id: string
   email: string
   role: Role
-  invited_by: string
+  invited_by: string | null
   status: InvitationStatus
   expires_at: string
   created_at: string
@@ -41,3 +53,24 @@ export interface InvitationWithDetails extends Invitation {
   organization_name: string
   invited_by_name: string
 }
+
+export interface OwnershipTransfer {
+  id: string
+  organization_id: string
+  from_user_id: string
+  to_user_id: string
+  expires_at: string
+  created_at: string
+  organization_name: string
+  organization_slug: string
+  from_name: string
+  from_email: string
+  to_name: string
+  to_email: string
+}
+
+export interface Page<T> {
+  data: T[]
+  next_cursor?: string
+  has_more: boolean
+}
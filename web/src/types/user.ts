@@ -3,6 +3,9 @@ export interface User {
   email: string
   name: string
   picture: string
+  timezone: string
+  locale: string
+  sync_google_profile: boolean
   created_at: string
   updated_at: string
 }
@@ -14,6 +14,7 @@ export default defineConfig({
       '/api': {
         target: 'http://api:8080',
         changeOrigin: true,
+        ws: true,
       },
       '/auth': {
         target: 'http://api:8080',